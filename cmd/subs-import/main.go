@@ -0,0 +1,9 @@
+package main
+
+import "online-subs/internal/initializers"
+
+// Импорт подписок из CSV в обход HTTP API:
+// go run cmd/subs-import/main.go -file subs.csv -dry-run
+func main() {
+	initializers.RunCSVImport()
+}
@@ -8,4 +8,187 @@ CREATE TABLE IF NOT EXISTS subscriptions (
     CONSTRAINT start_before_end CHECK (end_date IS NULL OR start_date <= end_date)
 );
 CREATE UNIQUE INDEX IF NOT EXISTS ux_subs_service_user_start ON subscriptions(service, user_id, start_date);
-CREATE INDEX IF NOT EXISTS ix_subs_period ON subscriptions(start_date, end_date);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS ix_subs_period ON subscriptions(start_date, end_date);
+CREATE INDEX IF NOT EXISTS ix_subs_cost ON subscriptions(cost);
+CREATE INDEX IF NOT EXISTS ix_subs_user ON subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS ix_subs_end_date ON subscriptions(end_date);
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS ix_subs_service_trgm ON subscriptions USING gin (service gin_trgm_ops);
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    user_id UUID PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS price_changes (
+    id CHAR(40) PRIMARY KEY,
+    subscription_id CHAR(40) NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    cost INTEGER NOT NULL CHECK (cost >= 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_price_changes_sub_date ON price_changes(subscription_id, effective_date);
+CREATE TABLE IF NOT EXISTS budgets (
+    id CHAR(40) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    thresholds VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_budgets_user_category ON budgets(user_id, category);
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id CHAR(40) PRIMARY KEY,
+    budget_id CHAR(40) NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    month DATE NOT NULL,
+    threshold INTEGER NOT NULL,
+    spend BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_budget_alerts_month ON budget_alerts(budget_id, month, threshold);
+CREATE INDEX IF NOT EXISTS idx_budget_alerts_user_id ON budget_alerts(user_id);
+CREATE TABLE IF NOT EXISTS categories (
+    id CHAR(40) PRIMARY KEY,
+    parent_id CHAR(40) REFERENCES categories(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_categories_parent_name ON categories (COALESCE(parent_id, ''), lower(name));
+-- Бюджет ссылается на категорию и удаляется вместе с ней. Бюджеты на несуществующие категории удаляются
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS category_id CHAR(40) REFERENCES categories(id) ON DELETE CASCADE;
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'budgets' AND column_name = 'category') THEN
+        UPDATE budgets SET category_id = category WHERE category IN (SELECT id FROM categories);
+        DELETE FROM budgets WHERE category <> '' AND category_id IS NULL;
+        DROP INDEX IF EXISTS ux_budgets_user_category;
+        ALTER TABLE budgets DROP COLUMN category;
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_budgets_user_category_id ON budgets(user_id, COALESCE(category_id, ''));
+CREATE INDEX IF NOT EXISTS idx_budgets_category_id ON budgets(category_id);
+CREATE TABLE IF NOT EXISTS catalog_services (
+    id CHAR(40) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    category_id CHAR(40) REFERENCES categories(id) ON DELETE SET NULL,
+    default_price INTEGER CHECK (default_price >= 0),
+    website VARCHAR(2048) NOT NULL DEFAULT '',
+    logo_url VARCHAR(2048) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_catalog_services_name ON catalog_services(name);
+CREATE INDEX IF NOT EXISTS idx_catalog_services_category_id ON catalog_services(category_id);
+CREATE TABLE IF NOT EXISTS catalog_aliases (
+    key VARCHAR(255) PRIMARY KEY,
+    service_id CHAR(40) NOT NULL REFERENCES catalog_services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_catalog_aliases_service_id ON catalog_aliases(service_id);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id CHAR(40) REFERENCES catalog_services(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS ix_subs_service_id ON subscriptions(service_id);
+-- Названия вне каталога, отличающиеся только регистром, - один сервис. Варианты получают написание самой ранней
+-- подписки пользователя, совпавшие после этого подписки сливаются в одну, индекс становится нечувствительным к регистру
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'ux_subs_service_user_start' AND indexdef LIKE '%lower(%') THEN
+        DROP INDEX IF EXISTS ux_subs_service_user_start;
+        UPDATE subscriptions s SET service = v.service
+        FROM (SELECT DISTINCT ON (user_id, lower(service)) user_id, lower(service) AS service_key, service
+              FROM subscriptions ORDER BY user_id, lower(service), start_date, id) v
+        WHERE s.user_id = v.user_id AND lower(s.service) = v.service_key AND s.service <> v.service;
+        CREATE TEMP TABLE subs_case_duplicates ON COMMIT DROP AS
+            SELECT id, first_value(id) OVER (PARTITION BY user_id, service, start_date ORDER BY id) AS target_id
+            FROM subscriptions;
+        DELETE FROM subs_case_duplicates WHERE id = target_id;
+        UPDATE subscriptions s SET end_date = m.end_date
+        FROM (SELECT d.target_id, CASE WHEN bool_or(x.end_date IS NULL) THEN NULL ELSE max(x.end_date) END AS end_date
+              FROM subs_case_duplicates d JOIN subscriptions x ON x.id IN (d.id, d.target_id)
+              GROUP BY d.target_id) m
+        WHERE s.id = m.target_id;
+        DELETE FROM subscriptions WHERE id IN (SELECT id FROM subs_case_duplicates);
+        CREATE UNIQUE INDEX ux_subs_service_user_start ON subscriptions(lower(service), user_id, start_date);
+    END IF;
+END $$;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS category_id CHAR(40) REFERENCES categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS ix_subs_category ON subscriptions(category_id);
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    subscription_id CHAR(40) NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    PRIMARY KEY (subscription_id, name)
+);
+CREATE INDEX IF NOT EXISTS ix_subscription_tags_name ON subscription_tags(name);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_start_date DATE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_end_date DATE;
+CREATE INDEX IF NOT EXISTS ix_subs_trial_end ON subscriptions(trial_end_date);
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id CHAR(40) PRIMARY KEY,
+    subscription_id CHAR(40) NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE CHECK (end_date >= start_date),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS ix_subscription_pauses_sub ON subscription_pauses(subscription_id);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS end_date_before_cancel DATE;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'subscriptions' AND column_name = 'billing_day') THEN
+        ALTER TABLE subscriptions ADD COLUMN billing_day SMALLINT NOT NULL DEFAULT 1 CHECK (billing_day BETWEEN 1 AND 31);
+        UPDATE subscriptions SET end_date = (end_date + interval '1 month' - interval '1 day')::date WHERE end_date IS NOT NULL;
+        UPDATE subscriptions SET end_date_before_cancel = (end_date_before_cancel + interval '1 month' - interval '1 day')::date WHERE end_date_before_cancel IS NOT NULL;
+        UPDATE subscriptions SET trial_end_date = (trial_end_date + interval '1 month' - interval '1 day')::date WHERE trial_end_date IS NOT NULL;
+        UPDATE subscription_pauses SET end_date = (end_date + interval '1 month' - interval '1 day')::date WHERE end_date IS NOT NULL;
+    END IF;
+END $$;
+CREATE TABLE IF NOT EXISTS charges (
+    id CHAR(40) PRIMARY KEY,
+    subscription_id CHAR(40) NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    service VARCHAR(255) NOT NULL,
+    date DATE NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'expected',
+    actual_amount BIGINT,
+    settled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_charges_sub_date ON charges(subscription_id, date);
+CREATE INDEX IF NOT EXISTS ix_charges_user_date ON charges(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_charges_status ON charges(status);
+CREATE TABLE IF NOT EXISTS bank_transactions (
+    id CHAR(40) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    date DATE NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    description VARCHAR(512) NOT NULL,
+    charge_id CHAR(40) REFERENCES charges(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_bank_tx_user_external ON bank_transactions(user_id, external_id);
+CREATE INDEX IF NOT EXISTS ix_bank_tx_user_date ON bank_transactions(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_bank_transactions_charge_id ON bank_transactions(charge_id);
+CREATE TABLE IF NOT EXISTS recurring_candidates (
+    id CHAR(40) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    merchant VARCHAR(512) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    service VARCHAR(255) NOT NULL,
+    price BIGINT NOT NULL,
+    start_date DATE NOT NULL,
+    last_date DATE NOT NULL,
+    billing_day SMALLINT NOT NULL,
+    occurrences INT NOT NULL,
+    confidence DOUBLE PRECISION NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'proposed',
+    subscription_id CHAR(40) REFERENCES subscriptions(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_recurring_candidates_merchant ON recurring_candidates(user_id, merchant, currency);
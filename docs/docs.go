@@ -18,344 +18,539 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions/v1/create": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/subscriptions/v1/analytics/aggregate": {
+            "get": {
+                "description": "Groups subscriptions matching the filter and computes metrics in the database.\ntotal_cost is the cost over the startDate..endDate period, both are required for it. At most 1000 groups are returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "analytics"
                 ],
-                "summary": "Create subscription",
+                "summary": "Aggregate subscriptions",
                 "parameters": [
                     {
-                        "description": "Subscription payload",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.basicRequest"
-                        }
+                        "type": "string",
+                        "description": "Comma separated: service, service_id, category, root_category, user_id, start_month, end_month",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated: count, sum_cost, total_cost, avg_price; count,sum_cost by default",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date YYYY-MM-DD or MM-YYYY",
+                        "name": "startDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date YYYY-MM-DD or MM-YYYY (last day of the month)",
+                        "name": "endDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal cost, inclusive",
+                        "name": "priceMin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal cost, inclusive",
+                        "name": "priceMax",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Service names, comma separated or repeated",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Catalog service IDs, comma separated or repeated",
+                        "name": "serviceIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, subcategories included",
+                        "name": "categoryID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Subscriptions with any of the tags, comma separated or repeated",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "User UUIDs, comma separated or repeated",
+                        "name": "userIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the service name",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions active today",
+                        "name": "activeOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions ended before today",
+                        "name": "endedOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Proration policy for total_cost: none, daily, by_billing_day; the service default if omitted",
+                        "name": "proration",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.BasicResponse"
+                            "$ref": "#/definitions/handlers.AggregateResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/v1/delete/{id}": {
-            "delete": {
+        "/subscriptions/v1/batch/create": {
+            "post": {
+                "description": "mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.\nResponds 200 when every item succeeded and 207 otherwise, see per-item results.\noverlapPolicy works as in /create and also compares items with each other, reject fails the overlapping items.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Delete subscription",
+                "summary": "Create subscriptions in batch",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Batch payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "allow, warn or reject",
+                        "name": "overlapPolicy",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.BasicResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "207": {
+                        "description": "Multi-Status",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/v1/get/query": {
-            "get": {
+        "/subscriptions/v1/batch/delete": {
+            "post": {
+                "description": "mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.\nResponds 200 when every item succeeded and 207 otherwise, see per-item results.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Get subscription by unique params",
+                "summary": "Delete subscriptions in batch",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Service name",
-                        "name": "service",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User UUID",
-                        "name": "userID",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Start date MM-YYYY",
-                        "name": "startDate",
-                        "in": "query",
-                        "required": true
+                        "description": "Batch payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchDeleteRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "207": {
+                        "description": "Multi-Status",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/v1/get/{id}": {
-            "get": {
+        "/subscriptions/v1/batch/update": {
+            "patch": {
+                "description": "mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.\nResponds 200 when every item succeeded and 207 otherwise, see per-item results.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Get subscription by ID",
+                "summary": "Update subscriptions in batch",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Batch payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchUpdateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "207": {
+                        "description": "Multi-Status",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/v1/list": {
+        "/subscriptions/v1/budgets": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "List subscriptions",
+                "summary": "List budgets of a user",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Page",
-                        "name": "Page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit",
-                        "name": "Limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sort (cost_asc\\|cost_desc\\|service_asc\\|service_desc\\|start_date)",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Service name",
-                        "name": "service",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "User UUID",
                         "name": "userID",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Start date MM-YYYY",
-                        "name": "startDate",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "End date MM-YYYY",
-                        "name": "endDate",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Cost",
-                        "name": "price",
-                        "in": "query"
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ListResponse"
+                            "$ref": "#/definitions/handlers.BudgetsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
-            }
-        },
-        "/subscriptions/v1/total": {
-            "get": {
+            },
+            "post": {
+                "description": "Monthly spending limit for a user, optionally for one category including its subcategories. Thresholds are percents of the amount, 80 and 100 by default.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Get total subscription cost for period",
+                "summary": "Create budget",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Start date MM-YYYY",
-                        "name": "startDate",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "End date MM-YYYY",
-                        "name": "endDate",
-                        "in": "query",
-                        "required": true
+                        "description": "Budget",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.budgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Service name",
-                        "name": "service",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/budgets/alerts": {
+            "get": {
+                "description": "Latest 100 threshold crossings, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "List budget alerts of a user",
+                "parameters": [
                     {
                         "type": "string",
                         "description": "User UUID",
                         "name": "userID",
-                        "in": "query"
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CostResponse"
+                            "$ref": "#/definitions/handlers.BudgetAlertsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/v1/update/{id}": {
+        "/subscriptions/v1/budgets/{id}": {
+            "get": {
+                "description": "Evaluating the budget also records alerts for thresholds crossed since the last check.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Get budget with its status in the current month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BudgetResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Delete budget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
             "patch": {
                 "consumes": [
                     "application/json"
@@ -364,26 +559,124 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Update subscription",
+                "summary": "Update budget",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Subscription ID",
+                        "type": "string",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Subscription payload",
+                        "description": "Fields to change",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.basicRequest"
+                            "$ref": "#/definitions/handlers.budgetUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/calendar/token": {
+            "post": {
+                "description": "Creates a secret token for the user's .ics feed. Issuing a new token invalidates the previous one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Issue calendar feed token",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.feedTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FeedTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
+                }
+            }
+        },
+        "/subscriptions/v1/calendar/token/{userID}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Revoke calendar feed token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -395,57 +688,3918 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/calendar/{token}/feed.ics": {
+            "get": {
+                "description": "iCalendar feed with a monthly recurring all-day event per active subscription, authenticated by the feed token.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Calendar feed of upcoming renewals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/cancel/{id}": {
+            "post": {
+                "description": "The subscription ends the day before the next charge, or with the trial if it is in progress. An earlier end date is kept.\nAllowed in the trial, active and paused statuses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel subscription at period end",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ProblemResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.BasicResponse": {
+        },
+        "/subscriptions/v1/candidates": {
+            "get": {
+                "description": "Candidates found by the last detection ordered by confidence.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candidates"
+                ],
+                "summary": "List subscription candidates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses: proposed, accepted, dismissed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest confidence",
+                        "name": "minConfidence",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CandidatesListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/candidates/detect": {
+            "post": {
+                "description": "Groups unmatched transactions of the last 13 months by merchant and currency and proposes monthly ones as subscription candidates.\nConfidence from 0 to 1 grows with regular intervals, a stable amount, the number of payments and a recent last payment.\nMerchants resembling existing subscriptions are skipped, accepted and dismissed candidates are not proposed again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candidates"
+                ],
+                "summary": "Detect recurring payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest confidence of returned candidates, 0.5 by default",
+                        "name": "minConfidence",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amount deviation in percent treated as the same price, 10 by default, at most 50",
+                        "name": "tolerance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CandidatesListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/candidates/{id}/accept": {
+            "post": {
+                "description": "Creates a subscription from a proposed candidate, body fields override the detected ones.\nPast transactions of the merchant are then reconciled against charges of the new subscription.\noverlapPolicy works as in /create.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candidates"
+                ],
+                "summary": "Accept subscription candidate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Overrides",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.acceptRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "allow, warn or reject",
+                        "name": "overlapPolicy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AcceptCandidateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/candidates/{id}/dismiss": {
+            "post": {
+                "description": "A dismissed candidate is not proposed again by later detections.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candidates"
+                ],
+                "summary": "Dismiss subscription candidate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/catalog/services": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "List catalog services",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CatalogServicesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Names and aliases are matched case-insensitively with collapsed spaces. Existing subscriptions with a matching name are linked to the service and renamed to the canonical name.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Create catalog service",
+                "parameters": [
+                    {
+                        "description": "Catalog service",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.catalogServiceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/catalog/services/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Get catalog service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CatalogServiceResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces all fields and aliases. Subscriptions are relinked: the ones matching the new names are renamed, the ones matching only removed aliases lose service_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Replace catalog service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Catalog service",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.catalogServiceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Subscriptions keep their names and lose service_id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Delete catalog service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/categories": {
+            "get": {
+                "description": "Flat list of the whole tree, every category carries its path from the root.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "List categories",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CategoriesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Categories form a tree up to 5 levels deep. Names are unique among siblings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Create category",
+                "parameters": [
+                    {
+                        "description": "Category",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.categoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/categories/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CategoryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Both fields are replaced, parent_id null moves the category to the top level together with its subcategories.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Rename or move category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.categoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Only categories without subcategories can be deleted. Subscriptions and catalog services lose the category.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Delete category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/charges": {
+            "get": {
+                "description": "Charges ledger ordered by date. dateFrom and dateTo are inclusive.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "List charges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "subscriptionID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses: expected, paid, failed, refunded",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChargesListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/charges/generate": {
+            "post": {
+                "description": "Rebuilds expected charges of the subscriptions up to the end of the horizon-th month from the current one.\nCharges already marked paid, failed or refunded are kept, expected ones are replaced.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "Generate expected charges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID, all users when omitted",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Months ahead, 3 by default, at most 24",
+                        "name": "horizon",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GenerateChargesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/charges/{id}/settle": {
+            "post": {
+                "description": "Marks a charge paid, failed or refunded. paid is allowed from expected and failed, failed from expected, refunded from paid.\namount is the actual amount, for paid and refunded it defaults to the expected one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "Settle charge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Charge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settlement",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.settleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChargeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/create": {
+            "post": {
+                "description": "overlapPolicy decides what happens when the user already has a subscription to the same service for an overlapping period:\nallow creates it silently, warn creates it and lists the overlapping subscriptions, reject responds 409. The default comes from OVERLAP_POLICY.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.basicRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "allow, warn or reject",
+                        "name": "overlapPolicy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/delete/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Delete subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/export": {
+            "get": {
+                "description": "Streams every subscription matching the filter as CSV, NDJSON or XLSX.\nBesides stored fields each row has months_active and total_paid computed up to today.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Export subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Format (csv\\|ndjson\\|xlsx), csv by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort expression like service,-cost,start_date over service, cost, start_date, end_date, user_id and id; -start_date by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date YYYY-MM-DD or MM-YYYY",
+                        "name": "startDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date YYYY-MM-DD or MM-YYYY (last day of the month)",
+                        "name": "endDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cost",
+                        "name": "price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal cost, inclusive",
+                        "name": "priceMin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal cost, inclusive",
+                        "name": "priceMax",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Service names, comma separated or repeated",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Catalog service IDs, comma separated or repeated",
+                        "name": "serviceIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, subcategories included",
+                        "name": "categoryID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Subscriptions with any of the tags, comma separated or repeated",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "User UUIDs, comma separated or repeated",
+                        "name": "userIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the service name",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions active today",
+                        "name": "activeOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions ended before today",
+                        "name": "endedOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date from YYYY-MM-DD or MM-YYYY, inclusive",
+                        "name": "endDateFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date to YYYY-MM-DD or MM-YYYY, inclusive",
+                        "name": "endDateTo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/forecast": {
+            "get": {
+                "description": "Projects monthly spend starting from the current month. Subscriptions are charged until their end date,\nscheduled price changes are applied, partial months follow the proration policy. expected discounts each month by (1 - churnRate)^k.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forecast"
+                ],
+                "summary": "Spending forecast",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of months, 12 by default, at most 36",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Monthly probability of cancellation in [0, 1), 0 by default",
+                        "name": "churnRate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Proration policy: none, daily, by_billing_day; the service default if omitted",
+                        "name": "proration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal cost, inclusive",
+                        "name": "priceMin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal cost, inclusive",
+                        "name": "priceMax",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Service names, comma separated or repeated",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Catalog service IDs, comma separated or repeated",
+                        "name": "serviceIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, subcategories included",
+                        "name": "categoryID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Subscriptions with any of the tags, comma separated or repeated",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "User UUIDs, comma separated or repeated",
+                        "name": "userIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the service name",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ForecastResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/get/query": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription by unique params",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date YYYY-MM-DD or MM-YYYY",
+                        "name": "startDate",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated response fields, e.g. id,service_name,price",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Computed fields to add: months_active, total_spent, next_charge_date",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/get/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated response fields, e.g. id,service_name,price",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Computed fields to add: months_active, total_spent, next_charge_date",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/import/csv": {
+            "post": {
+                "description": "Accepts a multipart form with a \"file\" field or a raw text/csv body (up to 10 MB, 10000 rows).\nColumns are matched by name (service_name, price, user_id, start_date, end_date), dates may be MM-YYYY or YYYY-MM-DD.\nWith dryRun=true rows are only validated and checked for duplicates, nothing is written.\noverlapPolicy works as in /batch/create, rows rejected by it are reported as failed.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Import subscriptions from CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate only, write nothing",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column delimiter (single character or tab), detected from the header if omitted",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column mapping field:column, e.g. service_name:Service,price:Monthly price",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "allow, warn or reject",
+                        "name": "overlapPolicy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/list": {
+            "get": {
+                "description": "Supports page/limit (offset) pagination and keyset pagination: pass meta.next_cursor or meta.prev_cursor as cursor.\nA cursor is bound to the sort it was issued for and takes precedence over page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from meta.next_cursor or meta.prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated response fields, e.g. id,service_name,price",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Computed fields to add: months_active, total_spent, next_charge_date",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count total rows (default true), set to false to skip the COUNT query",
+                        "name": "withTotal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort expression like service,-cost,start_date over service, cost, start_date, end_date, user_id and id; -start_date by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date YYYY-MM-DD or MM-YYYY",
+                        "name": "startDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date YYYY-MM-DD or MM-YYYY (last day of the month)",
+                        "name": "endDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cost",
+                        "name": "price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal cost, inclusive",
+                        "name": "priceMin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal cost, inclusive",
+                        "name": "priceMax",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Service names, comma separated or repeated",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Catalog service IDs, comma separated or repeated",
+                        "name": "serviceIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, subcategories included",
+                        "name": "categoryID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Subscriptions with any of the tags, comma separated or repeated",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "User UUIDs, comma separated or repeated",
+                        "name": "userIDs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the service name",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions active today",
+                        "name": "activeOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions ended before today",
+                        "name": "endedOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date from YYYY-MM-DD or MM-YYYY, inclusive",
+                        "name": "endDateFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date to YYYY-MM-DD or MM-YYYY, inclusive",
+                        "name": "endDateTo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/overlaps": {
+            "get": {
+                "description": "Groups subscriptions of the user to the same service (catalog service or normalized name) whose periods overlap.\nextra_monthly_cost is what totals count twice today: prices of the active subscriptions in the group except the most expensive one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "overlaps"
+                ],
+                "summary": "Report overlapping subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OverlapsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/overlaps/merge": {
+            "post": {
+                "description": "Keeps target_id and stretches its period over the merged subscriptions, which are deleted with their pauses and charges.\nTags are combined, price and billing day of the target are kept. All subscriptions must belong to the same user,\nrefer to the same service and overlap as one group, see GET /overlaps.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "overlaps"
+                ],
+                "summary": "Merge overlapping subscriptions",
+                "parameters": [
+                    {
+                        "description": "Target and merged subscription IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.mergeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/pause/{id}": {
+            "post": {
+                "description": "Charges falling on paused days are skipped and the subscription is not active on them. Without end_date the pause lasts until resumed.\nThe body may be omitted to pause from today.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pause",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.pauseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/price-changes": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forecast"
+                ],
+                "summary": "List scheduled price changes of a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "subscriptionID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PriceChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "The new price applies from effective_date onwards and is taken into account by the forecast.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forecast"
+                ],
+                "summary": "Schedule a price change",
+                "parameters": [
+                    {
+                        "description": "Price change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.priceChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/price-changes/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "forecast"
+                ],
+                "summary": "Cancel a scheduled price change",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Price change ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/reactivate/{id}": {
+            "post": {
+                "description": "Removes the cancellation and the end date. For a cancelled or expired subscription the days since it ended are recorded as a pause.\nAllowed in the cancellation_scheduled, cancelled and expired statuses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Reactivate subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/resume/{id}": {
+            "post": {
+                "description": "Ends the nearest unfinished pause the day before resume_date, a pause that has not started by then is cancelled.\nThe body may be omitted to resume from today.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resume",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.resumeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/statements/import": {
+            "post": {
+                "description": "Accepts a CSV or OFX statement as a multipart form with a \"file\" field or a raw body (up to 10 MB, 10000 transactions).\nCSV columns are matched by name (date, amount, description, optional currency and id), debits are negative.\nDebits are stored once per user and matched to expected charges of the statement period: same currency, date within window days,\namount within tolerance percent and the description containing the service name or a catalog alias. Matched charges are marked paid.\nThe response lists unmatched transactions, expected charges missing from the statement and recurring merchants proposed as subscription candidates.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv",
+                    "application/x-ofx"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statements"
+                ],
+                "summary": "Import bank statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Statement file",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv or ofx, detected from the content if omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV column delimiter, detected from the header if omitted",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Days between expected and actual charge, 3 by default, at most 15",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amount deviation in percent, 10 by default, at most 50",
+                        "name": "tolerance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatementImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/statements/reconcile": {
+            "post": {
+                "description": "Matches unmatched transactions of the period against expected charges again, e.g. after adding subscriptions.\nThe period defaults to the current and two previous months.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statements"
+                ],
+                "summary": "Reconcile imported transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Days between expected and actual charge, 3 by default, at most 15",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amount deviation in percent, 10 by default, at most 50",
+                        "name": "tolerance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReconcileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/tags": {
+            "get": {
+                "description": "Every tag in use with the number of subscriptions carrying it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "List tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TagsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/tags/{tag}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Remove tag from all subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteTagResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/total": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get total subscription cost for period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date YYYY-MM-DD or MM-YYYY",
+                        "name": "startDate",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date YYYY-MM-DD or MM-YYYY (last day of the month)",
+                        "name": "endDate",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Proration policy: none, daily, by_billing_day; the service default if omitted",
+                        "name": "proration",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/transactions": {
+            "get": {
+                "description": "Imported debits of the user ordered by date. matched=false lists transactions without a charge.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statements"
+                ],
+                "summary": "List bank transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only matched or only unmatched transactions",
+                        "name": "matched",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date YYYY-MM-DD or MM-YYYY",
+                        "name": "dateTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionsListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/update/{id}": {
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.basicRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/users/{userID}/summary": {
+            "get": {
+                "description": "Overview for the user's home screen: active subscriptions and spend in the current month, spend since January,\nthe most expensive active subscription, subscriptions ending soon and the change against the previous month.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "User spending summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Months ahead to look for ending subscriptions, 3 by default, at most 24",
+                        "name": "endingWithin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Proration policy: none, daily, by_billing_day; the service default if omitted",
+                        "name": "proration",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/v1/users/{userID}/trials/ending": {
+            "get": {
+                "description": "Subscriptions whose trial ends from today to the end of the within-th month, ordered by trial end, so the user can cancel before the first charge.\nnext_charge_date is the first paid charge.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Trials ending soon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Months ahead including the current one, 3 by default, at most 24",
+                        "name": "within",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EndingTrialsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AcceptCandidateResponse": {
+            "type": "object",
+            "properties": {
+                "candidate": {
+                    "$ref": "#/definitions/handlers.CandidateDTO"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Matched - прошлые операции мерчанта, привязанные к списаниям новой подписки",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "overlaps": {
+                    "description": "Overlaps - пересекающиеся подписки пользователя на тот же сервис при overlapPolicy=warn",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
+                }
+            }
+        },
+        "handlers.AggregateGroup": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "description": "Group - значения полей группировки, null для подписок без end_date при группировке по end_month",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "metrics": {
+                    "type": "object"
+                }
+            }
+        },
+        "handlers.AggregateResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AggregateGroup"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BasicResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchItemError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/handlers.ErrorCode"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/handlers.BatchItemError"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/subs.BatchMode"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BudgetAlertDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "budget_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "10-2026"
+                },
+                "spend": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BudgetAlertsResponse": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BudgetAlertDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BudgetDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/handlers.BudgetStatusDTO"
+                },
+                "thresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BudgetResponse": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "$ref": "#/definitions/handlers.BudgetDTO"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BudgetStatusDTO": {
+            "type": "object",
+            "properties": {
+                "crossed_thresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "10-2026"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "spend": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BudgetsResponse": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BudgetDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CandidateDTO": {
+            "type": "object",
+            "properties": {
+                "billing_day": {
+                    "type": "integer"
+                },
+                "confidence": {
+                    "type": "number",
+                    "example": 0.85
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_date": {
+                    "type": "string",
+                    "example": "2026-10-15"
+                },
+                "occurrences": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2026-06-15"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "proposed"
+                },
+                "subscription_id": {
+                    "description": "SubscriptionID - подписка, созданная при принятии",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CandidatesListResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CandidateDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CatalogServiceDTO": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 799
+                },
+                "id": {
+                    "type": "string"
+                },
+                "logo_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CatalogServiceResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "service": {
+                    "$ref": "#/definitions/handlers.CatalogServiceDTO"
+                }
+            }
+        },
+        "handlers.CatalogServicesResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CatalogServiceDTO"
+                    }
+                }
+            }
+        },
+        "handlers.CategoriesResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CategoryDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CategoryDTO": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Streaming"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Entertainment",
+                        "Streaming"
+                    ]
+                }
+            }
+        },
+        "handlers.CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/handlers.CategoryDTO"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ChargeDTO": {
+            "type": "object",
+            "properties": {
+                "actual_amount": {
+                    "type": "integer"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2026-10-15"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "service": {
+                    "type": "string"
+                },
+                "settled_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "expected"
+                },
+                "subscription_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ChargeResponse": {
+            "type": "object",
+            "properties": {
+                "charge": {
+                    "$ref": "#/definitions/handlers.ChargeDTO"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ChargesListResponse": {
+            "type": "object",
+            "properties": {
+                "charges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ChargeDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/handlers.Metadata"
+                }
+            }
+        },
+        "handlers.CostResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "sum_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CreateResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "overlaps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
+                }
+            }
+        },
+        "handlers.DeleteTagResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.EndingTrialsResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "description": "Subscriptions упорядочены по trial_end_date, next_charge_date - первое платное списание",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "validation_failed",
+                "malformed_body",
+                "wrong_params",
+                "subscription_not_found",
+                "subscription_already_exists",
+                "internal_error",
+                "invalid_date_format",
+                "invalid_uuid",
+                "invalid_number",
+                "invalid_type",
+                "required",
+                "out_of_range",
+                "invalid_period",
+                "invalid_param",
+                "duplicate",
+                "payload_too_large",
+                "feed_not_found",
+                "price_change_not_found",
+                "price_change_already_exists",
+                "budget_not_found",
+                "budget_already_exists",
+                "catalog_service_not_found",
+                "catalog_service_already_exists",
+                "category_not_found",
+                "category_already_exists",
+                "category_has_children",
+                "unknown_category",
+                "pause_conflict",
+                "subscription_not_paused",
+                "invalid_transition",
+                "charge_not_found",
+                "candidate_not_found",
+                "candidate_resolved",
+                "subscription_overlap",
+                "merge_mismatch"
+            ],
+            "x-enum-varnames": [
+                "CodeValidationFailed",
+                "CodeMalformedBody",
+                "CodeWrongParams",
+                "CodeNotFound",
+                "CodeAlreadyExists",
+                "CodeInternal",
+                "CodeInvalidDateFormat",
+                "CodeInvalidUUID",
+                "CodeInvalidNumber",
+                "CodeInvalidType",
+                "CodeRequired",
+                "CodeOutOfRange",
+                "CodeInvalidPeriod",
+                "CodeInvalidParam",
+                "CodeDuplicate",
+                "CodePayloadTooLarge",
+                "CodeFeedNotFound",
+                "CodePriceChangeNotFound",
+                "CodePriceChangeAlreadyExists",
+                "CodeBudgetNotFound",
+                "CodeBudgetAlreadyExists",
+                "CodeCatalogNotFound",
+                "CodeCatalogAlreadyExists",
+                "CodeCategoryNotFound",
+                "CodeCategoryAlreadyExists",
+                "CodeCategoryHasChildren",
+                "CodeUnknownCategory",
+                "CodePauseConflict",
+                "CodeNotPaused",
+                "CodeInvalidTransition",
+                "CodeChargeNotFound",
+                "CodeCandidateNotFound",
+                "CodeCandidateResolved",
+                "CodeOverlap",
+                "CodeMergeMismatch"
+            ]
+        },
+        "handlers.FeedTokenResponse": {
+            "type": "object",
+            "properties": {
+                "feed_url": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "webcal_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/handlers.ErrorCode"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ForecastContribution": {
+            "type": "object",
+            "properties": {
+                "price": {
+                    "type": "integer"
+                },
+                "price_changed": {
+                    "type": "boolean"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "expected": {
+                    "type": "number"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "11-2026"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ForecastContribution"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ForecastResponse": {
+            "type": "object",
+            "properties": {
+                "churn_rate": {
+                    "type": "number"
+                },
+                "expected": {
+                    "type": "number"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ForecastMonth"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.GenerateChargesResponse": {
+            "type": "object",
+            "properties": {
+                "charges": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "until": {
+                    "type": "string",
+                    "example": "2027-01-31"
+                }
+            }
+        },
+        "handlers.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "delimiter": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ImportRowResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ImportRowResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/importer.RowStatus"
+                }
+            }
+        },
+        "handlers.ListResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/handlers.Metadata"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
+                }
+            }
+        },
+        "handlers.MatchDTO": {
+            "type": "object",
+            "properties": {
+                "charge": {
+                    "$ref": "#/definitions/handlers.ChargeDTO"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/handlers.TransactionDTO"
+                }
+            }
+        },
+        "handlers.Metadata": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.MonthOverMonth": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "type": "integer"
+                },
+                "change_percent": {
+                    "description": "ChangePercent - null, если в прошлом месяце трат не было",
+                    "type": "number"
+                },
+                "current_spend": {
+                    "type": "integer"
+                },
+                "previous_spend": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.OverlapGroupDTO": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "example": "2026-12-31"
+                },
+                "extra_monthly_cost": {
+                    "description": "ExtraMonthlyCost - сколько в месяц сейчас учитывается дважды",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.OverlapsResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.OverlapGroupDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PauseDTO": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-08-31"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-06-01"
+                }
+            }
+        },
+        "handlers.PriceChangeDTO": {
+            "type": "object",
+            "properties": {
+                "effective_date": {
+                    "type": "string",
+                    "example": "01-2027"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PriceChangesResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "price_changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.PriceChangeDTO"
+                    }
+                }
+            }
+        },
+        "handlers.ProblemResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/handlers.ErrorCode"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReconcileResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "reconciliation": {
+                    "$ref": "#/definitions/handlers.ReconciliationDTO"
+                }
+            }
+        },
+        "handlers.ReconciliationDTO": {
+            "type": "object",
+            "properties": {
+                "matched": {
+                    "description": "Matched - списания, отмеченные оплаченными по операциям выписки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.MatchDTO"
+                    }
+                },
+                "missing": {
+                    "description": "Missing - ожидаемые списания периода без операции",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ChargeDTO"
+                    }
+                },
+                "period_end": {
+                    "type": "string",
+                    "example": "2026-10-31"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "2026-10-01"
+                },
+                "suggestions": {
+                    "description": "Suggestions - предложенные кандидаты в подписки, принимаются через POST /candidates/{id}/accept",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CandidateDTO"
+                    }
+                },
+                "unmatched": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.TransactionDTO"
+                    }
+                }
+            }
+        },
+        "handlers.StatementImportResponse": {
+            "type": "object",
+            "properties": {
+                "credits": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "format": {
+                    "type": "string",
+                    "example": "ofx"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.StatementRowDTO"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "reconciliation": {
+                    "description": "Reconciliation отсутствует, если в выписке нет списаний",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.ReconciliationDTO"
+                        }
+                    ]
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.StatementRowDTO": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/handlers.ErrorCode"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SubscriptionDTO": {
             "type": "object",
             "properties": {
+                "billing_day": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
                 "id": {
+                    "type": "string"
+                },
+                "months_active": {
                     "type": "integer"
                 },
-                "message": {
+                "next_charge_date": {
+                    "type": "string",
+                    "example": "2025-08-15"
+                },
+                "pauses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.PauseDTO"
+                    }
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "service_id": {
                     "type": "string"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "status": {
+                    "description": "Status - состояние на текущий день",
+                    "type": "string",
+                    "example": "active"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total_spent": {
+                    "type": "integer"
+                },
+                "trial_end_date": {
+                    "type": "string",
+                    "example": "2025-08-14"
+                },
+                "trial_start_date": {
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
-        "handlers.CostResponse": {
+        "handlers.SubscriptionResponse": {
             "type": "object",
             "properties": {
                 "message": {
                     "type": "string"
                 },
-                "sum_cost": {
+                "subscription": {
+                    "$ref": "#/definitions/handlers.SubscriptionDTO"
+                }
+            }
+        },
+        "handlers.TagDTO": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "work"
+                },
+                "subscriptions": {
                     "type": "integer"
                 }
             }
         },
-        "handlers.ErrorResponse": {
+        "handlers.TagsResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "message": {
                     "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.TagDTO"
+                    }
                 }
             }
         },
-        "handlers.ListResponse": {
+        "handlers.TransactionDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "charge_id": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2026-10-15"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TransactionsListResponse": {
             "type": "object",
             "properties": {
                 "message": {
@@ -454,48 +4608,84 @@ const docTemplate = `{
                 "meta": {
                     "$ref": "#/definitions/handlers.Metadata"
                 },
-                "subscriptions": {
+                "transactions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/subs.Subscription"
+                        "$ref": "#/definitions/handlers.TransactionDTO"
                     }
                 }
             }
         },
-        "handlers.Metadata": {
+        "handlers.UserSummaryResponse": {
             "type": "object",
             "properties": {
-                "limit": {
+                "active_count": {
                     "type": "integer"
                 },
-                "page": {
-                    "type": "integer"
+                "ending_soon": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionDTO"
+                    }
                 },
-                "pages": {
+                "message": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "10-2026"
+                },
+                "month_over_month": {
+                    "$ref": "#/definitions/handlers.MonthOverMonth"
+                },
+                "monthly_spend": {
                     "type": "integer"
                 },
-                "total": {
+                "most_expensive": {
+                    "$ref": "#/definitions/handlers.SubscriptionDTO"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "year_spend": {
                     "type": "integer"
                 }
             }
         },
-        "handlers.SubscriptionResponse": {
+        "handlers.acceptRequest": {
             "type": "object",
             "properties": {
-                "message": {
+                "billing_day": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_name": {
                     "type": "string"
                 },
-                "subscription": {
-                    "$ref": "#/definitions/subs.Subscription"
+                "start_date": {
+                    "type": "string",
+                    "example": "2026-06-15"
                 }
             }
         },
         "handlers.basicRequest": {
             "type": "object",
             "properties": {
-                "end_date": {
+                "billing_day": {
+                    "description": "BillingDay по умолчанию совпадает с днём start_date",
+                    "type": "integer",
+                    "example": 15
+                },
+                "category_id": {
                     "type": "string"
                 },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
                 "price": {
                     "type": "integer"
                 },
@@ -503,35 +4693,339 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "start_date": {
+                    "description": "Даты в формате YYYY-MM-DD, MM-YYYY означает первое число месяца для начала и последнее для окончания",
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "tags": {
+                    "description": "Tags при обновлении: отсутствие поля оставляет метки как есть, [] снимает все",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "family"
+                    ]
+                },
+                "trial_end_date": {
+                    "type": "string",
+                    "example": "2025-08-14"
+                },
+                "trial_start_date": {
+                    "description": "TrialStartDate по умолчанию совпадает с start_date, достаточно указать trial_end_date",
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.batchCreateRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.basicRequest"
+                    }
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/subs.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                }
+            }
+        },
+        "handlers.batchDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/subs.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                }
+            }
+        },
+        "handlers.batchUpdateItem": {
+            "type": "object",
+            "properties": {
+                "billing_day": {
+                    "description": "BillingDay по умолчанию совпадает с днём start_date",
+                    "type": "integer",
+                    "example": 15
+                },
+                "category_id": {
                     "type": "string"
                 },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Даты в формате YYYY-MM-DD, MM-YYYY означает первое число месяца для начала и последнее для окончания",
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "tags": {
+                    "description": "Tags при обновлении: отсутствие поля оставляет метки как есть, [] снимает все",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "family"
+                    ]
+                },
+                "trial_end_date": {
+                    "type": "string",
+                    "example": "2025-08-14"
+                },
+                "trial_start_date": {
+                    "description": "TrialStartDate по умолчанию совпадает с start_date, достаточно указать trial_end_date",
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "subs.Subscription": {
+        "handlers.batchUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.batchUpdateItem"
+                    }
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/subs.BatchMode"
+                        }
+                    ],
+                    "example": "best_effort"
+                }
+            }
+        },
+        "handlers.budgetRequest": {
             "type": "object",
             "properties": {
-                "cost": {
+                "amount": {
                     "type": "integer"
                 },
-                "endDate": {
+                "category_id": {
                     "type": "string"
                 },
-                "id": {
+                "thresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        100
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.budgetUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
                     "type": "integer"
                 },
-                "service": {
+                "category_id": {
+                    "type": "string"
+                },
+                "thresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        100
+                    ]
+                }
+            }
+        },
+        "handlers.catalogServiceRequest": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix.com",
+                        "Нетфликс"
+                    ]
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 799
+                },
+                "logo_url": {
+                    "type": "string",
+                    "example": "https://www.netflix.com/favicon.ico"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "website": {
+                    "type": "string",
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "handlers.categoryRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Streaming"
+                },
+                "parent_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.feedTokenRequest": {
+            "type": "object",
+            "properties": {
+                "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "handlers.mergeRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "startDate": {
+                "target_id": {
+                    "description": "TargetID - подписка, которая остаётся",
                     "type": "string"
+                }
+            }
+        },
+        "handlers.pauseRequest": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-08-31"
+                },
+                "start_date": {
+                    "description": "StartDate по умолчанию - текущий месяц, без EndDate пауза длится до возобновления",
+                    "type": "string",
+                    "example": "2025-06-01"
+                }
+            }
+        },
+        "handlers.priceChangeRequest": {
+            "type": "object",
+            "properties": {
+                "effective_date": {
+                    "type": "string",
+                    "example": "01-2027"
+                },
+                "price": {
+                    "type": "integer"
                 },
-                "userID": {
+                "subscription_id": {
                     "type": "string"
                 }
             }
+        },
+        "handlers.resumeRequest": {
+            "type": "object",
+            "properties": {
+                "resume_date": {
+                    "description": "ResumeDate - первый оплачиваемый месяц после паузы, по умолчанию текущий",
+                    "type": "string",
+                    "example": "2025-09-01"
+                }
+            }
+        },
+        "handlers.settleRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount - фактическая сумма, для paid и refunded по умолчанию ожидаемая",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "paid"
+                }
+            }
+        },
+        "importer.RowStatus": {
+            "type": "string",
+            "enum": [
+                "valid",
+                "created",
+                "invalid",
+                "duplicate",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "RowStatusValid",
+                "RowStatusCreated",
+                "RowStatusInvalid",
+                "RowStatusDuplicate",
+                "RowStatusFailed"
+            ]
+        },
+        "subs.BatchMode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "best_effort"
+            ],
+            "x-enum-varnames": [
+                "BatchModeAtomic",
+                "BatchModeBestEffort"
+            ]
         }
     },
     "tags": [
@@ -0,0 +1,107 @@
+package initializers
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/importer"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+	"os"
+)
+
+func RunCSVImport() {
+	filePath := flag.String("file", "", "path to the CSV file")
+	dryRun := flag.Bool("dry-run", false, "validate and check duplicates without writing anything")
+	delimiterStr := flag.String("delimiter", "", "column delimiter (single character or tab), detected if empty")
+	columnsStr := flag.String("columns", "", "column mapping field:column, e.g. service_name:Service,price:Monthly price")
+	overlapPolicyStr := flag.String("overlap-policy", "", "allow, warn or reject rows overlapping existing subscriptions, OVERLAP_POLICY if empty")
+	flag.Parse()
+
+	if *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	delimiter, err := importer.ParseDelimiter(*delimiterStr)
+	if err != nil {
+		log.Fatalf("Invalid delimiter: %v", err)
+	}
+
+	mapping, err := importer.ParseMapping(*columnsStr)
+	if err != nil {
+		log.Fatalf("Invalid column mapping: %v", err)
+	}
+
+	startGetEnv()
+
+	overlapPolicy := startOverlapPolicy()
+	if *overlapPolicyStr != "" {
+		if overlapPolicy, err = overlaps.ParsePolicy(*overlapPolicyStr); err != nil {
+			log.Fatalf("Invalid overlap policy: %q", *overlapPolicyStr)
+		}
+	}
+
+	zapLogger := startLogger()
+	// Sync для stderr в терминале всегда возвращает ошибку, для CLI это не важно
+	defer func() { _ = zapLogger.Sync() }()
+
+	logger := zapLogger.Sugar()
+
+	db := startPostgres()
+
+	catalogIndex := catalog.NewIndex(catalog.NewServicesPgRepo(logger, db), logger)
+	subsRepo := subs.NewSubscriptionsPgRepo(logger, db, catalogIndex, nil)
+	csvImporter := importer.NewCSVImporter(subsRepo, overlaps.NewChecker(subsRepo, catalogIndex, logger), catalogIndex, logger)
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		log.Fatalf("Error opening %s: %v", *filePath, err)
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			logger.Warnw("error closing import file", "error", errClose)
+		}
+	}()
+
+	report, err := csvImporter.Import(file, &importer.Options{
+		Delimiter:     delimiter,
+		Mapping:       mapping,
+		DryRun:        *dryRun,
+		OverlapPolicy: overlapPolicy,
+	})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	printImportReport(report)
+
+	if report.Invalid+report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func printImportReport(report *importer.Report) {
+	for _, row := range report.Rows {
+		if len(row.Errors) == 0 {
+			continue
+		}
+
+		for _, rowErr := range row.Errors {
+			if rowErr.Field == "" {
+				fmt.Printf("line %d: %s: %v\n", row.Line, row.Status, rowErr.Err)
+			} else {
+				fmt.Printf("line %d: %s: %s: %v\n", row.Line, row.Status, rowErr.Field, rowErr.Err)
+			}
+		}
+	}
+
+	mode := "import"
+	if report.DryRun {
+		mode = "dry run"
+	}
+
+	fmt.Printf("%s finished: total=%d valid=%d invalid=%d duplicates=%d created=%d failed=%d\n",
+		mode, report.Total, report.Valid, report.Invalid, report.Duplicates, report.Created, report.Failed)
+}
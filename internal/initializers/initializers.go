@@ -1,11 +1,24 @@
 package initializers
 
 import (
+	"context"
 	"log"
 	"online-subs/docs"
+	"online-subs/pkg/budgets"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/categories"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/feeds"
 	"online-subs/pkg/handlers"
+	"online-subs/pkg/i18n"
+	"online-subs/pkg/middleware"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/statements"
 	"online-subs/pkg/subs"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -17,6 +30,14 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	defaultBudgetsEvalInterval = 15 * time.Minute
+	defaultChargesGenInterval  = time.Hour
+
+	// calendarFeedRoute - маршрут фида календаря, токен в нём скрывается в журнале запросов
+	calendarFeedRoute = "/subscriptions/v1/calendar/:token/feed.ics"
+)
+
 func startGetEnv() {
 	if os.Getenv("ENVIRONMENT") == "PROD" {
 		return
@@ -41,7 +62,9 @@ func startLogger() *zap.Logger {
 
 func startPostgres() *gorm.DB {
 	dsn := os.Getenv("PG_DSN")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+	})
 
 	if err != nil {
 		log.Fatalf("Error initializing postgres: %v", err)
@@ -50,8 +73,81 @@ func startPostgres() *gorm.DB {
 	return db
 }
 
-func initSubsRouter(handler *handlers.SubsHandler) *gin.Engine {
-	r := gin.Default()
+func startI18n() *i18n.Bundle {
+	bundle, err := i18n.NewBundle()
+
+	if err != nil {
+		log.Fatalf("Error loading message catalogs: %v", err)
+	}
+
+	return bundle
+}
+
+// startBudgetsEvaluator проверяет бюджеты в фоне раз в BUDGETS_EVAL_INTERVAL, по умолчанию раз в 15 минут
+func startBudgetsEvaluator(ctx context.Context, evaluator *budgets.Evaluator) {
+	interval := defaultBudgetsEvalInterval
+	if intervalStr := os.Getenv("BUDGETS_EVAL_INTERVAL"); intervalStr != "" {
+		parsed, err := time.ParseDuration(intervalStr)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("Invalid BUDGETS_EVAL_INTERVAL: %q", intervalStr)
+		}
+		interval = parsed
+	}
+
+	go evaluator.Run(ctx, interval)
+}
+
+// startChargesGenerator пересобирает реестр списаний на CHARGES_HORIZON месяцев вперёд раз в CHARGES_GEN_INTERVAL,
+// по умолчанию на 3 месяца раз в час
+func startChargesGenerator(ctx context.Context, generator *charges.Generator) {
+	interval := defaultChargesGenInterval
+	if intervalStr := os.Getenv("CHARGES_GEN_INTERVAL"); intervalStr != "" {
+		parsed, err := time.ParseDuration(intervalStr)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("Invalid CHARGES_GEN_INTERVAL: %q", intervalStr)
+		}
+		interval = parsed
+	}
+
+	horizon := charges.DefaultHorizon
+	if horizonStr := os.Getenv("CHARGES_HORIZON"); horizonStr != "" {
+		parsed, err := strconv.Atoi(horizonStr)
+		if err != nil || parsed < 0 || parsed > charges.MaxHorizon {
+			log.Fatalf("Invalid CHARGES_HORIZON: %q", horizonStr)
+		}
+		horizon = parsed
+	}
+
+	go generator.Run(ctx, interval, horizon)
+}
+
+// startProration читает политику расчёта сумм по умолчанию из PRORATION_POLICY, без неё - by_billing_day
+func startProration() subs.Proration {
+	proration, err := subs.ParseProration(os.Getenv("PRORATION_POLICY"))
+	if err != nil {
+		log.Fatalf("Invalid PRORATION_POLICY: %q", os.Getenv("PRORATION_POLICY"))
+	}
+
+	return proration
+}
+
+func startOverlapPolicy() overlaps.Policy {
+	policy, err := overlaps.ParsePolicy(os.Getenv("OVERLAP_POLICY"))
+	if err != nil {
+		log.Fatalf("Invalid OVERLAP_POLICY: %q", os.Getenv("OVERLAP_POLICY"))
+	}
+
+	return policy
+}
+
+func initSubsRouter(bundle *i18n.Bundle, handler *handlers.SubsHandler, importHandler *handlers.ImportHandler,
+	calendarHandler *handlers.CalendarHandler, forecastHandler *handlers.ForecastHandler, budgetsHandler *handlers.BudgetsHandler,
+	catalogHandler *handlers.CatalogHandler, categoriesHandler *handlers.CategoriesHandler,
+	chargesHandler *handlers.ChargesHandler, statementsHandler *handlers.StatementsHandler,
+	candidatesHandler *handlers.CandidatesHandler, overlapsHandler *handlers.OverlapsHandler) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.AccessLog(calendarFeedRoute), gin.Recovery())
+	r.Use(middleware.RequestID(), i18n.Middleware(bundle))
 
 	host := "localhost:" + os.Getenv("PORT")
 	docs.SwaggerInfo.Host = host
@@ -63,12 +159,73 @@ func initSubsRouter(handler *handlers.SubsHandler) *gin.Engine {
 	subsGroup.GET("/get/:id", handler.GetSubByID)
 	subsGroup.GET("/list", handler.List)
 	subsGroup.GET("/total", handler.GetTotalCost)
+	subsGroup.GET("/export", handler.Export)
+	subsGroup.GET("/analytics/aggregate", handler.Aggregate)
+	subsGroup.GET("/users/:userID/summary", handler.UserSummary)
+	subsGroup.GET("/users/:userID/trials/ending", handler.EndingTrials)
 
 	subsGroup.POST("/create", handler.CreateSub)
 	subsGroup.PATCH("/update/:id", handler.UpdateSub)
+	subsGroup.POST("/pause/:id", handler.PauseSub)
+	subsGroup.POST("/resume/:id", handler.ResumeSub)
+	subsGroup.POST("/cancel/:id", handler.CancelSub)
+	subsGroup.POST("/reactivate/:id", handler.ReactivateSub)
 
 	subsGroup.DELETE("/delete/:id", handler.DeleteSub)
 
+	subsGroup.POST("/batch/create", handler.CreateBatch)
+	subsGroup.PATCH("/batch/update", handler.UpdateBatch)
+	subsGroup.POST("/batch/delete", handler.DeleteBatch)
+
+	subsGroup.POST("/import/csv", importHandler.ImportCSV)
+
+	subsGroup.POST("/calendar/token", calendarHandler.IssueFeedToken)
+	subsGroup.DELETE("/calendar/token/:userID", calendarHandler.RevokeFeedToken)
+	subsGroup.GET("/calendar/:token/feed.ics", calendarHandler.Feed)
+
+	subsGroup.GET("/forecast", forecastHandler.Forecast)
+	subsGroup.POST("/price-changes", forecastHandler.SchedulePriceChange)
+	subsGroup.GET("/price-changes", forecastHandler.ListPriceChanges)
+	subsGroup.DELETE("/price-changes/:id", forecastHandler.DeletePriceChange)
+
+	subsGroup.POST("/budgets", budgetsHandler.CreateBudget)
+	subsGroup.GET("/budgets", budgetsHandler.ListBudgets)
+	subsGroup.GET("/budgets/alerts", budgetsHandler.ListBudgetAlerts)
+	subsGroup.GET("/budgets/:id", budgetsHandler.GetBudget)
+	subsGroup.PATCH("/budgets/:id", budgetsHandler.UpdateBudget)
+	subsGroup.DELETE("/budgets/:id", budgetsHandler.DeleteBudget)
+
+	subsGroup.POST("/charges/generate", chargesHandler.GenerateCharges)
+	subsGroup.GET("/charges", chargesHandler.ListCharges)
+	subsGroup.POST("/charges/:id/settle", chargesHandler.SettleCharge)
+
+	subsGroup.POST("/statements/import", statementsHandler.ImportStatement)
+	subsGroup.POST("/statements/reconcile", statementsHandler.Reconcile)
+	subsGroup.GET("/transactions", statementsHandler.ListTransactions)
+
+	subsGroup.POST("/candidates/detect", candidatesHandler.DetectCandidates)
+	subsGroup.GET("/candidates", candidatesHandler.ListCandidates)
+	subsGroup.POST("/candidates/:id/accept", candidatesHandler.AcceptCandidate)
+	subsGroup.POST("/candidates/:id/dismiss", candidatesHandler.DismissCandidate)
+
+	subsGroup.GET("/overlaps", overlapsHandler.ListOverlaps)
+	subsGroup.POST("/overlaps/merge", overlapsHandler.MergeSubscriptions)
+
+	subsGroup.POST("/catalog/services", catalogHandler.CreateCatalogService)
+	subsGroup.GET("/catalog/services", catalogHandler.ListCatalogServices)
+	subsGroup.GET("/catalog/services/:id", catalogHandler.GetCatalogService)
+	subsGroup.PUT("/catalog/services/:id", catalogHandler.UpdateCatalogService)
+	subsGroup.DELETE("/catalog/services/:id", catalogHandler.DeleteCatalogService)
+
+	subsGroup.POST("/categories", categoriesHandler.CreateCategory)
+	subsGroup.GET("/categories", categoriesHandler.ListCategories)
+	subsGroup.GET("/categories/:id", categoriesHandler.GetCategory)
+	subsGroup.PUT("/categories/:id", categoriesHandler.UpdateCategory)
+	subsGroup.DELETE("/categories/:id", categoriesHandler.DeleteCategory)
+
+	subsGroup.GET("/tags", handler.ListTags)
+	subsGroup.DELETE("/tags/:tag", handler.DeleteTag)
+
 	return r
 }
 
@@ -79,8 +236,38 @@ func gormAutoMigrate(db *gorm.DB) {
 
 	if errAuto := db.AutoMigrate(
 		&subs.Subscription{},
+		&feeds.FeedToken{},
+		&pricing.PriceChange{},
+		&budgets.Budget{},
+		&budgets.Alert{},
+		&catalog.Service{},
+		&catalog.Alias{},
+		&categories.Category{},
+		&subs.Tag{},
+		&subs.Pause{},
+		&charges.Charge{},
+		&statements.Transaction{},
+		&statements.Candidate{},
 	); errAuto != nil {
 		log.Fatalf("AutoMigrate failed: %v", errAuto)
 		return
 	}
+
+	// Индексы, которые не описать тегами gorm: уникальность подписки без учёта регистра сервиса,
+	// триграммный для поиска по подстроке названия сервиса и уникальность названий категорий среди соседей,
+	// включая корневые
+	for _, statement := range []string{
+		"DROP INDEX IF EXISTS index_subs",
+		"CREATE UNIQUE INDEX IF NOT EXISTS ux_subs_service_user_start ON subscriptions (lower(service), user_id, start_date)",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS ix_subs_service_trgm ON subscriptions USING gin (service gin_trgm_ops)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS ux_categories_parent_name ON categories (COALESCE(parent_id, ''), lower(name))",
+		"DROP INDEX IF EXISTS ux_budgets_user_category",
+		"CREATE UNIQUE INDEX IF NOT EXISTS ux_budgets_user_category_id ON budgets (user_id, COALESCE(category_id, ''))",
+	} {
+		if errExec := db.Exec(statement).Error; errExec != nil {
+			log.Fatalf("AutoMigrate failed: %v", errExec)
+			return
+		}
+	}
 }
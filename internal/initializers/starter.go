@@ -1,8 +1,18 @@
 package initializers
 
 import (
+	"context"
 	"log"
+	"online-subs/pkg/budgets"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/categories"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/feeds"
 	"online-subs/pkg/handlers"
+	"online-subs/pkg/importer"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/statements"
 	"online-subs/pkg/subs"
 	"os"
 
@@ -25,11 +35,49 @@ func RunSubsService() {
 	db := startPostgres()
 	gormAutoMigrate(db)
 
-	subsRepo := subs.NewSubscriptionsPgRepo(logger, db)
+	categoriesRepo := categories.NewCategoriesPgRepo(logger, db)
+	categoriesIndex := categories.NewIndex(categoriesRepo, logger)
+	servicesRepo := catalog.NewServicesPgRepo(logger, db)
+	catalogIndex := catalog.NewIndex(servicesRepo, logger)
 
-	subsHandler := handlers.NewSubsHandler(subsRepo, logger)
+	pricingRepo := pricing.NewPriceChangesPgRepo(logger, db)
+	subsRepo := subs.NewSubscriptionsPgRepo(logger, db, catalogIndex, pricingRepo)
+	feedsRepo := feeds.NewFeedTokensPgRepo(logger, db)
+	budgetsRepo := budgets.NewBudgetsPgRepo(logger, db)
+	chargesRepo := charges.NewChargesPgRepo(logger, db)
+	transactionsRepo := statements.NewTransactionsPgRepo(logger, db)
+	candidatesRepo := statements.NewCandidatesPgRepo(logger, db)
 
-	r := initSubsRouter(subsHandler)
+	proration := startProration()
+	overlapPolicy := startOverlapPolicy()
+	overlapsChecker := overlaps.NewChecker(subsRepo, catalogIndex, logger)
+	csvImporter := importer.NewCSVImporter(subsRepo, overlapsChecker, catalogIndex, logger)
+	budgetsEvaluator := budgets.NewEvaluator(subsRepo, budgetsRepo, categoriesIndex, budgets.NewLogNotifier(logger),
+		proration, pricingRepo, logger)
+	chargesGenerator := charges.NewGenerator(subsRepo, pricingRepo, chargesRepo, logger)
+	detector := statements.NewDetector(transactionsRepo, candidatesRepo, subsRepo, overlapsChecker, catalogIndex, logger)
+	reconciler := statements.NewReconciler(transactionsRepo, chargesRepo, chargesGenerator, detector, catalogIndex, logger)
+
+	subsHandler := handlers.NewSubsHandler(subsRepo, overlapsChecker, proration, pricingRepo, overlapPolicy, logger)
+	importHandler := handlers.NewImportHandler(csvImporter, overlapPolicy, logger)
+	calendarHandler := handlers.NewCalendarHandler(feedsRepo, subsRepo, logger)
+	forecastHandler := handlers.NewForecastHandler(subsRepo, pricingRepo, proration, logger)
+	budgetsHandler := handlers.NewBudgetsHandler(budgetsRepo, categoriesRepo, budgetsEvaluator, logger)
+	catalogHandler := handlers.NewCatalogHandler(servicesRepo, categoriesRepo, catalogIndex, logger)
+	categoriesHandler := handlers.NewCategoriesHandler(categoriesRepo, categoriesIndex, logger)
+	chargesHandler := handlers.NewChargesHandler(chargesRepo, chargesGenerator, logger)
+	statementsHandler := handlers.NewStatementsHandler(reconciler, transactionsRepo, logger)
+	candidatesHandler := handlers.NewCandidatesHandler(detector, reconciler, candidatesRepo, overlapPolicy, logger)
+	overlapsHandler := handlers.NewOverlapsHandler(overlapsChecker, logger)
+
+	bundle := startI18n()
+
+	startBudgetsEvaluator(context.Background(), budgetsEvaluator)
+	startChargesGenerator(context.Background(), chargesGenerator)
+
+	r := initSubsRouter(bundle, subsHandler, importHandler, calendarHandler, forecastHandler, budgetsHandler,
+		catalogHandler, categoriesHandler, chargesHandler, statementsHandler,
+		candidatesHandler, overlapsHandler)
 
 	logger.Fatal(r.Run(":" + os.Getenv("PORT")))
 }
@@ -0,0 +1,124 @@
+package budgets
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxThresholds = 10
+	MaxThreshold  = 1000
+)
+
+var DefaultThresholds = Thresholds{80, 100}
+
+// Budget - месячный лимит трат пользователя. CategoryID - категория вместе с подкатегориями, nil означает бюджет
+// на все подписки. Удаление категории удаляет и её бюджеты
+type Budget struct {
+	ID         string     `gorm:"primaryKey;type:char(40)"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null"`
+	CategoryID *string    `gorm:"type:char(40);index"`
+	Amount     int64      `gorm:"type:bigint;not null"`
+	Thresholds Thresholds `gorm:"type:varchar(255);not null"`
+	CreatedAt  time.Time  `gorm:"not null"`
+	UpdatedAt  time.Time  `gorm:"not null"`
+}
+
+// Alert фиксирует пересечение порога бюджета в месяце. Каждый порог срабатывает не чаще раза в месяц
+type Alert struct {
+	ID        string    `gorm:"primaryKey;type:char(40)"`
+	BudgetID  string    `gorm:"type:char(40);not null;uniqueIndex:ux_budget_alerts_month"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Month     time.Time `gorm:"type:date;not null;uniqueIndex:ux_budget_alerts_month"`
+	Threshold int       `gorm:"not null;uniqueIndex:ux_budget_alerts_month"`
+	Spend     int64     `gorm:"type:bigint;not null"`
+	Amount    int64     `gorm:"type:bigint;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (Alert) TableName() string {
+	return "budget_alerts"
+}
+
+type BudgetsRepo interface {
+	Create(budget *Budget) (string, error)
+	ReadByID(id string) (*Budget, error)
+	ListByUser(userID uuid.UUID) ([]*Budget, error)
+	// Each обходит все бюджеты пачками, упорядоченно по пользователю
+	Each(fn func(budget *Budget) error) error
+	Update(id string, budget *Budget) error
+	Delete(id string) error
+
+	// RecordAlert сохраняет событие и сообщает, новое ли оно
+	RecordAlert(alert *Alert) (bool, error)
+	ListAlerts(userID uuid.UUID, limit int) ([]*Alert, error)
+}
+
+var (
+	ErrNotFound         = errors.New("budget not found")
+	ErrAlreadyExists    = errors.New("budget for this user and category already exists")
+	ErrInvalidThreshold = errors.New("invalid budget threshold")
+)
+
+// Thresholds - пороги в процентах от суммы бюджета, в базе хранятся строкой "80,100"
+type Thresholds []int
+
+// NewThresholds проверяет пороги и упорядочивает их по возрастанию
+func NewThresholds(values []int) (Thresholds, error) {
+	if len(values) == 0 || len(values) > MaxThresholds {
+		return nil, ErrInvalidThreshold
+	}
+
+	thresholds := slices.Clone(values)
+	slices.Sort(thresholds)
+
+	for i, threshold := range thresholds {
+		if threshold < 1 || threshold > MaxThreshold || (i > 0 && thresholds[i-1] == threshold) {
+			return nil, ErrInvalidThreshold
+		}
+	}
+
+	return thresholds, nil
+}
+
+func (t Thresholds) Value() (driver.Value, error) {
+	parts := make([]string, 0, len(t))
+	for _, threshold := range t {
+		parts = append(parts, strconv.Itoa(threshold))
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+func (t *Thresholds) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("unsupported thresholds type %T", value)
+	}
+
+	*t = (*t)[:0]
+	for _, part := range strings.Split(raw, ",") {
+		if part == "" {
+			continue
+		}
+		threshold, err := strconv.Atoi(part)
+		if err != nil {
+			return err
+		}
+		*t = append(*t, threshold)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const eachBatchSize = 500
+
+type BudgetsPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewBudgetsPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *BudgetsPgRepo {
+	return &BudgetsPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *BudgetsPgRepo) Create(budget *Budget) (string, error) {
+	repo.logger.Debugw("create budget", "budget", budget)
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return "", err
+	}
+
+	budget.ID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	if err = repo.db.WithContext(ctx).Create(budget).Error; err != nil {
+		repo.logger.Errorw("error creating budget", "error", err, "budget", budget)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return "", ErrAlreadyExists
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return "", subs.ErrUnknownCategory
+		}
+		return "", err
+	}
+
+	repo.logger.Infow("budget created", "budget", budget)
+	return budget.ID, nil
+}
+
+func (repo *BudgetsPgRepo) ReadByID(id string) (*Budget, error) {
+	repo.logger.Debugw("read budget by id", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var budget Budget
+	res := repo.db.WithContext(ctx).Where("id = ?", id).First(&budget)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error finding budget by id", "id", id, "error", res.Error)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, res.Error
+	}
+
+	return &budget, nil
+}
+
+func (repo *BudgetsPgRepo) ListByUser(userID uuid.UUID) ([]*Budget, error) {
+	repo.logger.Debugw("list budgets", "userID", userID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	budgets := []*Budget{}
+	res := repo.db.WithContext(ctx).Where("user_id = ?", userID).Order("category_id NULLS FIRST").Find(&budgets)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error listing budgets", "userID", userID, "error", res.Error)
+		return nil, res.Error
+	}
+
+	return budgets, nil
+}
+
+func (repo *BudgetsPgRepo) Each(fn func(budget *Budget) error) error {
+	repo.logger.Debugw("iterate budgets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.ExportSLATimeout)
+	defer cancel()
+
+	var batch []*Budget
+	res := repo.db.WithContext(ctx).Order("user_id, id").FindInBatches(&batch, eachBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, budget := range batch {
+			if err := fn(budget); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if res.Error != nil {
+		repo.logger.Errorw("error iterating budgets", "error", res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (repo *BudgetsPgRepo) Update(id string, budget *Budget) error {
+	repo.logger.Debugw("update budget", "id", id, "budget", budget)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	budget.UpdatedAt = time.Now().UTC()
+	res := repo.db.WithContext(ctx).Model(&Budget{}).Where("id = ?", id).
+		Select("category_id", "amount", "thresholds", "updated_at").Updates(budget)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error updating budget", "id", id, "error", res.Error)
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyExists
+		}
+		if errors.Is(res.Error, gorm.ErrForeignKeyViolated) {
+			return subs.ErrUnknownCategory
+		}
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		repo.logger.Warnw("failed budget update", "id", id)
+		return ErrNotFound
+	}
+
+	repo.logger.Infow("budget updated", "id", id)
+	return nil
+}
+
+// Delete удаляет бюджет вместе с его событиями
+func (repo *BudgetsPgRepo) Delete(id string) error {
+	repo.logger.Debugw("delete budget", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ?", id).Delete(&Alert{}).Error; err != nil {
+			repo.logger.Errorw("error deleting budget alerts", "id", id, "error", err)
+			return err
+		}
+
+		res := tx.Where("id = ?", id).Delete(&Budget{})
+		if res.Error != nil {
+			repo.logger.Errorw("error deleting budget", "id", id, "error", res.Error)
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			repo.logger.Warnw("failed deleting budget", "id", id)
+			return ErrNotFound
+		}
+
+		repo.logger.Infow("budget deleted", "id", id)
+		return nil
+	})
+}
+
+func (repo *BudgetsPgRepo) RecordAlert(alert *Alert) (bool, error) {
+	repo.logger.Debugw("record budget alert", "alert", alert)
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return false, err
+	}
+
+	alert.ID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	res := repo.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	if res.Error != nil {
+		repo.logger.Errorw("error recording budget alert", "error", res.Error, "alert", alert)
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (repo *BudgetsPgRepo) ListAlerts(userID uuid.UUID, limit int) ([]*Alert, error) {
+	repo.logger.Debugw("list budget alerts", "userID", userID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	alerts := []*Alert{}
+	res := repo.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").Limit(limit).Find(&alerts)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error listing budget alerts", "userID", userID, "error", res.Error)
+		return nil, res.Error
+	}
+
+	return alerts, nil
+}
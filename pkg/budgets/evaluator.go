@@ -0,0 +1,205 @@
+package budgets
+
+import (
+	"context"
+	"online-subs/pkg/subs"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Categorizer проверяет, входит ли подписка в категорию бюджета, включая её подкатегории
+type Categorizer interface {
+	InCategory(subscription *subs.Subscription, categoryID string) bool
+}
+
+// Notifier получает каждое новое событие бюджета ровно один раз
+type Notifier interface {
+	Notify(alert *Alert)
+}
+
+type LogNotifier struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogNotifier(logger *zap.SugaredLogger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(alert *Alert) {
+	n.logger.Infow("budget threshold crossed", "budgetID", alert.BudgetID, "userID", alert.UserID,
+		"month", alert.Month, "threshold", alert.Threshold, "spend", alert.Spend, "amount", alert.Amount)
+}
+
+// Status - состояние бюджета в месяце
+type Status struct {
+	Month     time.Time
+	Spend     int64
+	Remaining int64
+	// Crossed - все пройденные пороги, NewAlerts - события, созданные этой проверкой
+	Crossed   []int
+	NewAlerts []*Alert
+}
+
+type Evaluator struct {
+	subsRepo    subs.SubscriptionsRepo
+	budgetsRepo BudgetsRepo
+	categorizer Categorizer
+	notifier    Notifier
+	// proration - политика расчёта трат месяца, та же, что у /total по умолчанию
+	proration subs.Proration
+	// prices - запланированные изменения цен, nil означает текущие цены
+	prices subs.PriceSource
+	logger *zap.SugaredLogger
+}
+
+func NewEvaluator(subsRepo subs.SubscriptionsRepo, budgetsRepo BudgetsRepo, categorizer Categorizer, notifier Notifier,
+	proration subs.Proration, prices subs.PriceSource, logger *zap.SugaredLogger) *Evaluator {
+	return &Evaluator{
+		subsRepo:    subsRepo,
+		budgetsRepo: budgetsRepo,
+		categorizer: categorizer,
+		notifier:    notifier,
+		proration:   proration,
+		prices:      prices,
+		logger:      logger,
+	}
+}
+
+// Evaluate сравнивает траты месяца с бюджетом и создаёт события для пройденных порогов
+func (e *Evaluator) Evaluate(budget *Budget, month time.Time) (*Status, error) {
+	subscriptions, prices, err := e.userSubscriptions(budget.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.evaluate(budget, subscriptions, prices, month)
+}
+
+// EvaluateAll проверяет все бюджеты, подписки каждого пользователя читаются один раз
+func (e *Evaluator) EvaluateAll(month time.Time) error {
+	e.logger.Debugw("evaluate all budgets", "month", month)
+
+	var (
+		currentUser   uuid.UUID
+		subscriptions []*subs.Subscription
+		prices        map[string]subs.PriceFunc
+		evaluated     int
+	)
+
+	err := e.budgetsRepo.Each(func(budget *Budget) error {
+		if budget.UserID != currentUser || subscriptions == nil {
+			var err error
+			if subscriptions, prices, err = e.userSubscriptions(budget.UserID); err != nil {
+				return err
+			}
+			currentUser = budget.UserID
+		}
+
+		if _, err := e.evaluate(budget, subscriptions, prices, month); err != nil {
+			return err
+		}
+		evaluated++
+		return nil
+	})
+	if err != nil {
+		e.logger.Errorw("error evaluating budgets", "error", err, "evaluated", evaluated)
+		return err
+	}
+
+	e.logger.Infow("budgets evaluated", "month", month, "count", evaluated)
+	return nil
+}
+
+// Run периодически проверяет бюджеты текущего месяца до отмены ctx
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.EvaluateAll(time.Now().UTC()); err != nil {
+			e.logger.Warnw("budgets evaluation failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Evaluator) evaluate(budget *Budget, subscriptions []*subs.Subscription, prices map[string]subs.PriceFunc,
+	at time.Time) (*Status, error) {
+	month := subs.MonthStart(at)
+
+	matching := subscriptions
+	if budget.CategoryID != nil {
+		matching = make([]*subs.Subscription, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			if e.categorizer.InCategory(subscription, *budget.CategoryID) {
+				matching = append(matching, subscription)
+			}
+		}
+	}
+
+	spend := subs.TotalCost(matching, month, subs.MonthEnd(month), e.proration, prices)
+	status := &Status{
+		Month:     month,
+		Spend:     spend,
+		Remaining: budget.Amount - spend,
+		Crossed:   []int{},
+		NewAlerts: []*Alert{},
+	}
+
+	for _, threshold := range budget.Thresholds {
+		// Сравнение в целых числах: spend/amount >= threshold%
+		if spend*100 < budget.Amount*int64(threshold) {
+			break
+		}
+		status.Crossed = append(status.Crossed, threshold)
+
+		alert := &Alert{
+			BudgetID:  budget.ID,
+			UserID:    budget.UserID,
+			Month:     month,
+			Threshold: threshold,
+			Spend:     spend,
+			Amount:    budget.Amount,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		created, err := e.budgetsRepo.RecordAlert(alert)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			status.NewAlerts = append(status.NewAlerts, alert)
+			e.notifier.Notify(alert)
+		}
+	}
+
+	return status, nil
+}
+
+// userSubscriptions - подписки пользователя и их цены с учётом запланированных изменений
+func (e *Evaluator) userSubscriptions(userID uuid.UUID) ([]*subs.Subscription, map[string]subs.PriceFunc, error) {
+	subscriptions := []*subs.Subscription{}
+	err := e.subsRepo.Stream(&subs.SubscriptionFilter{UserID: &userID}, func(subscription *subs.Subscription) error {
+		subscriptions = append(subscriptions, subscription)
+		return nil
+	})
+	if err != nil {
+		e.logger.Errorw("error reading subscriptions for budget", "userID", userID, "error", err)
+		return nil, nil, err
+	}
+
+	prices, err := subs.LoadPrices(e.prices, subscriptions)
+	if err != nil {
+		e.logger.Errorw("error loading prices for budget", "userID", userID, "error", err)
+		return nil, nil, err
+	}
+
+	return subscriptions, prices, nil
+}
@@ -0,0 +1,170 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"online-subs/pkg/subs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	productID = "-//online-subs//Subscriptions Service//EN"
+	uidDomain = "subs-service"
+
+	// Подсказка клиентам, как часто перечитывать ленту, чтобы изменения подписок доезжали до календаря
+	refreshInterval = "PT6H"
+
+	icsDateFormat     = "20060102"
+	icsDateTimeFormat = "20060102T150405Z"
+
+	maxLineOctets = 75
+)
+
+// WriteFeed пишет VCALENDAR с ежемесячно повторяющимся событием списания на каждую подписку
+func WriteFeed(w io.Writer, name string, subscriptions []*subs.Subscription, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	writer := &lineWriter{w: bw}
+
+	writer.line("BEGIN:VCALENDAR")
+	writer.line("VERSION:2.0")
+	writer.line("PRODID:" + productID)
+	writer.line("CALSCALE:GREGORIAN")
+	writer.line("METHOD:PUBLISH")
+	writer.line("X-WR-CALNAME:" + escapeText(name))
+	writer.line("REFRESH-INTERVAL;VALUE=DURATION:" + refreshInterval)
+	writer.line("X-PUBLISHED-TTL:" + refreshInterval)
+
+	dtstamp := now.UTC().Format(icsDateTimeFormat)
+	for _, subscription := range subscriptions {
+		writeEvent(writer, subscription, dtstamp)
+	}
+
+	writer.line("END:VCALENDAR")
+
+	if writer.err != nil {
+		return writer.err
+	}
+
+	return bw.Flush()
+}
+
+// writeEvent начинает серию с первого оплачиваемого дня списания, подписка, целиком ушедшая в пробный период, пропускается
+func writeEvent(writer *lineWriter, subscription *subs.Subscription, dtstamp string) {
+	firstCharge := subscription.NextChargeDate(subscription.StartDate.AddDate(0, 0, -1))
+	if firstCharge == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("%s — %d", subscription.Service, subscription.Cost)
+
+	until, skipped := chargeGaps(subscription, *firstCharge)
+
+	rrule := "RRULE:FREQ=MONTHLY"
+	// Серия с 31 числа по RFC 5545 пропускает короткие месяцы, поэтому берётся последний из дней 28..якорь
+	if anchor := subscription.Anchor(); anchor > 28 {
+		days := make([]string, 0, anchor-27)
+		for day := 28; day <= anchor; day++ {
+			days = append(days, strconv.Itoa(day))
+		}
+		rrule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+	if until != nil {
+		rrule += ";UNTIL=" + until.Format(icsDateFormat)
+	}
+
+	writer.line("BEGIN:VEVENT")
+	writer.line("UID:" + subscription.ID + "@" + uidDomain)
+	writer.line("DTSTAMP:" + dtstamp)
+	writer.line("DTSTART;VALUE=DATE:" + firstCharge.Format(icsDateFormat))
+	writer.line("DTEND;VALUE=DATE:" + firstCharge.AddDate(0, 0, 1).Format(icsDateFormat))
+	writer.line(rrule)
+	for _, day := range skipped {
+		writer.line("EXDATE;VALUE=DATE:" + day.Format(icsDateFormat))
+	}
+	writer.line("SUMMARY:" + escapeText(summary))
+	writer.line("DESCRIPTION:" + escapeText(fmt.Sprintf("Monthly charge for %s: %d", subscription.Service, subscription.Cost)))
+	writer.line("TRANSP:TRANSPARENT")
+	writer.line("END:VEVENT")
+}
+
+// chargeGaps - конец серии списаний с учётом бессрочной паузы и дни списания без оплаты внутри неё: паузы и пробный период
+func chargeGaps(subscription *subs.Subscription, firstCharge time.Time) (*time.Time, []time.Time) {
+	until := subscription.EndDate
+	lastFree := firstCharge
+	for _, pause := range subscription.Pauses {
+		if pause.EndDate == nil {
+			if pause.StartDate.After(firstCharge) && (until == nil || pause.StartDate.Before(*until)) {
+				lastCharge := pause.StartDate.AddDate(0, 0, -1)
+				until = &lastCharge
+			}
+			continue
+		}
+		if pause.EndDate.After(lastFree) {
+			lastFree = *pause.EndDate
+		}
+	}
+	if subscription.TrialEndDate != nil && subscription.TrialEndDate.After(lastFree) {
+		lastFree = *subscription.TrialEndDate
+	}
+	if until != nil && until.Before(lastFree) {
+		lastFree = *until
+	}
+
+	charges := subscription.ChargeDates(firstCharge, lastFree)
+
+	var skipped []time.Time
+	for _, day := range subscription.BillingDates(firstCharge, lastFree) {
+		if !slices.ContainsFunc(charges, day.Equal) {
+			skipped = append(skipped, day)
+		}
+	}
+
+	return until, skipped
+}
+
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line дописывает CRLF и сворачивает строки длиннее 75 октетов по RFC 5545, не разрывая UTF-8 символы
+func (lw *lineWriter) line(content string) {
+	if lw.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+
+		lw.write(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Продолжение начинается с пробела, он тоже считается
+		limit = maxLineOctets - 1
+	}
+
+	lw.write(content + "\r\n")
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.WriteString(s)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	MaxAliases    = 50
+	MaxNameLength = 255
+)
+
+// Service - канонический сервис каталога, подписки ссылаются на него через service_id.
+// CategoryID - категория по умолчанию для новых подписок на сервис
+type Service struct {
+	ID           string    `gorm:"primaryKey;type:char(40)"`
+	Name         string    `gorm:"type:varchar(255);not null;uniqueIndex:ux_catalog_services_name"`
+	CategoryID   *string   `gorm:"type:char(40);index"`
+	DefaultPrice *int32    `gorm:"type:int"`
+	Website      string    `gorm:"type:varchar(2048);not null;default:''"`
+	LogoURL      string    `gorm:"type:varchar(2048);not null;default:''"`
+	Aliases      []*Alias  `gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time `gorm:"not null"`
+	UpdatedAt    time.Time `gorm:"not null"`
+}
+
+func (Service) TableName() string {
+	return "catalog_services"
+}
+
+// Alias - вариант написания сервиса. Key - нормализованная форма, уникальна во всём каталоге.
+// Каноническое название тоже хранится алиасом, чтобы поиск шёл по одной таблице
+type Alias struct {
+	Key       string `gorm:"primaryKey;type:varchar(255)"`
+	ServiceID string `gorm:"type:char(40);not null;index"`
+	Name      string `gorm:"type:varchar(255);not null"`
+}
+
+func (Alias) TableName() string {
+	return "catalog_aliases"
+}
+
+type ServicesRepo interface {
+	// Create и Update привязывают к сервису подходящие по алиасам подписки
+	Create(service *Service) (string, error)
+	ReadByID(id string) (*Service, error)
+	List() ([]*Service, error)
+	Update(id string, service *Service) error
+	Delete(id string) error
+}
+
+var (
+	ErrNotFound      = errors.New("catalog service not found")
+	ErrAlreadyExists = errors.New("catalog service with this name or alias already exists")
+)
+
+// CleanName убирает пробелы по краям и схлопывает повторяющиеся пробелы внутри
+func CleanName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// NormalizeKey - ключ сравнения названий: "Netflix", "netflix" и "Netflix " дают один ключ
+func NormalizeKey(name string) string {
+	return strings.ToLower(CleanName(name))
+}
+
+// SetAliases заменяет алиасы сервиса, добавляя каноническое название. Повторы по ключу отбрасываются
+func (s *Service) SetAliases(names []string) {
+	s.Name = CleanName(s.Name)
+	s.Aliases = []*Alias{{Key: NormalizeKey(s.Name), Name: s.Name}}
+
+	seen := map[string]struct{}{s.Aliases[0].Key: {}}
+	for _, name := range names {
+		key := NormalizeKey(name)
+		if _, ok := seen[key]; ok || key == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		s.Aliases = append(s.Aliases, &Alias{Key: key, Name: CleanName(name)})
+	}
+}
+
+// AliasNames - алиасы без канонического названия
+func (s *Service) AliasNames() []string {
+	nameKey := NormalizeKey(s.Name)
+
+	names := make([]string, 0, len(s.Aliases))
+	for _, alias := range s.Aliases {
+		if alias.Key != nameKey {
+			names = append(names, alias.Name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,253 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// normalizedServiceSQL - NormalizeKey на стороне БД
+const normalizedServiceSQL = `lower(btrim(regexp_replace(service, '\s+', ' ', 'g')))`
+
+type ServicesPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewServicesPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *ServicesPgRepo {
+	return &ServicesPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *ServicesPgRepo) Create(service *Service) (string, error) {
+	repo.logger.Debugw("create catalog service", "service", service)
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return "", err
+	}
+
+	service.ID = id
+
+	// Привязка подписок проходит по всей таблице, поэтому таймаут как у пакетных операций
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	err = repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Aliases").Create(service).Error; err != nil {
+			return err
+		}
+
+		if err := repo.createAliases(tx, service); err != nil {
+			return err
+		}
+
+		return repo.linkSubscriptions(tx, service)
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error creating catalog service", "error", err, "service", service)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return "", ErrAlreadyExists
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return "", subs.ErrUnknownCategory
+		}
+		return "", err
+	}
+
+	repo.logger.Infow("catalog service created", "service", service)
+	return service.ID, nil
+}
+
+func (repo *ServicesPgRepo) ReadByID(id string) (*Service, error) {
+	repo.logger.Debugw("read catalog service by id", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var service Service
+	res := repo.db.WithContext(ctx).Preload("Aliases", aliasesOrder).Where("id = ?", id).First(&service)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error finding catalog service by id", "id", id, "error", res.Error)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, res.Error
+	}
+
+	return &service, nil
+}
+
+// List возвращает весь каталог: он небольшой и целиком кэшируется в Index
+func (repo *ServicesPgRepo) List() ([]*Service, error) {
+	repo.logger.Debugw("list catalog services")
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	services := []*Service{}
+	res := repo.db.WithContext(ctx).Preload("Aliases", aliasesOrder).Order("name").Find(&services)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error listing catalog services", "error", res.Error)
+		return nil, res.Error
+	}
+
+	return services, nil
+}
+
+// Update заменяет поля и алиасы сервиса и заново привязывает подписки, в том числе переименовывая их
+func (repo *ServicesPgRepo) Update(id string, service *Service) error {
+	repo.logger.Debugw("update catalog service", "id", id, "service", service)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	service.ID = id
+	service.UpdatedAt = time.Now().UTC()
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Service{}).Where("id = ?", id).
+			Select("name", "category_id", "default_price", "website", "logo_url", "updated_at").Updates(service)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		if err := tx.Where("service_id = ?", id).Delete(&Alias{}).Error; err != nil {
+			return err
+		}
+
+		if err := repo.createAliases(tx, service); err != nil {
+			return err
+		}
+
+		if err := repo.unlinkSubscriptions(tx, id); err != nil {
+			return err
+		}
+
+		return repo.linkSubscriptions(tx, service)
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error updating catalog service", "id", id, "error", err)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyExists
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return subs.ErrUnknownCategory
+		}
+		return err
+	}
+
+	repo.logger.Infow("catalog service updated", "id", id)
+	return nil
+}
+
+// Delete удаляет сервис, подписки остаются со своими названиями, но без service_id
+func (repo *ServicesPgRepo) Delete(id string) error {
+	repo.logger.Debugw("delete catalog service", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repo.unlinkSubscriptions(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Where("service_id = ?", id).Delete(&Alias{}).Error; err != nil {
+			repo.logger.Errorw("error deleting catalog aliases", "id", id, "error", err)
+			return err
+		}
+
+		res := tx.Where("id = ?", id).Delete(&Service{})
+		if res.Error != nil {
+			repo.logger.Errorw("error deleting catalog service", "id", id, "error", res.Error)
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			repo.logger.Warnw("failed deleting catalog service", "id", id)
+			return ErrNotFound
+		}
+
+		repo.logger.Infow("catalog service deleted", "id", id)
+		return nil
+	})
+}
+
+// createAliases вставляет алиасы явно: ассоциации gorm пропускают конфликты, а нам нужна ошибка дубликата
+func (repo *ServicesPgRepo) createAliases(tx *gorm.DB, service *Service) error {
+	for _, alias := range service.Aliases {
+		alias.ServiceID = service.ID
+	}
+
+	return tx.Create(&service.Aliases).Error
+}
+
+// linkSubscriptions проставляет service_id подпискам, чьё название совпадает с алиасом, подпискам без категории -
+// категорию сервиса, и приводит название к каноническому. Название не меняется, если у того же пользователя
+// с той же даты есть другая подписка на этот сервис - иначе сработал бы уникальный индекс, service_id их всё равно объединяет
+func (repo *ServicesPgRepo) linkSubscriptions(tx *gorm.DB, service *Service) error {
+	keys := make([]string, 0, len(service.Aliases))
+	for _, alias := range service.Aliases {
+		keys = append(keys, alias.Key)
+	}
+
+	linked := tx.Model(&subs.Subscription{}).Where(normalizedServiceSQL+" IN ?", keys).
+		Update("service_id", service.ID)
+	if linked.Error != nil {
+		repo.logger.Errorw("error linking subscriptions", "serviceID", service.ID, "error", linked.Error)
+		return linked.Error
+	}
+
+	if service.CategoryID != nil {
+		err := tx.Model(&subs.Subscription{}).Where("service_id = ? AND category_id IS NULL", service.ID).
+			Update("category_id", *service.CategoryID).Error
+		if err != nil {
+			repo.logger.Errorw("error categorizing linked subscriptions", "serviceID", service.ID, "error", err)
+			return err
+		}
+	}
+
+	renamed := tx.Exec(`UPDATE subscriptions s SET service = @name
+		WHERE s.service_id = @id AND s.service <> @name AND NOT EXISTS (
+			SELECT 1 FROM subscriptions o
+			WHERE o.service_id = @id AND o.user_id = s.user_id AND o.start_date = s.start_date AND o.id <> s.id)`,
+		map[string]any{"id": service.ID, "name": service.Name})
+	if renamed.Error != nil {
+		repo.logger.Errorw("error renaming linked subscriptions", "serviceID", service.ID, "error", renamed.Error)
+		return renamed.Error
+	}
+
+	repo.logger.Infow("subscriptions linked to catalog service", "serviceID", service.ID,
+		"linked", linked.RowsAffected, "renamed", renamed.RowsAffected)
+	return nil
+}
+
+func (repo *ServicesPgRepo) unlinkSubscriptions(tx *gorm.DB, id string) error {
+	err := tx.Model(&subs.Subscription{}).Where("service_id = ?", id).Update("service_id", nil).Error
+	if err != nil {
+		repo.logger.Errorw("error unlinking subscriptions", "serviceID", id, "error", err)
+	}
+
+	return err
+}
+
+func aliasesOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("key")
+}
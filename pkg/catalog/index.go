@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"online-subs/pkg/subs"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// IndexTTL - как долго другие экземпляры сервиса могут не видеть изменений каталога
+const IndexTTL = time.Minute
+
+// Index - закэшированный в памяти каталог. Реализует subs.ServiceResolver
+type Index struct {
+	repo   ServicesRepo
+	logger *zap.SugaredLogger
+
+	mu       sync.RWMutex
+	byKey    map[string]*Service
+	loadedAt time.Time
+}
+
+func NewIndex(repo ServicesRepo, logger *zap.SugaredLogger) *Index {
+	return &Index{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Invalidate сбрасывает кэш, следующее обращение перечитает каталог
+func (idx *Index) Invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loadedAt = time.Time{}
+}
+
+// Resolve находит сервис по названию или алиасу. Для неизвестного сервиса возвращается очищенное название без ID
+func (idx *Index) Resolve(name string) (*subs.ResolvedService, error) {
+	byKey, err := idx.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := byKey[NormalizeKey(name)]
+	if !ok {
+		return &subs.ResolvedService{Name: CleanName(name)}, nil
+	}
+
+	return &subs.ResolvedService{ID: &service.ID, Name: service.Name, CategoryID: service.CategoryID}, nil
+}
+
+// snapshot перечитывает каталог по истечении IndexTTL. Если БД недоступна, а кэш уже есть, отдаётся устаревший
+func (idx *Index) snapshot() (map[string]*Service, error) {
+	idx.mu.RLock()
+	if time.Since(idx.loadedAt) < IndexTTL {
+		defer idx.mu.RUnlock()
+		return idx.byKey, nil
+	}
+	idx.mu.RUnlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if time.Since(idx.loadedAt) < IndexTTL {
+		return idx.byKey, nil
+	}
+
+	services, err := idx.repo.List()
+	if err != nil {
+		if idx.byKey != nil {
+			idx.logger.Warnw("error reloading catalog, using stale index", "error", err)
+			return idx.byKey, nil
+		}
+		idx.logger.Errorw("error loading catalog", "error", err)
+		return nil, err
+	}
+
+	idx.byKey = make(map[string]*Service)
+	for _, service := range services {
+		for _, alias := range service.Aliases {
+			idx.byKey[alias.Key] = service
+		}
+	}
+	idx.loadedAt = time.Now()
+
+	idx.logger.Debugw("catalog index loaded", "services", len(services), "aliases", len(idx.byKey))
+	return idx.byKey, nil
+}
+
+// Aliases - каноническое название и все алиасы сервиса каталога. Для неизвестного сервиса - только очищенное название
+func (idx *Index) Aliases(name string) ([]string, error) {
+	byKey, err := idx.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := byKey[NormalizeKey(name)]
+	if !ok {
+		return []string{CleanName(name)}, nil
+	}
+
+	return append([]string{service.Name}, service.AliasNames()...), nil
+}
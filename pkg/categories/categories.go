@@ -0,0 +1,147 @@
+package categories
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MaxDepth - сколько уровней может быть в дереве, корневая категория на первом уровне
+	MaxDepth      = 5
+	MaxNameLength = 255
+)
+
+// Category - узел дерева категорий. ParentID равен nil у категорий верхнего уровня
+type Category struct {
+	ID        string    `gorm:"primaryKey;type:char(40)"`
+	ParentID  *string   `gorm:"type:char(40);index"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+type CategoriesRepo interface {
+	Create(category *Category) (string, error)
+	ReadByID(id string) (*Category, error)
+	List() ([]*Category, error)
+	Update(id string, category *Category) error
+	// Delete удаляет только лист дерева, подписки и сервисы каталога остаются без категории
+	Delete(id string) error
+}
+
+var (
+	ErrNotFound       = errors.New("category not found")
+	ErrAlreadyExists  = errors.New("category with this name already exists under the same parent")
+	ErrParentNotFound = errors.New("parent category not found")
+	ErrCycle          = errors.New("category cannot be moved under itself or its descendant")
+	ErrTooDeep        = errors.New("category tree is too deep")
+	ErrHasChildren    = errors.New("category has subcategories")
+)
+
+// Tree - дерево категорий в памяти, каталог небольшой и читается целиком
+type Tree struct {
+	byID     map[string]*Category
+	children map[string][]string
+}
+
+func NewTree(categories []*Category) *Tree {
+	tree := &Tree{
+		byID:     make(map[string]*Category, len(categories)),
+		children: make(map[string][]string),
+	}
+
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+		if category.ParentID != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+		}
+	}
+
+	return tree
+}
+
+func (t *Tree) Get(id string) (*Category, bool) {
+	category, ok := t.byID[id]
+	return category, ok
+}
+
+// Depth - уровень категории, у корневой 1
+func (t *Tree) Depth(id string) int {
+	depth := 0
+	for category, ok := t.byID[id]; ok && depth <= len(t.byID); category, ok = t.parent(category) {
+		depth++
+	}
+
+	return depth
+}
+
+// Height - число уровней поддерева с вершиной id, у листа 1
+func (t *Tree) Height(id string) int {
+	height := 0
+	for _, child := range t.children[id] {
+		height = max(height, t.Height(child))
+	}
+
+	return height + 1
+}
+
+// IsWithin сообщает, совпадает ли id с ancestorID или лежит в его поддереве
+func (t *Tree) IsWithin(id, ancestorID string) bool {
+	steps := 0
+	for category, ok := t.byID[id]; ok && steps <= len(t.byID); category, ok = t.parent(category) {
+		if category.ID == ancestorID {
+			return true
+		}
+		steps++
+	}
+
+	return false
+}
+
+// Path - названия категорий от корня до id
+func (t *Tree) Path(id string) []string {
+	var path []string
+	for category, ok := t.byID[id]; ok && len(path) <= len(t.byID); category, ok = t.parent(category) {
+		path = append([]string{category.Name}, path...)
+	}
+
+	return path
+}
+
+// Place проверяет, что категорию id (пустой для новой) можно поместить под parentID
+func (t *Tree) Place(id string, parentID *string) error {
+	height := 1
+	if id != "" {
+		height = t.Height(id)
+	}
+
+	if parentID == nil {
+		if height > MaxDepth {
+			return ErrTooDeep
+		}
+		return nil
+	}
+
+	if _, ok := t.byID[*parentID]; !ok {
+		return ErrParentNotFound
+	}
+
+	if id != "" && t.IsWithin(*parentID, id) {
+		return ErrCycle
+	}
+
+	if t.Depth(*parentID)+height > MaxDepth {
+		return ErrTooDeep
+	}
+
+	return nil
+}
+
+func (t *Tree) parent(category *Category) (*Category, bool) {
+	if category.ParentID == nil {
+		return nil, false
+	}
+
+	parent, ok := t.byID[*category.ParentID]
+	return parent, ok
+}
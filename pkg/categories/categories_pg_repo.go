@@ -0,0 +1,200 @@
+package categories
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// lockSQL сериализует изменения дерева, иначе два встречных переноса могут образовать цикл
+const lockSQL = "LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"
+
+type CategoriesPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewCategoriesPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *CategoriesPgRepo {
+	return &CategoriesPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *CategoriesPgRepo) Create(category *Category) (string, error) {
+	repo.logger.Debugw("create category", "category", category)
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return "", err
+	}
+
+	category.ID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	err = repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tree, err := repo.lockedTree(tx)
+		if err != nil {
+			return err
+		}
+
+		if err = tree.Place("", category.ParentID); err != nil {
+			return err
+		}
+
+		return tx.Create(category).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error creating category", "error", err, "category", category)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return "", ErrAlreadyExists
+		}
+		return "", err
+	}
+
+	repo.logger.Infow("category created", "category", category)
+	return category.ID, nil
+}
+
+func (repo *CategoriesPgRepo) ReadByID(id string) (*Category, error) {
+	repo.logger.Debugw("read category by id", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var category Category
+	res := repo.db.WithContext(ctx).Where("id = ?", id).First(&category)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error finding category by id", "id", id, "error", res.Error)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, res.Error
+	}
+
+	return &category, nil
+}
+
+func (repo *CategoriesPgRepo) List() ([]*Category, error) {
+	repo.logger.Debugw("list categories")
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	categories := []*Category{}
+	if err := repo.db.WithContext(ctx).Order("name, id").Find(&categories).Error; err != nil {
+		repo.logger.Errorw("error listing categories", "error", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// Update меняет название и родителя, перенос проверяется на циклы и глубину дерева
+func (repo *CategoriesPgRepo) Update(id string, category *Category) error {
+	repo.logger.Debugw("update category", "id", id, "category", category)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	category.UpdatedAt = time.Now().UTC()
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tree, err := repo.lockedTree(tx)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := tree.Get(id); !ok {
+			return ErrNotFound
+		}
+
+		if err = tree.Place(id, category.ParentID); err != nil {
+			return err
+		}
+
+		return tx.Model(&Category{}).Where("id = ?", id).
+			Select("parent_id", "name", "updated_at").Updates(category).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error updating category", "id", id, "error", err)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+
+	repo.logger.Infow("category updated", "id", id)
+	return nil
+}
+
+func (repo *CategoriesPgRepo) Delete(id string) error {
+	repo.logger.Debugw("delete category", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(lockSQL).Error; err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			repo.logger.Errorw("error counting subcategories", "id", id, "error", err)
+			return err
+		}
+
+		if children > 0 {
+			repo.logger.Warnw("category has subcategories", "id", id, "children", children)
+			return ErrHasChildren
+		}
+
+		for _, table := range []string{"subscriptions", "catalog_services"} {
+			if err := tx.Table(table).Where("category_id = ?", id).Update("category_id", nil).Error; err != nil {
+				repo.logger.Errorw("error detaching category", "id", id, "table", table, "error", err)
+				return err
+			}
+		}
+
+		res := tx.Where("id = ?", id).Delete(&Category{})
+		if res.Error != nil {
+			repo.logger.Errorw("error deleting category", "id", id, "error", res.Error)
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			repo.logger.Warnw("failed deleting category", "id", id)
+			return ErrNotFound
+		}
+
+		repo.logger.Infow("category deleted", "id", id)
+		return nil
+	})
+}
+
+func (repo *CategoriesPgRepo) lockedTree(tx *gorm.DB) (*Tree, error) {
+	if err := tx.Exec(lockSQL).Error; err != nil {
+		repo.logger.Errorw("error locking categories", "error", err)
+		return nil, err
+	}
+
+	var categories []*Category
+	if err := tx.Find(&categories).Error; err != nil {
+		repo.logger.Errorw("error loading categories", "error", err)
+		return nil, err
+	}
+
+	return NewTree(categories), nil
+}
@@ -0,0 +1,76 @@
+package categories
+
+import (
+	"online-subs/pkg/subs"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// IndexTTL - как долго другие экземпляры сервиса могут не видеть изменений дерева
+const IndexTTL = time.Minute
+
+// Index - закэшированное дерево категорий. Реализует budgets.Categorizer
+type Index struct {
+	repo   CategoriesRepo
+	logger *zap.SugaredLogger
+
+	mu       sync.Mutex
+	tree     *Tree
+	loadedAt time.Time
+}
+
+func NewIndex(repo CategoriesRepo, logger *zap.SugaredLogger) *Index {
+	return &Index{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Invalidate сбрасывает кэш, следующее обращение перечитает дерево
+func (idx *Index) Invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loadedAt = time.Time{}
+}
+
+// Tree возвращает дерево, перечитывая его по истечении IndexTTL. Если БД недоступна, отдаётся устаревшее
+func (idx *Index) Tree() (*Tree, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if time.Since(idx.loadedAt) < IndexTTL {
+		return idx.tree, nil
+	}
+
+	categories, err := idx.repo.List()
+	if err != nil {
+		if idx.tree != nil {
+			idx.logger.Warnw("error reloading categories, using stale tree", "error", err)
+			return idx.tree, nil
+		}
+		idx.logger.Errorw("error loading categories", "error", err)
+		return nil, err
+	}
+
+	idx.tree = NewTree(categories)
+	idx.loadedAt = time.Now()
+
+	return idx.tree, nil
+}
+
+// InCategory сообщает, относится ли подписка к категории или любой из её подкатегорий
+func (idx *Index) InCategory(subscription *subs.Subscription, categoryID string) bool {
+	if subscription.CategoryID == nil {
+		return false
+	}
+
+	tree, err := idx.Tree()
+	if err != nil {
+		return false
+	}
+
+	return tree.IsWithin(*subscription.CategoryID, categoryID)
+}
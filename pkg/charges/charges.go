@@ -0,0 +1,113 @@
+package charges
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCurrency - валюта цен подписок, отдельной валюты у подписок нет
+const DefaultCurrency = "RUB"
+
+// Status - состояние списания в реестре: ожидаемое или сверенное с фактической оплатой
+type Status string
+
+const (
+	StatusExpected Status = "expected"
+	StatusPaid     Status = "paid"
+	StatusFailed   Status = "failed"
+	StatusRefunded Status = "refunded"
+)
+
+var Statuses = []Status{StatusExpected, StatusPaid, StatusFailed, StatusRefunded}
+
+// settleTransitions - из каких состояний можно перейти в данное при отметке оплаты
+var settleTransitions = map[Status][]Status{
+	StatusPaid:     {StatusExpected, StatusFailed},
+	StatusFailed:   {StatusExpected},
+	StatusRefunded: {StatusPaid},
+}
+
+// Charge - ожидаемое списание по подписке в день Date. Генератор пересоздаёт только ожидаемые списания,
+// отмеченные оплата, отказ и возврат сохраняются вместе с фактической суммой
+type Charge struct {
+	ID             string    `gorm:"primaryKey;type:char(40)"`
+	SubscriptionID string    `gorm:"type:char(40);not null;uniqueIndex:ux_charges_sub_date"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index:ix_charges_user_date"`
+	Service        string    `gorm:"type:varchar(255);not null"`
+	Date           time.Time `gorm:"type:date;not null;uniqueIndex:ux_charges_sub_date;index:ix_charges_user_date"`
+	Amount         int64     `gorm:"type:bigint;not null"`
+	Currency       string    `gorm:"type:char(3);not null"`
+	Status         Status    `gorm:"type:varchar(16);not null;default:expected;index"`
+	// ActualAmount - фактически списанная или возвращённая сумма, nil пока списание не сверено
+	ActualAmount *int64     `gorm:"type:bigint"`
+	SettledAt    *time.Time `gorm:"type:timestamptz"`
+	CreatedAt    time.Time  `gorm:"not null"`
+}
+
+type ChargeFilter struct {
+	UserID         *uuid.UUID
+	SubscriptionID *string
+	Statuses       []Status
+	// DateFrom и DateTo ограничивают дни списаний включительно
+	DateFrom *time.Time
+	DateTo   *time.Time
+
+	// Limit 0 - все списания без ограничения
+	Limit  int
+	Offset int
+}
+
+type ChargesRepo interface {
+	// Sync приводит ожидаемые списания подписок subscriptionIDs к charges: списания на те же дни обновляются
+	// с прежним ID, лишние удаляются, сверенные не трогаются. Возвращает число созданных и обновлённых списаний
+	Sync(subscriptionIDs []string, charges []*Charge) (int64, error)
+	List(filter *ChargeFilter) ([]*Charge, int64, error)
+	ReadByID(id string) (*Charge, error)
+	// Settle отмечает списание оплаченным, неуспешным или возвращённым
+	Settle(id string, status Status, actualAmount *int64) (*Charge, error)
+}
+
+var (
+	ErrNotFound          = errors.New("charge not found")
+	ErrUnknownStatus     = errors.New("unknown charge status")
+	ErrInvalidTransition = errors.New("charge status transition is not allowed")
+	ErrNegativeAmount    = errors.New("amount must not be negative")
+)
+
+func ParseStatus(value string) (Status, error) {
+	for _, status := range Statuses {
+		if string(status) == value {
+			return status, nil
+		}
+	}
+
+	return "", ErrUnknownStatus
+}
+
+// Settle проверяет переход и проставляет фактическую сумму: для оплаты и возврата по умолчанию ожидаемую
+func (c *Charge) Settle(status Status, actualAmount *int64, now time.Time) error {
+	allowed, ok := settleTransitions[status]
+	if !ok || !slices.Contains(allowed, c.Status) {
+		return ErrInvalidTransition
+	}
+
+	if actualAmount != nil && *actualAmount < 0 {
+		return ErrNegativeAmount
+	}
+	if actualAmount == nil && status != StatusFailed {
+		amount := c.Amount
+		if c.ActualAmount != nil {
+			amount = *c.ActualAmount
+		}
+		actualAmount = &amount
+	}
+
+	c.Status = status
+	c.ActualAmount = actualAmount
+	c.SettledAt = &now
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package charges
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Размер пачки строк в одном INSERT
+	insertBatchSize = 1000
+	// Число подписок в одном DELETE устаревших списаний, дни всех их списаний передаются параметрами
+	deleteBatchSize = 100
+)
+
+type ChargesPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewChargesPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *ChargesPgRepo {
+	return &ChargesPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *ChargesPgRepo) Sync(subscriptionIDs []string, charges []*Charge) (int64, error) {
+	repo.logger.Debugw("sync charges", "subscriptions", len(subscriptionIDs), "charges", len(charges))
+
+	for _, charge := range charges {
+		id, err := utils.GenerateID()
+		if err != nil {
+			repo.logger.Errorw("error generating id", "err", err)
+			return 0, err
+		}
+		charge.ID = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	var synced int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repo.deleteStale(tx, subscriptionIDs, charges); err != nil {
+			return err
+		}
+
+		if len(charges) == 0 {
+			return nil
+		}
+
+		// Ожидаемое списание на тот же день обновляется с прежним ID, на который могут ссылаться клиенты и сверка.
+		// Сверенное списание остаётся как есть
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "service", "amount", "currency"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Eq{Column: clause.Column{Table: "charges", Name: "status"}, Value: StatusExpected},
+			}},
+		}).CreateInBatches(charges, insertBatchSize)
+		synced = res.RowsAffected
+		return res.Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error syncing charges", "error", err)
+		return 0, err
+	}
+
+	return synced, nil
+}
+
+// deleteStale удаляет ожидаемые списания подписок subscriptionIDs, дней которых больше нет в charges
+func (repo *ChargesPgRepo) deleteStale(tx *gorm.DB, subscriptionIDs []string, charges []*Charge) error {
+	days := make(map[string][][]any)
+	for _, charge := range charges {
+		days[charge.SubscriptionID] = append(days[charge.SubscriptionID], []any{charge.SubscriptionID, charge.Date.Format(subs.DateFormat)})
+	}
+
+	for start := 0; start < len(subscriptionIDs); start += deleteBatchSize {
+		ids := subscriptionIDs[start:min(start+deleteBatchSize, len(subscriptionIDs))]
+
+		var keep [][]any
+		for _, id := range ids {
+			keep = append(keep, days[id]...)
+		}
+
+		query := tx.Where("subscription_id IN ? AND status = ?", ids, StatusExpected)
+		if len(keep) > 0 {
+			query = query.Where("(subscription_id, date) NOT IN ?", keep)
+		}
+		if err := query.Delete(&Charge{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *ChargesPgRepo) List(filter *ChargeFilter) ([]*Charge, int64, error) {
+	repo.logger.Debugw("list charges", "filter", filter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	query := repo.db.WithContext(ctx).Model(&Charge{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.SubscriptionID != nil {
+		query = query.Where("subscription_id = ?", *filter.SubscriptionID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("date <= ?", *filter.DateTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		repo.logger.Errorw("error counting charges", "filter", filter, "error", err)
+		return nil, 0, err
+	}
+
+	query = query.Order("date, id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	charges := []*Charge{}
+	res := query.Find(&charges)
+	if res.Error != nil {
+		repo.logger.Errorw("error listing charges", "filter", filter, "error", res.Error)
+		return nil, 0, res.Error
+	}
+
+	return charges, total, nil
+}
+
+func (repo *ChargesPgRepo) ReadByID(id string) (*Charge, error) {
+	repo.logger.Debugw("read charge by id", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var charge Charge
+	res := repo.db.WithContext(ctx).Where("id = ?", id).First(&charge)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error finding charge by id", "id", id, "error", res.Error)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, res.Error
+	}
+
+	return &charge, nil
+}
+
+func (repo *ChargesPgRepo) Settle(id string, status Status, actualAmount *int64) (*Charge, error) {
+	repo.logger.Debugw("settle charge", "id", id, "status", status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var charge Charge
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&charge)
+		if res.Error != nil {
+			if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return res.Error
+		}
+
+		if err := charge.Settle(status, actualAmount, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		return tx.Model(&charge).Select("status", "actual_amount", "settled_at").Updates(&charge).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error settling charge", "id", id, "error", err)
+		return nil, err
+	}
+
+	repo.logger.Infow("charge settled", "id", id, "status", status)
+	return &charge, nil
+}
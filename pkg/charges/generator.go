@@ -0,0 +1,149 @@
+package charges
+
+import (
+	"context"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/subs"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHorizon и MaxHorizon - на сколько месяцев вперёд от текущего генерируются ожидаемые списания
+	DefaultHorizon = 3
+	MaxHorizon     = 24
+
+	// Подписки обрабатываются пачками: на пачку один запрос изменений цены и одна транзакция реестра
+	generateBatchSize = 500
+)
+
+type GenerateResult struct {
+	Subscriptions int
+	Charges       int64
+}
+
+type Generator struct {
+	subsRepo    subs.SubscriptionsRepo
+	pricingRepo pricing.PriceChangesRepo
+	chargesRepo ChargesRepo
+	logger      *zap.SugaredLogger
+}
+
+func NewGenerator(subsRepo subs.SubscriptionsRepo, pricingRepo pricing.PriceChangesRepo, chargesRepo ChargesRepo,
+	logger *zap.SugaredLogger) *Generator {
+	return &Generator{
+		subsRepo:    subsRepo,
+		pricingRepo: pricingRepo,
+		chargesRepo: chargesRepo,
+		logger:      logger,
+	}
+}
+
+// Build - ожидаемые списания подписки с её начала по день until: дни списания вне пробного периода и пауз,
+// сумма - цена на день списания с учётом запланированных изменений
+func Build(subscription *subs.Subscription, changes []*pricing.PriceChange, until, now time.Time) []*Charge {
+	dates := subscription.ChargeDates(subscription.StartDate, until)
+
+	charges := make([]*Charge, 0, len(dates))
+	for _, date := range dates {
+		cost, _ := pricing.CostAt(subscription.Cost, changes, date)
+		charges = append(charges, &Charge{
+			SubscriptionID: subscription.ID,
+			UserID:         subscription.UserID,
+			Service:        subscription.Service,
+			Date:           date,
+			Amount:         int64(cost),
+			Currency:       DefaultCurrency,
+			Status:         StatusExpected,
+			CreatedAt:      now,
+		})
+	}
+
+	return charges
+}
+
+// Generate пересобирает реестр для подписок фильтра по день until
+func (g *Generator) Generate(filter *subs.SubscriptionFilter, until time.Time) (*GenerateResult, error) {
+	g.logger.Debugw("generate charges", "filter", filter, "until", until)
+
+	result := &GenerateResult{}
+	batch := make([]*subs.Subscription, 0, generateBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		created, err := g.sync(batch, until)
+		if err != nil {
+			return err
+		}
+
+		result.Subscriptions += len(batch)
+		result.Charges += created
+		batch = batch[:0]
+		return nil
+	}
+
+	err := g.subsRepo.Stream(filter, func(subscription *subs.Subscription) error {
+		batch = append(batch, subscription)
+		if len(batch) < generateBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		g.logger.Errorw("error generating charges", "filter", filter, "error", err)
+		return nil, err
+	}
+
+	g.logger.Infow("charges generated", "subscriptions", result.Subscriptions, "charges", result.Charges, "until", until)
+	return result, nil
+}
+
+func (g *Generator) sync(subscriptions []*subs.Subscription, until time.Time) (int64, error) {
+	ids := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+
+	changes, err := g.pricingRepo.ListForSubscriptions(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var charges []*Charge
+	for _, subscription := range subscriptions {
+		charges = append(charges, Build(subscription, changes[subscription.ID], until, now)...)
+	}
+
+	return g.chargesRepo.Sync(ids, charges)
+}
+
+// Run периодически пересобирает реестр на horizon месяцев вперёд до отмены ctx
+func (g *Generator) Run(ctx context.Context, interval time.Duration, horizon int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := g.Generate(&subs.SubscriptionFilter{}, HorizonEnd(subs.Today(), horizon)); err != nil {
+			g.logger.Warnw("charges generation failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HorizonEnd - последний день месяца, отстоящего от day на horizon месяцев
+func HorizonEnd(day time.Time, horizon int) time.Time {
+	return subs.MonthEnd(subs.MonthStart(day).AddDate(0, horizon, 0))
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w)}
+
+	if err := writer.w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (cw *csvWriter) WriteRow(row *Row) error {
+	endDate := ""
+	if row.EndDate != nil {
+		endDate = *row.EndDate
+	}
+
+	return cw.w.Write([]string{
+		row.ID,
+		row.Service,
+		strconv.FormatInt(int64(row.Cost), 10),
+		row.UserID,
+		row.StartDate,
+		endDate,
+		strconv.Itoa(row.MonthsActive),
+		strconv.FormatInt(row.TotalPaid, 10),
+	})
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"errors"
+	"io"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format, expected csv, ndjson or xlsx")
+
+type Row struct {
+	ID           string  `json:"id"`
+	Service      string  `json:"service_name"`
+	Cost         int32   `json:"price"`
+	UserID       string  `json:"user_id"`
+	StartDate    string  `json:"start_date"`
+	EndDate      *string `json:"end_date"`
+	MonthsActive int     `json:"months_active"`
+	TotalPaid    int64   `json:"total_paid"`
+}
+
+var columns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "months_active", "total_paid"}
+
+// Writer пишет строки сразу в выходной поток, весь результат в памяти не держится
+type Writer interface {
+	WriteRow(row *Row) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	return string(f)
+}
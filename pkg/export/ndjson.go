@@ -0,0 +1,23 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+// Encode сам дописывает перевод строки после каждого объекта
+func (nw *ndjsonWriter) WriteRow(row *Row) error {
+	return nw.encoder.Encode(row)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// Минимальный SpreadsheetML-пакет из одного листа. Лист пишется последним файлом архива,
+// поэтому строки уходят в zip-поток по мере поступления
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{
+		name: "[Content_Types].xml",
+		content: xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`,
+	},
+	{
+		name: "_rels/.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`,
+	},
+	{
+		name: "xl/workbook.xml",
+		content: xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="subscriptions" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`,
+	},
+	{
+		name: "xl/_rels/workbook.xml.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`,
+	},
+}
+
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		partWriter, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetWriter, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &xlsxWriter{
+		archive: archive,
+		sheet:   bufio.NewWriter(sheetWriter),
+	}
+
+	writer.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writer.sheet.WriteString("<row>")
+	for _, column := range columns {
+		writer.writeString(column)
+	}
+	writer.sheet.WriteString("</row>")
+
+	return writer, nil
+}
+
+func (xw *xlsxWriter) WriteRow(row *Row) error {
+	xw.sheet.WriteString("<row>")
+
+	xw.writeString(row.ID)
+	xw.writeString(row.Service)
+	xw.writeNumber(int64(row.Cost))
+	xw.writeString(row.UserID)
+	xw.writeString(row.StartDate)
+	if row.EndDate != nil {
+		xw.writeString(*row.EndDate)
+	} else {
+		xw.sheet.WriteString("<c/>")
+	}
+	xw.writeNumber(int64(row.MonthsActive))
+	xw.writeNumber(row.TotalPaid)
+
+	_, err := xw.sheet.WriteString("</row>")
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := xw.sheet.WriteString("</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return xw.archive.Close()
+}
+
+// Ошибки записи в bufio.Writer запоминаются и вернутся из следующего WriteString или Flush
+func (xw *xlsxWriter) writeString(value string) {
+	xw.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	_ = xml.EscapeText(xw.sheet, []byte(value))
+	xw.sheet.WriteString("</t></is></c>")
+}
+
+func (xw *xlsxWriter) writeNumber(value int64) {
+	xw.sheet.WriteString("<c><v>")
+	xw.sheet.WriteString(strconv.FormatInt(value, 10))
+	xw.sheet.WriteString("</v></c>")
+}
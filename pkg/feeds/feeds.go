@@ -0,0 +1,33 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FeedToken struct {
+	UserID    uuid.UUID `gorm:"primaryKey;type:uuid"`
+	TokenHash string    `gorm:"type:char(64);uniqueIndex;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+type FeedTokensRepo interface {
+	// Issue создаёт новый токен пользователю, старый при этом перестаёт работать
+	Issue(userID uuid.UUID) (string, error)
+	ResolveUserID(token string) (uuid.UUID, error)
+	Revoke(userID uuid.UUID) error
+}
+
+var (
+	ErrNotFound = errors.New("feed token not found")
+)
+
+// В базе хранится только хэш, сам токен показывается пользователю один раз при выпуске
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
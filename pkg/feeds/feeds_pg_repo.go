@@ -0,0 +1,102 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeedTokensPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewFeedTokensPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *FeedTokensPgRepo {
+	return &FeedTokensPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *FeedTokensPgRepo) Issue(userID uuid.UUID) (string, error) {
+	repo.logger.Debugw("issue feed token", "userID", userID)
+
+	token, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating feed token", "err", err)
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	feedToken := &FeedToken{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	res := repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at"}),
+	}).Create(feedToken)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error issuing feed token", "error", res.Error, "userID", userID)
+		return "", res.Error
+	}
+
+	repo.logger.Infow("feed token issued", "userID", userID)
+	return token, nil
+}
+
+func (repo *FeedTokensPgRepo) ResolveUserID(token string) (uuid.UUID, error) {
+	repo.logger.Debugw("resolve feed token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var feedToken FeedToken
+	res := repo.db.WithContext(ctx).Where("token_hash = ?", hashToken(token)).First(&feedToken)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			repo.logger.Warnw("unknown feed token")
+			return uuid.Nil, ErrNotFound
+		}
+		repo.logger.Errorw("error resolving feed token", "error", res.Error)
+		return uuid.Nil, res.Error
+	}
+
+	repo.logger.Debugw("feed token resolved", "userID", feedToken.UserID)
+	return feedToken.UserID, nil
+}
+
+func (repo *FeedTokensPgRepo) Revoke(userID uuid.UUID) error {
+	repo.logger.Debugw("revoke feed token", "userID", userID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	res := repo.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&FeedToken{})
+
+	if res.Error != nil {
+		repo.logger.Errorw("error revoking feed token", "userID", userID, "error", res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		repo.logger.Warnw("failed revoking feed token", "userID", userID)
+		return ErrNotFound
+	}
+
+	repo.logger.Infow("feed token revoked", "userID", userID)
+	return nil
+}
@@ -0,0 +1,94 @@
+package forecast
+
+import (
+	"math"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/subs"
+	"time"
+)
+
+const MaxMonths = 36
+
+type Options struct {
+	// From - первый месяц прогноза
+	From   time.Time
+	Months int
+	// ChurnRate - вероятность отмены подписки за месяц, 0 - подписки продлеваются до end_date или бессрочно
+	ChurnRate float64
+	// Proration - как считаются месяцы, в которых подписка начинается, заканчивается или меняет цену
+	Proration subs.Proration
+}
+
+type Contribution struct {
+	SubscriptionID string
+	Service        string
+	// Cost - сумма за месяц по политике Proration
+	Cost int32
+	// PriceChanged - цена в этом месяце отличается от текущей из-за запланированного изменения
+	PriceChanged bool
+}
+
+type Month struct {
+	Month time.Time
+	// Total - сумма без учёта оттока, Expected - с учётом ChurnRate
+	Total         int64
+	Expected      float64
+	Subscriptions []*Contribution
+}
+
+type Forecast struct {
+	Months   []*Month
+	Total    int64
+	Expected float64
+}
+
+// Build проецирует ежемесячные траты: сумма подписки за месяц считается по политике Proration без пробного периода и пауз,
+// цена берётся с учётом запланированных изменений. Отток уменьшает ожидаемую сумму в (1-ChurnRate)^k раз
+// для k-го месяца после From
+func Build(subscriptions []*subs.Subscription, changes map[string][]*pricing.PriceChange, opts Options) *Forecast {
+	from := subs.MonthStart(opts.From)
+
+	forecast := &Forecast{Months: make([]*Month, 0, opts.Months)}
+	for k := 0; k < opts.Months; k++ {
+		month := &Month{
+			Month:         from.AddDate(0, k, 0),
+			Subscriptions: []*Contribution{},
+		}
+
+		for _, subscription := range subscriptions {
+			subscriptionChanges := changes[subscription.ID]
+			price := func(day time.Time) int32 {
+				cost, _ := pricing.CostAt(subscription.Cost, subscriptionChanges, day)
+				return cost
+			}
+
+			cost, billed := subscription.CostIn(month.Month, subs.MonthEnd(month.Month), opts.Proration, price)
+			if !billed {
+				continue
+			}
+
+			current, changed := pricing.CostAt(subscription.Cost, subscriptionChanges, subs.MonthEnd(month.Month))
+			month.Total += cost
+			month.Subscriptions = append(month.Subscriptions, &Contribution{
+				SubscriptionID: subscription.ID,
+				Service:        subscription.Service,
+				Cost:           int32(cost),
+				PriceChanged:   changed && current != subscription.Cost,
+			})
+		}
+
+		month.Expected = roundCents(float64(month.Total) * math.Pow(1-opts.ChurnRate, float64(k)))
+
+		forecast.Total += month.Total
+		forecast.Expected += month.Expected
+		forecast.Months = append(forecast.Months, month)
+	}
+
+	forecast.Expected = roundCents(forecast.Expected)
+
+	return forecast
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"online-subs/pkg/subs"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AggregateGroup struct {
+	// Group - значения полей группировки, null для подписок без end_date при группировке по end_month
+	Group   map[string]*string `json:"group"`
+	Metrics map[string]any     `json:"metrics" swaggertype:"object"`
+}
+
+type AggregateResponse struct {
+	Message string            `json:"message"`
+	Groups  []*AggregateGroup `json:"groups"`
+}
+
+var defaultMetrics = []subs.Metric{subs.MetricCount, subs.MetricSumCost}
+
+// Aggregate godoc
+// @Summary Aggregate subscriptions
+// @Description Groups subscriptions matching the filter and computes metrics in the database.
+// @Description total_cost is the cost over the startDate..endDate period, both are required for it. At most 1000 groups are returned.
+// @Tags analytics
+// @Produce json
+// @Param groupBy query string false "Comma separated: service, service_id, category, root_category, user_id, start_month, end_month"
+// @Param metrics query string false "Comma separated: count, sum_cost, total_cost, avg_price; count,sum_cost by default"
+// @Param service query string false "Service name"
+// @Param userID query string false "User UUID"
+// @Param startDate query string false "Start date YYYY-MM-DD or MM-YYYY"
+// @Param endDate query string false "End date YYYY-MM-DD or MM-YYYY (last day of the month)"
+// @Param priceMin query int false "Minimal cost, inclusive"
+// @Param priceMax query int false "Maximal cost, inclusive"
+// @Param services query []string false "Service names, comma separated or repeated" collectionFormat(csv)
+// @Param serviceIDs query []string false "Catalog service IDs, comma separated or repeated" collectionFormat(csv)
+// @Param categoryID query string false "Category ID, subcategories included"
+// @Param tags query []string false "Subscriptions with any of the tags, comma separated or repeated" collectionFormat(csv)
+// @Param status query []string false "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired" collectionFormat(csv)
+// @Param userIDs query []string false "User UUIDs, comma separated or repeated" collectionFormat(csv)
+// @Param search query string false "Case-insensitive substring of the service name"
+// @Param activeOnly query bool false "Only subscriptions active today"
+// @Param endedOnly query bool false "Only subscriptions ended before today"
+// @Param proration query string false "Proration policy for total_cost: none, daily, by_billing_day; the service default if omitted"
+// @Success 200 {object} AggregateResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/analytics/aggregate [get]
+func (h *SubsHandler) Aggregate(c *gin.Context) {
+	h.logger.Debugw("handling Aggregate()")
+
+	filter, err := constructFilterFromContextQuery(c, h.logger)
+	if err != nil {
+		h.logger.Errorw("Failed to construct filter from context query", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	if filter.Proration, err = prorationParam(c, h.logger, h.proration); err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	query := &subs.AggregateQuery{Filter: filter}
+
+	for _, value := range queryList(c, "groupBy") {
+		groupBy, err := subs.ParseGroupBy(value)
+		if err != nil {
+			h.logger.Errorw("Invalid group by", "error", err, "value", value)
+
+			respondProblem(c, newFieldError("groupBy", CodeInvalidParam, err, value))
+			return
+		}
+		query.GroupBy = append(query.GroupBy, groupBy)
+	}
+
+	for _, value := range queryList(c, "metrics") {
+		metric, err := subs.ParseMetric(value)
+		if err != nil {
+			h.logger.Errorw("Invalid metric", "error", err, "value", value)
+
+			respondProblem(c, newFieldError("metrics", CodeInvalidParam, err, value))
+			return
+		}
+		query.Metrics = append(query.Metrics, metric)
+	}
+	if len(query.Metrics) == 0 {
+		query.Metrics = defaultMetrics
+	}
+
+	if slices.Contains(query.Metrics, subs.MetricTotalCost) && (filter.StartDate == nil || filter.EndDate == nil) {
+		h.logger.Errorw("Missing period bounds for total cost", "error", ErrRequiredParam)
+
+		respondProblem(c, requiredParamsError(map[string]bool{
+			"startDate": filter.StartDate == nil,
+			"endDate":   filter.EndDate == nil,
+		}))
+		return
+	}
+
+	rows, err := h.subsRepo.Aggregate(query)
+	if err != nil {
+		h.logger.Errorw("Failed to aggregate subscriptions", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	groups := make([]*AggregateGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, aggregateGroup(query, row))
+	}
+
+	h.logger.Infow("Successfully aggregated subscriptions", "groups", len(groups))
+	c.JSON(http.StatusOK, AggregateResponse{
+		Message: messageSuccess,
+		Groups:  groups,
+	})
+}
+
+func aggregateGroup(query *subs.AggregateQuery, row *subs.AggregateRow) *AggregateGroup {
+	group := &AggregateGroup{
+		Group:   make(map[string]*string, len(query.GroupBy)),
+		Metrics: make(map[string]any, len(query.Metrics)),
+	}
+
+	for _, groupBy := range query.GroupBy {
+		var value *string
+		switch groupBy {
+		case subs.GroupByService:
+			value = row.Service
+		case subs.GroupByServiceID:
+			value = row.ServiceID
+		case subs.GroupByCategory:
+			value = row.Category
+		case subs.GroupByRootCategory:
+			value = row.RootCategory
+		case subs.GroupByUserID:
+			if row.UserID != nil {
+				userID := row.UserID.String()
+				value = &userID
+			}
+		case subs.GroupByStartMonth:
+			if row.StartMonth != nil {
+				month := row.StartMonth.Format(subs.TimeParseFormat)
+				value = &month
+			}
+		case subs.GroupByEndMonth:
+			if row.EndMonth != nil {
+				month := row.EndMonth.Format(subs.TimeParseFormat)
+				value = &month
+			}
+		}
+		group.Group[string(groupBy)] = value
+	}
+
+	for _, metric := range query.Metrics {
+		switch metric {
+		case subs.MetricCount:
+			group.Metrics[string(metric)] = row.Count
+		case subs.MetricSumCost:
+			group.Metrics[string(metric)] = row.SumCost
+		case subs.MetricTotalCost:
+			group.Metrics[string(metric)] = row.TotalCost
+		case subs.MetricAvgPrice:
+			group.Metrics[string(metric)] = row.AvgPrice
+		}
+	}
+
+	return group
+}
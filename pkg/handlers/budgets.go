@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/budgets"
+	"online-subs/pkg/categories"
+	"online-subs/pkg/subs"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const budgetAlertsLimit = 100
+
+var (
+	ErrBudgetAmount = errors.New("budget amount must be positive")
+)
+
+type BudgetsHandler struct {
+	budgetsRepo    budgets.BudgetsRepo
+	categoriesRepo categories.CategoriesRepo
+	evaluator      *budgets.Evaluator
+	logger         *zap.SugaredLogger
+}
+
+func NewBudgetsHandler(budgetsRepo budgets.BudgetsRepo, categoriesRepo categories.CategoriesRepo, evaluator *budgets.Evaluator,
+	logger *zap.SugaredLogger) *BudgetsHandler {
+	return &BudgetsHandler{
+		budgetsRepo:    budgetsRepo,
+		categoriesRepo: categoriesRepo,
+		evaluator:      evaluator,
+		logger:         logger,
+	}
+}
+
+type budgetRequest struct {
+	UserID     uuid.UUID `json:"user_id"`
+	CategoryID string    `json:"category_id"`
+	Amount     int64     `json:"amount"`
+	Thresholds []int     `json:"thresholds" example:"80,100"`
+}
+
+type budgetUpdateRequest struct {
+	CategoryID *string `json:"category_id"`
+	Amount     *int64  `json:"amount"`
+	Thresholds []int   `json:"thresholds" example:"80,100"`
+}
+
+type BudgetStatusDTO struct {
+	Month     string `json:"month" example:"10-2026"`
+	Spend     int64  `json:"spend"`
+	Remaining int64  `json:"remaining"`
+	Crossed   []int  `json:"crossed_thresholds"`
+}
+
+type BudgetDTO struct {
+	ID         string           `json:"id"`
+	UserID     string           `json:"user_id"`
+	CategoryID string           `json:"category_id,omitempty"`
+	Amount     int64            `json:"amount"`
+	Thresholds []int            `json:"thresholds"`
+	Status     *BudgetStatusDTO `json:"status,omitempty"`
+}
+
+type BudgetAlertDTO struct {
+	ID        string    `json:"id"`
+	BudgetID  string    `json:"budget_id"`
+	Month     string    `json:"month" example:"10-2026"`
+	Threshold int       `json:"threshold"`
+	Spend     int64     `json:"spend"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BudgetResponse struct {
+	Message string     `json:"message"`
+	Budget  *BudgetDTO `json:"budget"`
+}
+
+type BudgetsResponse struct {
+	Message string       `json:"message"`
+	Budgets []*BudgetDTO `json:"budgets"`
+}
+
+type BudgetAlertsResponse struct {
+	Message string            `json:"message"`
+	Alerts  []*BudgetAlertDTO `json:"alerts"`
+}
+
+// CreateBudget godoc
+// @Summary Create budget
+// @Description Monthly spending limit for a user, optionally for one category including its subcategories. Thresholds are percents of the amount, 80 and 100 by default.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param request body budgetRequest true "Budget"
+// @Success 201 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets [post]
+func (h *BudgetsHandler) CreateBudget(c *gin.Context) {
+	h.logger.Debugw("handling CreateBudget()")
+
+	var request budgetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	if request.UserID == uuid.Nil {
+		h.logger.Errorw("Missing user ID", "error", ErrRequiredParam)
+
+		respondProblem(c, newFieldError("user_id", CodeRequired, ErrRequiredParam))
+		return
+	}
+
+	budget := &budgets.Budget{
+		UserID:     request.UserID,
+		CategoryID: budgetCategory(request.CategoryID),
+		Amount:     request.Amount,
+		Thresholds: budgets.DefaultThresholds,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	if err := h.validateBudget(budget, request.Thresholds); err != nil {
+		h.logger.Errorw("Invalid budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	id, err := h.budgetsRepo.Create(budget)
+	if err != nil {
+		h.logger.Errorw("Failed to create budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully created budget", "id", id)
+	c.JSON(http.StatusCreated, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// GetBudget godoc
+// @Summary Get budget with its status in the current month
+// @Description Evaluating the budget also records alerts for thresholds crossed since the last check.
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} BudgetResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets/{id} [get]
+func (h *BudgetsHandler) GetBudget(c *gin.Context) {
+	h.logger.Debugw("handling GetBudget()")
+
+	budget, err := h.budgetsRepo.ReadByID(c.Param("id"))
+	if err != nil {
+		h.logger.Errorw("Failed to read budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	status, err := h.evaluator.Evaluate(budget, time.Now().UTC())
+	if err != nil {
+		h.logger.Errorw("Failed to evaluate budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dto := budgetDTO(budget)
+	dto.Status = &BudgetStatusDTO{
+		Month:     status.Month.Format(subs.TimeParseFormat),
+		Spend:     status.Spend,
+		Remaining: status.Remaining,
+		Crossed:   status.Crossed,
+	}
+
+	c.JSON(http.StatusOK, BudgetResponse{
+		Message: messageSuccess,
+		Budget:  dto,
+	})
+}
+
+// ListBudgets godoc
+// @Summary List budgets of a user
+// @Tags budgets
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Success 200 {object} BudgetsResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets [get]
+func (h *BudgetsHandler) ListBudgets(c *gin.Context) {
+	h.logger.Debugw("handling ListBudgets()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	list, err := h.budgetsRepo.ListByUser(userID)
+	if err != nil {
+		h.logger.Errorw("Failed to list budgets", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*BudgetDTO, 0, len(list))
+	for _, budget := range list {
+		dtos = append(dtos, budgetDTO(budget))
+	}
+
+	c.JSON(http.StatusOK, BudgetsResponse{
+		Message: messageSuccess,
+		Budgets: dtos,
+	})
+}
+
+// UpdateBudget godoc
+// @Summary Update budget
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param request body budgetUpdateRequest true "Fields to change"
+// @Success 200 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets/{id} [patch]
+func (h *BudgetsHandler) UpdateBudget(c *gin.Context) {
+	h.logger.Debugw("handling UpdateBudget()")
+
+	var request budgetUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	id := c.Param("id")
+	budget, err := h.budgetsRepo.ReadByID(id)
+	if err != nil {
+		h.logger.Errorw("Failed to read budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	if request.CategoryID != nil {
+		budget.CategoryID = budgetCategory(*request.CategoryID)
+	}
+	if request.Amount != nil {
+		budget.Amount = *request.Amount
+	}
+
+	if err = h.validateBudget(budget, request.Thresholds); err != nil {
+		h.logger.Errorw("Invalid budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	if err = h.budgetsRepo.Update(id, budget); err != nil {
+		h.logger.Errorw("Failed to update budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully updated budget", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// DeleteBudget godoc
+// @Summary Delete budget
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} BasicResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets/{id} [delete]
+func (h *BudgetsHandler) DeleteBudget(c *gin.Context) {
+	h.logger.Debugw("handling DeleteBudget()")
+
+	id := c.Param("id")
+
+	if err := h.budgetsRepo.Delete(id); err != nil {
+		h.logger.Errorw("Failed to delete budget", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully deleted budget", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// ListBudgetAlerts godoc
+// @Summary List budget alerts of a user
+// @Description Latest 100 threshold crossings, newest first.
+// @Tags budgets
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Success 200 {object} BudgetAlertsResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/budgets/alerts [get]
+func (h *BudgetsHandler) ListBudgetAlerts(c *gin.Context) {
+	h.logger.Debugw("handling ListBudgetAlerts()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	alerts, err := h.budgetsRepo.ListAlerts(userID, budgetAlertsLimit)
+	if err != nil {
+		h.logger.Errorw("Failed to list budget alerts", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*BudgetAlertDTO, 0, len(alerts))
+	for _, alert := range alerts {
+		dtos = append(dtos, &BudgetAlertDTO{
+			ID:        alert.ID,
+			BudgetID:  alert.BudgetID,
+			Month:     alert.Month.Format(subs.TimeParseFormat),
+			Threshold: alert.Threshold,
+			Spend:     alert.Spend,
+			Amount:    alert.Amount,
+			CreatedAt: alert.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, BudgetAlertsResponse{
+		Message: messageSuccess,
+		Alerts:  dtos,
+	})
+}
+
+func (h *BudgetsHandler) validateBudget(budget *budgets.Budget, thresholds []int) error {
+	if budget.Amount <= 0 {
+		return newFieldError("amount", CodeOutOfRange, ErrBudgetAmount)
+	}
+
+	if err := checkCategory(h.categoriesRepo, "category_id", budget.CategoryID); err != nil {
+		return err
+	}
+
+	if thresholds != nil {
+		parsed, err := budgets.NewThresholds(thresholds)
+		if err != nil {
+			return newFieldError("thresholds", CodeInvalidParam, err, budgets.MaxThresholds, budgets.MaxThreshold)
+		}
+		budget.Thresholds = parsed
+	}
+
+	return nil
+}
+
+func requiredUserID(c *gin.Context) (uuid.UUID, error) {
+	userIDStr := c.Query("userID")
+	if userIDStr == "" {
+		return uuid.Nil, newFieldError("userID", CodeRequired, ErrRequiredParam)
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam)
+	}
+
+	return userID, nil
+}
+
+// budgetCategory - пустой category_id означает бюджет на все подписки
+func budgetCategory(categoryID string) *string {
+	categoryID = strings.TrimSpace(categoryID)
+	if categoryID == "" {
+		return nil
+	}
+
+	return &categoryID
+}
+
+func budgetDTO(budget *budgets.Budget) *BudgetDTO {
+	dto := &BudgetDTO{
+		ID:         budget.ID,
+		UserID:     budget.UserID.String(),
+		Amount:     budget.Amount,
+		Thresholds: budget.Thresholds,
+	}
+	if budget.CategoryID != nil {
+		dto.CategoryID = *budget.CategoryID
+	}
+
+	return dto
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"online-subs/pkg/calendar"
+	"online-subs/pkg/feeds"
+	"online-subs/pkg/subs"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const feedPathTemplate = "/subscriptions/v1/calendar/%s/feed.ics"
+
+type CalendarHandler struct {
+	feedsRepo feeds.FeedTokensRepo
+	subsRepo  subs.SubscriptionsRepo
+	logger    *zap.SugaredLogger
+}
+
+func NewCalendarHandler(feedsRepo feeds.FeedTokensRepo, subsRepo subs.SubscriptionsRepo, logger *zap.SugaredLogger) *CalendarHandler {
+	return &CalendarHandler{
+		feedsRepo: feedsRepo,
+		subsRepo:  subsRepo,
+		logger:    logger,
+	}
+}
+
+type feedTokenRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type FeedTokenResponse struct {
+	Message   string `json:"message"`
+	Token     string `json:"token"`
+	FeedURL   string `json:"feed_url"`
+	WebcalURL string `json:"webcal_url"`
+}
+
+// IssueFeedToken godoc
+// @Summary Issue calendar feed token
+// @Description Creates a secret token for the user's .ics feed. Issuing a new token invalidates the previous one.
+// @Tags calendar
+// @Accept json
+// @Produce json
+// @Param request body feedTokenRequest true "User"
+// @Success 201 {object} FeedTokenResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/calendar/token [post]
+func (h *CalendarHandler) IssueFeedToken(c *gin.Context) {
+	h.logger.Debugw("handling IssueFeedToken()")
+
+	var request feedTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	if request.UserID == uuid.Nil {
+		h.logger.Errorw("Missing user ID", "error", ErrRequiredParam)
+
+		respondProblem(c, newFieldError("user_id", CodeRequired, ErrRequiredParam))
+		return
+	}
+
+	token, err := h.feedsRepo.Issue(request.UserID)
+	if err != nil {
+		h.logger.Errorw("Failed to issue feed token", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	host := c.Request.Host
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	path := fmt.Sprintf(feedPathTemplate, token)
+
+	h.logger.Infow("Successfully issued feed token", "userID", request.UserID)
+	c.JSON(http.StatusCreated, FeedTokenResponse{
+		Message:   messageSuccess,
+		Token:     token,
+		FeedURL:   scheme + "://" + host + path,
+		WebcalURL: "webcal://" + host + path,
+	})
+}
+
+// RevokeFeedToken godoc
+// @Summary Revoke calendar feed token
+// @Tags calendar
+// @Produce json
+// @Param userID path string true "User UUID"
+// @Success 200 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/calendar/token/{userID} [delete]
+func (h *CalendarHandler) RevokeFeedToken(c *gin.Context) {
+	h.logger.Debugw("handling RevokeFeedToken()")
+
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		h.logger.Errorw("Failed to parse user ID", "error", err)
+
+		respondProblem(c, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam))
+		return
+	}
+
+	if err = h.feedsRepo.Revoke(userID); err != nil {
+		h.logger.Errorw("Failed to revoke feed token", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully revoked feed token", "userID", userID)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      userID.String(),
+	})
+}
+
+// Feed godoc
+// @Summary Calendar feed of upcoming renewals
+// @Description iCalendar feed with a monthly recurring all-day event per active subscription, authenticated by the feed token.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/calendar/{token}/feed.ics [get]
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	h.logger.Debugw("handling Feed()")
+
+	userID, err := h.feedsRepo.ResolveUserID(c.Param("token"))
+	if err != nil {
+		h.logger.Errorw("Failed to resolve feed token", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// ETag считается по данным подписок, а не по телу с DTSTAMP, так что меняется только вместе с ними
+	var active []*subs.Subscription
+	etagHash := sha256.New()
+	err = h.subsRepo.Stream(&subs.SubscriptionFilter{UserID: &userID}, func(subscription *subs.Subscription) error {
+		if subscription.EndDate != nil && subscription.EndDate.Before(currentMonth) {
+			return nil
+		}
+
+		active = append(active, subscription)
+		_, err := fmt.Fprintf(etagHash, "%s|%s|%d|%s|%v|%d|%v|%v\n", subscription.ID, subscription.Service, subscription.Cost,
+			subscription.StartDate, subscription.EndDate, subscription.BillingDay, subscription.TrialStartDate, subscription.TrialEndDate)
+		for _, pause := range subscription.Pauses {
+			if err == nil {
+				_, err = fmt.Fprintf(etagHash, "pause|%s|%v\n", pause.StartDate, pause.EndDate)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to read subscriptions for feed", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	etag := `"` + hex.EncodeToString(etagHash.Sum(nil)) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	var body bytes.Buffer
+	if err = calendar.WriteFeed(&body, "Subscriptions", active, now); err != nil {
+		h.logger.Errorw("Failed to render calendar feed", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully rendered calendar feed", "userID", userID, "events", len(active))
+	c.Data(http.StatusOK, calendar.ContentType, body.Bytes())
+}
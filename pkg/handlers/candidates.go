@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/statements"
+	"online-subs/pkg/subs"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CandidatesHandler struct {
+	detector       *statements.Detector
+	reconciler     *statements.Reconciler
+	candidatesRepo statements.CandidatesRepo
+	// overlapPolicy - политика пересечений при принятии, если запрос не передал свою в параметре overlapPolicy
+	overlapPolicy overlaps.Policy
+	logger        *zap.SugaredLogger
+}
+
+func NewCandidatesHandler(detector *statements.Detector, reconciler *statements.Reconciler,
+	candidatesRepo statements.CandidatesRepo, overlapPolicy overlaps.Policy, logger *zap.SugaredLogger) *CandidatesHandler {
+	return &CandidatesHandler{
+		detector:       detector,
+		reconciler:     reconciler,
+		candidatesRepo: candidatesRepo,
+		overlapPolicy:  overlapPolicy,
+		logger:         logger,
+	}
+}
+
+// acceptRequest - правки кандидата перед созданием подписки, отсутствующие поля берутся из кандидата
+type acceptRequest struct {
+	ServiceName *string `json:"service_name"`
+	Cost        *int32  `json:"price"`
+	StartDate   *string `json:"start_date" example:"2026-06-15"`
+	BillingDay  *int    `json:"billing_day" example:"15"`
+}
+
+// CandidateDTO - поля для POST /create: service_name, price, start_date и billing_day
+type CandidateDTO struct {
+	ID          string  `json:"id"`
+	Service     string  `json:"service_name"`
+	Price       int64   `json:"price"`
+	Currency    string  `json:"currency" example:"RUB"`
+	StartDate   string  `json:"start_date" example:"2026-06-15"`
+	BillingDay  int     `json:"billing_day"`
+	LastDate    string  `json:"last_date" example:"2026-10-15"`
+	Occurrences int     `json:"occurrences"`
+	Confidence  float64 `json:"confidence" example:"0.85"`
+	Status      string  `json:"status" example:"proposed"`
+	// SubscriptionID - подписка, созданная при принятии
+	SubscriptionID *string `json:"subscription_id,omitempty"`
+}
+
+type CandidatesListResponse struct {
+	Message    string          `json:"message"`
+	Candidates []*CandidateDTO `json:"candidates"`
+}
+
+type AcceptCandidateResponse struct {
+	Message   string        `json:"message"`
+	ID        string        `json:"id"`
+	Candidate *CandidateDTO `json:"candidate"`
+	// Matched - прошлые операции мерчанта, привязанные к списаниям новой подписки
+	Matched int `json:"matched"`
+	// Overlaps - пересекающиеся подписки пользователя на тот же сервис при overlapPolicy=warn
+	Overlaps []*SubscriptionDTO `json:"overlaps,omitempty"`
+}
+
+// DetectCandidates godoc
+// @Summary Detect recurring payments
+// @Description Groups unmatched transactions of the last 13 months by merchant and currency and proposes monthly ones as subscription candidates.
+// @Description Confidence from 0 to 1 grows with regular intervals, a stable amount, the number of payments and a recent last payment.
+// @Description Merchants resembling existing subscriptions are skipped, accepted and dismissed candidates are not proposed again.
+// @Tags candidates
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Param minConfidence query number false "Lowest confidence of returned candidates, 0.5 by default"
+// @Param tolerance query int false "Amount deviation in percent treated as the same price, 10 by default, at most 50"
+// @Success 200 {object} CandidatesListResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/candidates/detect [post]
+func (h *CandidatesHandler) DetectCandidates(c *gin.Context) {
+	h.logger.Debugw("handling DetectCandidates()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	minConfidence, err := h.minConfidence(c, statements.DefaultMinConfidence)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	tolerance := statements.DefaultTolerance
+	if toleranceStr := c.Query("tolerance"); toleranceStr != "" {
+		tolerance, err = strconv.Atoi(toleranceStr)
+		if err != nil || tolerance < 0 || tolerance > statements.MaxTolerance {
+			h.logger.Errorw("Invalid tolerance", "value", toleranceStr)
+
+			respondProblem(c, newFieldError("tolerance", CodeOutOfRange, ErrInvalidParam))
+			return
+		}
+	}
+
+	candidates, err := h.detector.Detect(userID, tolerance, minConfidence)
+	if err != nil {
+		h.logger.Errorw("Failed to detect recurring payments", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully detected recurring payments", "userID", userID, "candidates", len(candidates))
+	c.JSON(http.StatusOK, CandidatesListResponse{
+		Message:    messageSuccess,
+		Candidates: candidateDTOs(candidates),
+	})
+}
+
+// ListCandidates godoc
+// @Summary List subscription candidates
+// @Description Candidates found by the last detection ordered by confidence.
+// @Tags candidates
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Param status query []string false "Statuses: proposed, accepted, dismissed" collectionFormat(csv)
+// @Param minConfidence query number false "Lowest confidence"
+// @Success 200 {object} CandidatesListResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/candidates [get]
+func (h *CandidatesHandler) ListCandidates(c *gin.Context) {
+	h.logger.Debugw("handling ListCandidates()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	filter := &statements.CandidateFilter{UserID: userID}
+	for _, value := range queryList(c, "status") {
+		status, err := statements.ParseCandidateStatus(value)
+		if err != nil {
+			h.logger.Errorw("Unknown candidate status in filter", "status", value)
+
+			respondProblem(c, newFieldError("status", CodeInvalidParam, statements.ErrUnknownCandidateState, value))
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	if filter.MinConfidence, err = h.minConfidence(c, 0); err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	candidates, err := h.candidatesRepo.List(filter)
+	if err != nil {
+		h.logger.Errorw("Failed to list candidates", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CandidatesListResponse{
+		Message:    messageSuccess,
+		Candidates: candidateDTOs(candidates),
+	})
+}
+
+// AcceptCandidate godoc
+// @Summary Accept subscription candidate
+// @Description Creates a subscription from a proposed candidate, body fields override the detected ones.
+// @Description Past transactions of the merchant are then reconciled against charges of the new subscription.
+// @Description overlapPolicy works as in /create.
+// @Tags candidates
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Param request body acceptRequest false "Overrides"
+// @Param overlapPolicy query string false "allow, warn or reject"
+// @Success 201 {object} AcceptCandidateResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/candidates/{id}/accept [post]
+func (h *CandidatesHandler) AcceptCandidate(c *gin.Context) {
+	h.logger.Debugw("handling AcceptCandidate()")
+
+	policy, err := overlapPolicyParam(c, h.logger, h.overlapPolicy)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	var request acceptRequest
+	if err = bindOptionalJSON(c, &request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	override, err := h.buildOverride(&request)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	candidate, subscriptionID, overlapping, err := h.detector.Accept(id, override, policy)
+	if err != nil {
+		h.logger.Errorw("Failed to accept candidate", "id", id, "error", err)
+
+		respondProblem(c, candidateError(err))
+		return
+	}
+
+	// Подписка уже создана, неудачная сверка истории не отменяет принятие
+	matched := 0
+	reconciliation, err := h.reconciler.Reconcile(candidate.UserID, candidate.StartDate, subs.Today(),
+		statements.DefaultWindow, statements.DefaultTolerance)
+	if err != nil {
+		h.logger.Warnw("Failed to reconcile accepted candidate", "id", id, "error", err)
+	} else {
+		matched = len(reconciliation.Matched)
+	}
+
+	view := &viewOptions{now: subs.Today()}
+
+	h.logger.Infow("Successfully accepted candidate", "id", id, "subscriptionID", subscriptionID, "matched", matched)
+	c.JSON(http.StatusCreated, AcceptCandidateResponse{
+		Message:   messageSuccess,
+		ID:        subscriptionID,
+		Candidate: candidateDTO(candidate),
+		Matched:   matched,
+		Overlaps:  view.renderAll(overlapping),
+	})
+}
+
+// DismissCandidate godoc
+// @Summary Dismiss subscription candidate
+// @Description A dismissed candidate is not proposed again by later detections.
+// @Tags candidates
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Success 200 {object} BasicResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/candidates/{id}/dismiss [post]
+func (h *CandidatesHandler) DismissCandidate(c *gin.Context) {
+	h.logger.Debugw("handling DismissCandidate()")
+
+	id := c.Param("id")
+	if err := h.detector.Dismiss(id); err != nil {
+		h.logger.Errorw("Failed to dismiss candidate", "id", id, "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully dismissed candidate", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+func (h *CandidatesHandler) minConfidence(c *gin.Context, fallback float64) (float64, error) {
+	valueStr := c.Query("minConfidence")
+	if valueStr == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil || value < 0 || value > 1 {
+		h.logger.Errorw("Invalid minConfidence", "value", valueStr)
+
+		return 0, newFieldError("minConfidence", CodeOutOfRange, statements.ErrConfidenceRange)
+	}
+
+	return value, nil
+}
+
+func (h *CandidatesHandler) buildOverride(request *acceptRequest) (*statements.Override, error) {
+	override := &statements.Override{
+		Service:    request.ServiceName,
+		Price:      request.Cost,
+		BillingDay: request.BillingDay,
+	}
+
+	if request.ServiceName != nil && *request.ServiceName == "" {
+		return nil, newFieldError("service_name", CodeRequired, ErrRequiredParam)
+	}
+	if request.ServiceName != nil && len([]rune(*request.ServiceName)) > subs.MaxServiceLength {
+		h.logger.Errorw(ErrNameTooLong.Error(), "service", *request.ServiceName)
+
+		return nil, newFieldError("service_name", CodeOutOfRange, ErrNameTooLong, subs.MaxServiceLength)
+	}
+
+	if request.Cost != nil && *request.Cost < 0 {
+		h.logger.Errorw("Negative cost", "cost", *request.Cost)
+
+		return nil, newFieldError("price", CodeOutOfRange, ErrNegativeCost)
+	}
+
+	if request.BillingDay != nil && (*request.BillingDay < 1 || *request.BillingDay > 31) {
+		h.logger.Errorw(ErrBillingDay.Error(), "billingDay", *request.BillingDay)
+
+		return nil, newFieldError("billing_day", CodeOutOfRange, ErrBillingDay)
+	}
+
+	if request.StartDate != nil {
+		startDate, err := subs.ParseDate(*request.StartDate)
+		if err != nil {
+			h.logger.Errorw(ErrDateFormat.Error(), "error", err)
+
+			return nil, newFieldError("start_date", CodeInvalidDateFormat, ErrDateFormat)
+		}
+		override.StartDate = &startDate
+	}
+
+	return override, nil
+}
+
+// candidateError - сумма кандидата, не влезающая в цену подписки, исправляется полем price
+func candidateError(err error) error {
+	if errors.Is(err, statements.ErrCandidatePrice) {
+		return newFieldError("price", CodeOutOfRange, statements.ErrCandidatePrice)
+	}
+
+	return err
+}
+
+func candidateDTOs(candidates []*statements.Candidate) []*CandidateDTO {
+	dtos := make([]*CandidateDTO, 0, len(candidates))
+	for _, candidate := range candidates {
+		dtos = append(dtos, candidateDTO(candidate))
+	}
+
+	return dtos
+}
+
+func candidateDTO(candidate *statements.Candidate) *CandidateDTO {
+	return &CandidateDTO{
+		ID:             candidate.ID,
+		Service:        candidate.Service,
+		Price:          candidate.Price,
+		Currency:       candidate.Currency,
+		StartDate:      candidate.StartDate.Format(subs.DateFormat),
+		BillingDay:     candidate.BillingDay,
+		LastDate:       candidate.LastDate.Format(subs.DateFormat),
+		Occurrences:    candidate.Occurrences,
+		Confidence:     candidate.Confidence,
+		Status:         string(candidate.Status),
+		SubscriptionID: candidate.SubscriptionID,
+	}
+}
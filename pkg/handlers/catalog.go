@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/categories"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const maxURLLength = 2048
+
+var (
+	ErrNameTooLong = errors.New("name is too long")
+	ErrInvalidURL  = errors.New("must be an absolute http or https URL")
+)
+
+type CatalogHandler struct {
+	servicesRepo   catalog.ServicesRepo
+	categoriesRepo categories.CategoriesRepo
+	index          *catalog.Index
+	logger         *zap.SugaredLogger
+}
+
+func NewCatalogHandler(servicesRepo catalog.ServicesRepo, categoriesRepo categories.CategoriesRepo, index *catalog.Index,
+	logger *zap.SugaredLogger) *CatalogHandler {
+	return &CatalogHandler{
+		servicesRepo:   servicesRepo,
+		categoriesRepo: categoriesRepo,
+		index:          index,
+		logger:         logger,
+	}
+}
+
+type catalogServiceRequest struct {
+	Name         string   `json:"name" example:"Netflix"`
+	Aliases      []string `json:"aliases" example:"netflix.com,Нетфликс"`
+	CategoryID   *string  `json:"category_id"`
+	DefaultPrice *int32   `json:"default_price" example:"799"`
+	Website      string   `json:"website" example:"https://www.netflix.com"`
+	LogoURL      string   `json:"logo_url" example:"https://www.netflix.com/favicon.ico"`
+}
+
+type CatalogServiceDTO struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name" example:"Netflix"`
+	Aliases      []string `json:"aliases"`
+	CategoryID   *string  `json:"category_id,omitempty"`
+	DefaultPrice *int32   `json:"default_price,omitempty" example:"799"`
+	Website      string   `json:"website,omitempty"`
+	LogoURL      string   `json:"logo_url,omitempty"`
+}
+
+type CatalogServiceResponse struct {
+	Message string             `json:"message"`
+	Service *CatalogServiceDTO `json:"service"`
+}
+
+type CatalogServicesResponse struct {
+	Message  string               `json:"message"`
+	Services []*CatalogServiceDTO `json:"services"`
+}
+
+// CreateCatalogService godoc
+// @Summary Create catalog service
+// @Description Names and aliases are matched case-insensitively with collapsed spaces. Existing subscriptions with a matching name are linked to the service and renamed to the canonical name.
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param request body catalogServiceRequest true "Catalog service"
+// @Success 201 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/catalog/services [post]
+func (h *CatalogHandler) CreateCatalogService(c *gin.Context) {
+	h.logger.Debugw("handling CreateCatalogService()")
+
+	service, err := h.buildServiceFromContext(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	id, err := h.servicesRepo.Create(service)
+	if err != nil {
+		h.logger.Errorw("Failed to create catalog service", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully created catalog service", "id", id)
+	c.JSON(http.StatusCreated, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// GetCatalogService godoc
+// @Summary Get catalog service
+// @Tags catalog
+// @Produce json
+// @Param id path string true "Catalog service ID"
+// @Success 200 {object} CatalogServiceResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/catalog/services/{id} [get]
+func (h *CatalogHandler) GetCatalogService(c *gin.Context) {
+	h.logger.Debugw("handling GetCatalogService()")
+
+	service, err := h.servicesRepo.ReadByID(c.Param("id"))
+	if err != nil {
+		h.logger.Errorw("Failed to read catalog service", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CatalogServiceResponse{
+		Message: messageSuccess,
+		Service: catalogServiceDTO(service),
+	})
+}
+
+// ListCatalogServices godoc
+// @Summary List catalog services
+// @Tags catalog
+// @Produce json
+// @Success 200 {object} CatalogServicesResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/catalog/services [get]
+func (h *CatalogHandler) ListCatalogServices(c *gin.Context) {
+	h.logger.Debugw("handling ListCatalogServices()")
+
+	services, err := h.servicesRepo.List()
+	if err != nil {
+		h.logger.Errorw("Failed to list catalog services", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*CatalogServiceDTO, 0, len(services))
+	for _, service := range services {
+		dtos = append(dtos, catalogServiceDTO(service))
+	}
+
+	c.JSON(http.StatusOK, CatalogServicesResponse{
+		Message:  messageSuccess,
+		Services: dtos,
+	})
+}
+
+// UpdateCatalogService godoc
+// @Summary Replace catalog service
+// @Description Replaces all fields and aliases. Subscriptions are relinked: the ones matching the new names are renamed, the ones matching only removed aliases lose service_id.
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path string true "Catalog service ID"
+// @Param request body catalogServiceRequest true "Catalog service"
+// @Success 200 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/catalog/services/{id} [put]
+func (h *CatalogHandler) UpdateCatalogService(c *gin.Context) {
+	h.logger.Debugw("handling UpdateCatalogService()")
+
+	service, err := h.buildServiceFromContext(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if err = h.servicesRepo.Update(id, service); err != nil {
+		h.logger.Errorw("Failed to update catalog service", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully updated catalog service", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// DeleteCatalogService godoc
+// @Summary Delete catalog service
+// @Description Subscriptions keep their names and lose service_id.
+// @Tags catalog
+// @Produce json
+// @Param id path string true "Catalog service ID"
+// @Success 200 {object} BasicResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/catalog/services/{id} [delete]
+func (h *CatalogHandler) DeleteCatalogService(c *gin.Context) {
+	h.logger.Debugw("handling DeleteCatalogService()")
+
+	id := c.Param("id")
+
+	if err := h.servicesRepo.Delete(id); err != nil {
+		h.logger.Errorw("Failed to delete catalog service", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully deleted catalog service", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+func (h *CatalogHandler) buildServiceFromContext(c *gin.Context) (*catalog.Service, error) {
+	var request catalogServiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		return nil, bindingError(err)
+	}
+
+	service, err := buildCatalogService(&request)
+	if err != nil {
+		h.logger.Errorw("Invalid catalog service", "error", err)
+
+		return nil, err
+	}
+
+	if err = checkCategory(h.categoriesRepo, "category_id", service.CategoryID); err != nil {
+		h.logger.Errorw("Invalid catalog service category", "error", err)
+
+		return nil, err
+	}
+
+	return service, nil
+}
+
+func buildCatalogService(request *catalogServiceRequest) (*catalog.Service, error) {
+	name := catalog.CleanName(request.Name)
+	if name == "" {
+		return nil, newFieldError("name", CodeRequired, ErrRequiredParam)
+	}
+	if len([]rune(name)) > catalog.MaxNameLength {
+		return nil, newFieldError("name", CodeOutOfRange, ErrNameTooLong, catalog.MaxNameLength)
+	}
+
+	if len(request.Aliases) > catalog.MaxAliases {
+		return nil, newFieldError("aliases", CodeOutOfRange, ErrTooManyValues, catalog.MaxAliases)
+	}
+	for _, alias := range request.Aliases {
+		if len([]rune(catalog.CleanName(alias))) > catalog.MaxNameLength {
+			return nil, newFieldError("aliases", CodeOutOfRange, ErrNameTooLong, catalog.MaxNameLength)
+		}
+	}
+
+	if request.DefaultPrice != nil && *request.DefaultPrice < 0 {
+		return nil, newFieldError("default_price", CodeOutOfRange, ErrNegativeCost)
+	}
+
+	if err := validateURL("website", request.Website); err != nil {
+		return nil, err
+	}
+	if err := validateURL("logo_url", request.LogoURL); err != nil {
+		return nil, err
+	}
+
+	service := &catalog.Service{
+		Name:         name,
+		CategoryID:   request.CategoryID,
+		DefaultPrice: request.DefaultPrice,
+		Website:      request.Website,
+		LogoURL:      request.LogoURL,
+	}
+	service.SetAliases(request.Aliases)
+
+	return service, nil
+}
+
+// validateURL - пустое значение допустимо, иначе нужен абсолютный http(s) адрес
+func validateURL(field, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := url.ParseRequestURI(value)
+	if err != nil || len(value) > maxURLLength || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return newFieldError(field, CodeInvalidParam, ErrInvalidURL)
+	}
+
+	return nil
+}
+
+func catalogServiceDTO(service *catalog.Service) *CatalogServiceDTO {
+	return &CatalogServiceDTO{
+		ID:           service.ID,
+		Name:         service.Name,
+		Aliases:      service.AliasNames(),
+		CategoryID:   service.CategoryID,
+		DefaultPrice: service.DefaultPrice,
+		Website:      service.Website,
+		LogoURL:      service.LogoURL,
+	}
+}
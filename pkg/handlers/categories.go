@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/categories"
+	"online-subs/pkg/subs"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CategoriesHandler struct {
+	categoriesRepo categories.CategoriesRepo
+	index          *categories.Index
+	logger         *zap.SugaredLogger
+}
+
+func NewCategoriesHandler(categoriesRepo categories.CategoriesRepo, index *categories.Index, logger *zap.SugaredLogger) *CategoriesHandler {
+	return &CategoriesHandler{
+		categoriesRepo: categoriesRepo,
+		index:          index,
+		logger:         logger,
+	}
+}
+
+type categoryRequest struct {
+	Name     string  `json:"name" example:"Streaming"`
+	ParentID *string `json:"parent_id"`
+}
+
+type CategoryDTO struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name" example:"Streaming"`
+	ParentID *string  `json:"parent_id"`
+	Path     []string `json:"path" example:"Entertainment,Streaming"`
+}
+
+type CategoryResponse struct {
+	Message  string       `json:"message"`
+	Category *CategoryDTO `json:"category"`
+}
+
+type CategoriesResponse struct {
+	Message    string         `json:"message"`
+	Categories []*CategoryDTO `json:"categories"`
+}
+
+// CreateCategory godoc
+// @Summary Create category
+// @Description Categories form a tree up to 5 levels deep. Names are unique among siblings.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body categoryRequest true "Category"
+// @Success 201 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/categories [post]
+func (h *CategoriesHandler) CreateCategory(c *gin.Context) {
+	h.logger.Debugw("handling CreateCategory()")
+
+	category, err := h.buildCategoryFromContext(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	id, err := h.categoriesRepo.Create(category)
+	if err != nil {
+		h.logger.Errorw("Failed to create category", "error", err)
+
+		respondProblem(c, categoryPlacementError(err))
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully created category", "id", id)
+	c.JSON(http.StatusCreated, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// GetCategory godoc
+// @Summary Get category
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} CategoryResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/categories/{id} [get]
+func (h *CategoriesHandler) GetCategory(c *gin.Context) {
+	h.logger.Debugw("handling GetCategory()")
+
+	list, err := h.categoriesRepo.List()
+	if err != nil {
+		h.logger.Errorw("Failed to list categories", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	tree := categories.NewTree(list)
+	category, ok := tree.Get(c.Param("id"))
+	if !ok {
+		h.logger.Errorw("Category not found", "id", c.Param("id"))
+
+		respondProblem(c, categories.ErrNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, CategoryResponse{
+		Message:  messageSuccess,
+		Category: categoryDTO(tree, category),
+	})
+}
+
+// ListCategories godoc
+// @Summary List categories
+// @Description Flat list of the whole tree, every category carries its path from the root.
+// @Tags categories
+// @Produce json
+// @Success 200 {object} CategoriesResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/categories [get]
+func (h *CategoriesHandler) ListCategories(c *gin.Context) {
+	h.logger.Debugw("handling ListCategories()")
+
+	list, err := h.categoriesRepo.List()
+	if err != nil {
+		h.logger.Errorw("Failed to list categories", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	tree := categories.NewTree(list)
+	dtos := make([]*CategoryDTO, 0, len(list))
+	for _, category := range list {
+		dtos = append(dtos, categoryDTO(tree, category))
+	}
+
+	c.JSON(http.StatusOK, CategoriesResponse{
+		Message:    messageSuccess,
+		Categories: dtos,
+	})
+}
+
+// UpdateCategory godoc
+// @Summary Rename or move category
+// @Description Both fields are replaced, parent_id null moves the category to the top level together with its subcategories.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param request body categoryRequest true "Category"
+// @Success 200 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/categories/{id} [put]
+func (h *CategoriesHandler) UpdateCategory(c *gin.Context) {
+	h.logger.Debugw("handling UpdateCategory()")
+
+	category, err := h.buildCategoryFromContext(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if err = h.categoriesRepo.Update(id, category); err != nil {
+		h.logger.Errorw("Failed to update category", "error", err)
+
+		respondProblem(c, categoryPlacementError(err))
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully updated category", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Only categories without subcategories can be deleted. Subscriptions and catalog services lose the category.
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} BasicResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/categories/{id} [delete]
+func (h *CategoriesHandler) DeleteCategory(c *gin.Context) {
+	h.logger.Debugw("handling DeleteCategory()")
+
+	id := c.Param("id")
+
+	if err := h.categoriesRepo.Delete(id); err != nil {
+		h.logger.Errorw("Failed to delete category", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	h.index.Invalidate()
+
+	h.logger.Infow("Successfully deleted category", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+func (h *CategoriesHandler) buildCategoryFromContext(c *gin.Context) (*categories.Category, error) {
+	var request categoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		return nil, bindingError(err)
+	}
+
+	name := strings.Join(strings.Fields(request.Name), " ")
+	if name == "" {
+		h.logger.Errorw("Missing category name", "error", ErrRequiredParam)
+
+		return nil, newFieldError("name", CodeRequired, ErrRequiredParam)
+	}
+	if len([]rune(name)) > categories.MaxNameLength {
+		h.logger.Errorw("Category name is too long", "length", len(name))
+
+		return nil, newFieldError("name", CodeOutOfRange, ErrNameTooLong, categories.MaxNameLength)
+	}
+
+	return &categories.Category{
+		Name:     name,
+		ParentID: request.ParentID,
+	}, nil
+}
+
+// categoryPlacementError относит ошибки размещения в дереве к полю parent_id
+func categoryPlacementError(err error) error {
+	switch {
+	case errors.Is(err, categories.ErrParentNotFound):
+		return newFieldError("parent_id", CodeInvalidParam, categories.ErrParentNotFound)
+	case errors.Is(err, categories.ErrCycle):
+		return newFieldError("parent_id", CodeInvalidParam, categories.ErrCycle)
+	case errors.Is(err, categories.ErrTooDeep):
+		return newFieldError("parent_id", CodeOutOfRange, categories.ErrTooDeep, categories.MaxDepth)
+	default:
+		return err
+	}
+}
+
+// checkCategory проверяет, что категория существует. nil означает "без категории"
+func checkCategory(categoriesRepo categories.CategoriesRepo, field string, categoryID *string) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	if _, err := categoriesRepo.ReadByID(*categoryID); err != nil {
+		if errors.Is(err, categories.ErrNotFound) {
+			return newFieldError(field, CodeInvalidParam, subs.ErrUnknownCategory)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func categoryDTO(tree *categories.Tree, category *categories.Category) *CategoryDTO {
+	return &CategoryDTO{
+		ID:       category.ID,
+		Name:     category.Name,
+		ParentID: category.ParentID,
+		Path:     tree.Path(category.ID),
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"net/http"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ChargesHandler struct {
+	chargesRepo charges.ChargesRepo
+	generator   *charges.Generator
+	logger      *zap.SugaredLogger
+}
+
+func NewChargesHandler(chargesRepo charges.ChargesRepo, generator *charges.Generator, logger *zap.SugaredLogger) *ChargesHandler {
+	return &ChargesHandler{
+		chargesRepo: chargesRepo,
+		generator:   generator,
+		logger:      logger,
+	}
+}
+
+type settleRequest struct {
+	Status string `json:"status" example:"paid"`
+	// Amount - фактическая сумма, для paid и refunded по умолчанию ожидаемая
+	Amount *int64 `json:"amount"`
+}
+
+type ChargeDTO struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	UserID         string     `json:"user_id"`
+	Service        string     `json:"service"`
+	Date           string     `json:"date" example:"2026-10-15"`
+	Amount         int64      `json:"amount"`
+	Currency       string     `json:"currency" example:"RUB"`
+	Status         string     `json:"status" example:"expected"`
+	ActualAmount   *int64     `json:"actual_amount,omitempty"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+}
+
+type ChargeResponse struct {
+	Message string     `json:"message"`
+	Charge  *ChargeDTO `json:"charge"`
+}
+
+type ChargesListResponse struct {
+	Message string       `json:"message"`
+	Charges []*ChargeDTO `json:"charges"`
+	Meta    *Metadata    `json:"meta"`
+}
+
+type GenerateChargesResponse struct {
+	Message       string `json:"message"`
+	Until         string `json:"until" example:"2027-01-31"`
+	Subscriptions int    `json:"subscriptions"`
+	Charges       int64  `json:"charges"`
+}
+
+// GenerateCharges godoc
+// @Summary Generate expected charges
+// @Description Rebuilds expected charges of the subscriptions up to the end of the horizon-th month from the current one.
+// @Description Charges already marked paid, failed or refunded are kept, expected ones are replaced.
+// @Tags charges
+// @Produce json
+// @Param userID query string false "User UUID, all users when omitted"
+// @Param horizon query int false "Months ahead, 3 by default, at most 24"
+// @Success 200 {object} GenerateChargesResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/charges/generate [post]
+func (h *ChargesHandler) GenerateCharges(c *gin.Context) {
+	h.logger.Debugw("handling GenerateCharges()")
+
+	filter := &subs.SubscriptionFilter{}
+	if userIDStr := c.Query("userID"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.logger.Errorw("Failed to parse user ID", "error", err)
+
+			respondProblem(c, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	horizon := charges.DefaultHorizon
+	if horizonStr := c.Query("horizon"); horizonStr != "" {
+		var err error
+		horizon, err = strconv.Atoi(horizonStr)
+		if err != nil || horizon < 0 || horizon > charges.MaxHorizon {
+			h.logger.Errorw("Invalid horizon", "value", horizonStr)
+
+			respondProblem(c, newFieldError("horizon", CodeOutOfRange, ErrInvalidParam))
+			return
+		}
+	}
+
+	until := charges.HorizonEnd(subs.Today(), horizon)
+	result, err := h.generator.Generate(filter, until)
+	if err != nil {
+		h.logger.Errorw("Failed to generate charges", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully generated charges", "subscriptions", result.Subscriptions, "charges", result.Charges)
+	c.JSON(http.StatusOK, GenerateChargesResponse{
+		Message:       messageSuccess,
+		Until:         until.Format(subs.DateFormat),
+		Subscriptions: result.Subscriptions,
+		Charges:       result.Charges,
+	})
+}
+
+// ListCharges godoc
+// @Summary List charges
+// @Description Charges ledger ordered by date. dateFrom and dateTo are inclusive.
+// @Tags charges
+// @Produce json
+// @Param userID query string false "User UUID"
+// @Param subscriptionID query string false "Subscription ID"
+// @Param status query []string false "Statuses: expected, paid, failed, refunded" collectionFormat(csv)
+// @Param dateFrom query string false "From date YYYY-MM-DD or MM-YYYY"
+// @Param dateTo query string false "To date YYYY-MM-DD or MM-YYYY"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} ChargesListResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/charges [get]
+func (h *ChargesHandler) ListCharges(c *gin.Context) {
+	h.logger.Debugw("handling ListCharges()")
+
+	filter := &charges.ChargeFilter{}
+	if userIDStr := c.Query("userID"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.logger.Errorw("Failed to parse user ID", "error", err)
+
+			respondProblem(c, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if subscriptionID := c.Query("subscriptionID"); subscriptionID != "" {
+		filter.SubscriptionID = &subscriptionID
+	}
+
+	for _, value := range queryList(c, "status") {
+		status, err := charges.ParseStatus(value)
+		if err != nil {
+			h.logger.Errorw("Unknown charge status in filter", "status", value)
+
+			respondProblem(c, newFieldError("status", CodeInvalidParam, charges.ErrUnknownStatus, value))
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	var err error
+	if filter.DateFrom, err = parseDateParam(c, h.logger, "dateFrom", subs.ParseDate); err != nil {
+		respondProblem(c, err)
+		return
+	}
+	if filter.DateTo, err = parseDateParam(c, h.logger, "dateTo", subs.ParseEndDate); err != nil {
+		respondProblem(c, err)
+		return
+	}
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateTo.Before(*filter.DateFrom) {
+		h.logger.Errorw(ErrEndBeforeStart.Error(), "dateFrom", filter.DateFrom, "dateTo", filter.DateTo)
+
+		respondProblem(c, newFieldError("dateTo", CodeInvalidPeriod, ErrEndBeforeStart))
+		return
+	}
+
+	page, limit := utils.GetPageAndLimitFromContext(c)
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	list, total, err := h.chargesRepo.List(filter)
+	if err != nil {
+		h.logger.Errorw("Failed to list charges", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*ChargeDTO, 0, len(list))
+	for _, charge := range list {
+		dtos = append(dtos, chargeDTO(charge))
+	}
+
+	pages := utils.CountPages(total, int64(limit))
+	c.JSON(http.StatusOK, ChargesListResponse{
+		Message: messageSuccess,
+		Charges: dtos,
+		Meta: &Metadata{
+			Total: &total,
+			Page:  page,
+			Limit: limit,
+			Pages: &pages,
+		},
+	})
+}
+
+// SettleCharge godoc
+// @Summary Settle charge
+// @Description Marks a charge paid, failed or refunded. paid is allowed from expected and failed, failed from expected, refunded from paid.
+// @Description amount is the actual amount, for paid and refunded it defaults to the expected one.
+// @Tags charges
+// @Accept json
+// @Produce json
+// @Param id path string true "Charge ID"
+// @Param request body settleRequest true "Settlement"
+// @Success 200 {object} ChargeResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/charges/{id}/settle [post]
+func (h *ChargesHandler) SettleCharge(c *gin.Context) {
+	h.logger.Debugw("handling SettleCharge()")
+
+	var request settleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	status, err := charges.ParseStatus(request.Status)
+	if err != nil {
+		h.logger.Errorw("Unknown charge status", "status", request.Status)
+
+		respondProblem(c, newFieldError("status", CodeInvalidParam, charges.ErrUnknownStatus, request.Status))
+		return
+	}
+
+	if request.Amount != nil && *request.Amount < 0 {
+		h.logger.Errorw(charges.ErrNegativeAmount.Error(), "amount", *request.Amount)
+
+		respondProblem(c, newFieldError("amount", CodeOutOfRange, charges.ErrNegativeAmount))
+		return
+	}
+
+	id := c.Param("id")
+	charge, err := h.chargesRepo.Settle(id, status, request.Amount)
+	if err != nil {
+		h.logger.Errorw("Failed to settle charge", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully settled charge", "id", id, "status", status)
+	c.JSON(http.StatusOK, ChargeResponse{
+		Message: messageSuccess,
+		Charge:  chargeDTO(charge),
+	})
+}
+
+func chargeDTO(charge *charges.Charge) *ChargeDTO {
+	return &ChargeDTO{
+		ID:             charge.ID,
+		SubscriptionID: charge.SubscriptionID,
+		UserID:         charge.UserID.String(),
+		Service:        charge.Service,
+		Date:           charge.Date.Format(subs.DateFormat),
+		Amount:         charge.Amount,
+		Currency:       charge.Currency,
+		Status:         string(charge.Status),
+		ActualAmount:   charge.ActualAmount,
+		SettledAt:      charge.SettledAt,
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"online-subs/pkg/export"
+	"online-subs/pkg/subs"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const exportFlushEvery = 500
+
+// Export godoc
+// @Summary Export subscriptions
+// @Description Streams every subscription matching the filter as CSV, NDJSON or XLSX.
+// @Description Besides stored fields each row has months_active and total_paid computed up to today.
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Format (csv\|ndjson\|xlsx), csv by default"
+// @Param sort query string false "Sort expression like service,-cost,start_date over service, cost, start_date, end_date, user_id and id; -start_date by default"
+// @Param service query string false "Service name"
+// @Param userID query string false "User UUID"
+// @Param startDate query string false "Start date YYYY-MM-DD or MM-YYYY"
+// @Param endDate query string false "End date YYYY-MM-DD or MM-YYYY (last day of the month)"
+// @Param price query int false "Cost"
+// @Param priceMin query int false "Minimal cost, inclusive"
+// @Param priceMax query int false "Maximal cost, inclusive"
+// @Param services query []string false "Service names, comma separated or repeated" collectionFormat(csv)
+// @Param serviceIDs query []string false "Catalog service IDs, comma separated or repeated" collectionFormat(csv)
+// @Param categoryID query string false "Category ID, subcategories included"
+// @Param tags query []string false "Subscriptions with any of the tags, comma separated or repeated" collectionFormat(csv)
+// @Param status query []string false "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired" collectionFormat(csv)
+// @Param userIDs query []string false "User UUIDs, comma separated or repeated" collectionFormat(csv)
+// @Param search query string false "Case-insensitive substring of the service name"
+// @Param activeOnly query bool false "Only subscriptions active today"
+// @Param endedOnly query bool false "Only subscriptions ended before today"
+// @Param endDateFrom query string false "End date from YYYY-MM-DD or MM-YYYY, inclusive"
+// @Param endDateTo query string false "End date to YYYY-MM-DD or MM-YYYY, inclusive"
+// @Success 200 {file} file
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/export [get]
+func (h *SubsHandler) Export(c *gin.Context) {
+	h.logger.Debugw("handling Export()")
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		h.logger.Errorw("Invalid export format", "error", err)
+
+		respondProblem(c, newFieldError("format", CodeInvalidParam, err))
+		return
+	}
+
+	filter, err := constructFilterFromContextQuery(c, h.logger)
+	if err != nil {
+		h.logger.Errorw("Failed to construct filter from context query", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	fileName := "subscriptions-" + now.Format("20060102") + "." + format.Extension()
+
+	var writer export.Writer
+	var exported int
+
+	// Заголовки отправляются только с первой строкой, чтобы ошибку БД до неё ещё можно было отдать как problem+json
+	err = h.subsRepo.Stream(filter, func(subscription *subs.Subscription) error {
+		if writer == nil {
+			if writer, err = h.startExport(c, format, fileName); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.WriteRow(exportRow(subscription, now)); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+
+		return nil
+	})
+
+	if err == nil && writer == nil {
+		writer, err = h.startExport(c, format, fileName)
+	}
+
+	if err != nil {
+		h.logger.Errorw("Failed to export subscriptions", "error", err, "exported", exported)
+
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			respondProblem(c, err)
+			return
+		}
+
+		// Ответ уже частично отправлен, остаётся только оборвать соединение, чтобы клиент не получил обрезанный файл как целый
+		abortConnection(c)
+		return
+	}
+
+	if err = writer.Close(); err != nil {
+		h.logger.Errorw("Failed to finish export", "error", err)
+
+		abortConnection(c)
+		return
+	}
+
+	h.logger.Infow("Successfully exported subscriptions", "format", format, "count", exported)
+}
+
+func (h *SubsHandler) startExport(c *gin.Context, format export.Format, fileName string) (export.Writer, error) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Status(http.StatusOK)
+
+	return export.NewWriter(format, c.Writer)
+}
+
+func abortConnection(c *gin.Context) {
+	c.Abort()
+
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		return
+	}
+
+	_ = conn.Close()
+}
+
+func exportRow(subscription *subs.Subscription, now time.Time) *export.Row {
+	row := &export.Row{
+		ID:           subscription.ID,
+		Service:      subscription.Service,
+		Cost:         subscription.Cost,
+		UserID:       subscription.UserID.String(),
+		StartDate:    subscription.StartDate.Format(subs.DateFormat),
+		MonthsActive: subscription.MonthsActive(now),
+		TotalPaid:    subscription.TotalPaid(now),
+	}
+
+	if subscription.EndDate != nil {
+		endDate := subscription.EndDate.Format(subs.DateFormat)
+		row.EndDate = &endDate
+	}
+
+	return row
+}
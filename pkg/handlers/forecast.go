@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/forecast"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/subs"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultForecastMonths = 12
+
+var (
+	ErrEffectiveDateNotFuture  = errors.New("effective date must be after the current month")
+	ErrEffectiveDateOutOfRange = errors.New("effective date must be within the subscription period")
+	ErrChurnRate               = errors.New("churn rate must be in [0, 1)")
+)
+
+type ForecastHandler struct {
+	subsRepo    subs.SubscriptionsRepo
+	pricingRepo pricing.PriceChangesRepo
+	proration   subs.Proration
+	logger      *zap.SugaredLogger
+}
+
+func NewForecastHandler(subsRepo subs.SubscriptionsRepo, pricingRepo pricing.PriceChangesRepo, proration subs.Proration,
+	logger *zap.SugaredLogger) *ForecastHandler {
+	return &ForecastHandler{
+		subsRepo:    subsRepo,
+		pricingRepo: pricingRepo,
+		proration:   proration,
+		logger:      logger,
+	}
+}
+
+type priceChangeRequest struct {
+	SubscriptionID string `json:"subscription_id"`
+	Cost           *int32 `json:"price"`
+	EffectiveDate  string `json:"effective_date" example:"01-2027"`
+}
+
+type PriceChangeDTO struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	Price          int32  `json:"price"`
+	EffectiveDate  string `json:"effective_date" example:"01-2027"`
+}
+
+type PriceChangesResponse struct {
+	Message      string            `json:"message"`
+	PriceChanges []*PriceChangeDTO `json:"price_changes"`
+}
+
+type ForecastContribution struct {
+	SubscriptionID string `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	Price          int32  `json:"price"`
+	PriceChanged   bool   `json:"price_changed"`
+}
+
+type ForecastMonth struct {
+	Month         string                  `json:"month" example:"11-2026"`
+	Total         int64                   `json:"total"`
+	Expected      float64                 `json:"expected"`
+	Subscriptions []*ForecastContribution `json:"subscriptions"`
+}
+
+type ForecastResponse struct {
+	Message   string           `json:"message"`
+	ChurnRate float64          `json:"churn_rate"`
+	Total     int64            `json:"total"`
+	Expected  float64          `json:"expected"`
+	Months    []*ForecastMonth `json:"months"`
+}
+
+// SchedulePriceChange godoc
+// @Summary Schedule a price change
+// @Description The new price applies from effective_date onwards and is taken into account by the forecast.
+// @Tags forecast
+// @Accept json
+// @Produce json
+// @Param request body priceChangeRequest true "Price change"
+// @Success 201 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/price-changes [post]
+func (h *ForecastHandler) SchedulePriceChange(c *gin.Context) {
+	h.logger.Debugw("handling SchedulePriceChange()")
+
+	var request priceChangeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	change, err := h.buildPriceChange(&request)
+	if err != nil {
+		h.logger.Errorw("Invalid price change", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	id, err := h.pricingRepo.Schedule(change)
+	if err != nil {
+		h.logger.Errorw("Failed to schedule price change", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully scheduled price change", "id", id)
+	c.JSON(http.StatusCreated, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+func (h *ForecastHandler) buildPriceChange(request *priceChangeRequest) (*pricing.PriceChange, error) {
+	if request.SubscriptionID == "" || request.Cost == nil || request.EffectiveDate == "" {
+		validationErr := &ValidationError{}
+		for _, field := range []struct {
+			name    string
+			missing bool
+		}{
+			{"subscription_id", request.SubscriptionID == ""},
+			{"price", request.Cost == nil},
+			{"effective_date", request.EffectiveDate == ""},
+		} {
+			if field.missing {
+				validationErr.Fields = append(validationErr.Fields, FieldError{
+					Field:   field.name,
+					Code:    CodeRequired,
+					Message: ErrRequiredParam.Error(),
+				})
+			}
+		}
+		return nil, validationErr
+	}
+
+	if *request.Cost < 0 {
+		return nil, newFieldError("price", CodeOutOfRange, ErrNegativeCost)
+	}
+
+	// Цена меняется помесячно, день в дате отбрасывается
+	effectiveDate, err := subs.ParseDate(request.EffectiveDate)
+	if err != nil {
+		return nil, newFieldError("effective_date", CodeInvalidDateFormat, ErrDateFormat)
+	}
+	effectiveDate = subs.MonthStart(effectiveDate)
+
+	now := time.Now().UTC()
+	if !effectiveDate.After(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)) {
+		return nil, newFieldError("effective_date", CodeInvalidPeriod, ErrEffectiveDateNotFuture)
+	}
+
+	subscription, err := h.subsRepo.ReadByID(request.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Новая цена должна успеть примениться: в месяце изменения есть день списания в сроке подписки
+	if len(subscription.BillingDates(effectiveDate, subs.MonthEnd(effectiveDate))) == 0 {
+		return nil, newFieldError("effective_date", CodeInvalidPeriod, ErrEffectiveDateOutOfRange)
+	}
+
+	return &pricing.PriceChange{
+		SubscriptionID: subscription.ID,
+		EffectiveDate:  effectiveDate,
+		Cost:           *request.Cost,
+	}, nil
+}
+
+// ListPriceChanges godoc
+// @Summary List scheduled price changes of a subscription
+// @Tags forecast
+// @Produce json
+// @Param subscriptionID query string true "Subscription ID"
+// @Success 200 {object} PriceChangesResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/price-changes [get]
+func (h *ForecastHandler) ListPriceChanges(c *gin.Context) {
+	h.logger.Debugw("handling ListPriceChanges()")
+
+	subscriptionID := c.Query("subscriptionID")
+	if subscriptionID == "" {
+		h.logger.Errorw("Missing subscription ID", "error", ErrRequiredParam)
+
+		respondProblem(c, newFieldError("subscriptionID", CodeRequired, ErrRequiredParam))
+		return
+	}
+
+	changes, err := h.pricingRepo.ListBySubscription(subscriptionID)
+	if err != nil {
+		h.logger.Errorw("Failed to list price changes", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*PriceChangeDTO, 0, len(changes))
+	for _, change := range changes {
+		dtos = append(dtos, &PriceChangeDTO{
+			ID:             change.ID,
+			SubscriptionID: change.SubscriptionID,
+			Price:          change.Cost,
+			EffectiveDate:  change.EffectiveDate.Format(subs.TimeParseFormat),
+		})
+	}
+
+	c.JSON(http.StatusOK, PriceChangesResponse{
+		Message:      messageSuccess,
+		PriceChanges: dtos,
+	})
+}
+
+// DeletePriceChange godoc
+// @Summary Cancel a scheduled price change
+// @Tags forecast
+// @Produce json
+// @Param id path string true "Price change ID"
+// @Success 200 {object} BasicResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/price-changes/{id} [delete]
+func (h *ForecastHandler) DeletePriceChange(c *gin.Context) {
+	h.logger.Debugw("handling DeletePriceChange()")
+
+	id := c.Param("id")
+
+	if err := h.pricingRepo.Delete(id); err != nil {
+		h.logger.Errorw("Failed to delete price change", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully deleted price change", "id", id)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// Forecast godoc
+// @Summary Spending forecast
+// @Description Projects monthly spend starting from the current month. Subscriptions are charged until their end date,
+// @Description scheduled price changes are applied, partial months follow the proration policy. expected discounts each month by (1 - churnRate)^k.
+// @Tags forecast
+// @Produce json
+// @Param months query int false "Number of months, 12 by default, at most 36"
+// @Param churnRate query number false "Monthly probability of cancellation in [0, 1), 0 by default"
+// @Param proration query string false "Proration policy: none, daily, by_billing_day; the service default if omitted"
+// @Param service query string false "Service name"
+// @Param userID query string false "User UUID"
+// @Param priceMin query int false "Minimal cost, inclusive"
+// @Param priceMax query int false "Maximal cost, inclusive"
+// @Param services query []string false "Service names, comma separated or repeated" collectionFormat(csv)
+// @Param serviceIDs query []string false "Catalog service IDs, comma separated or repeated" collectionFormat(csv)
+// @Param categoryID query string false "Category ID, subcategories included"
+// @Param tags query []string false "Subscriptions with any of the tags, comma separated or repeated" collectionFormat(csv)
+// @Param status query []string false "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired" collectionFormat(csv)
+// @Param userIDs query []string false "User UUIDs, comma separated or repeated" collectionFormat(csv)
+// @Param search query string false "Case-insensitive substring of the service name"
+// @Success 200 {object} ForecastResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/forecast [get]
+func (h *ForecastHandler) Forecast(c *gin.Context) {
+	h.logger.Debugw("handling Forecast()")
+
+	opts, err := h.forecastOptions(c)
+	if err != nil {
+		h.logger.Errorw("Invalid forecast options", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	filter, err := constructFilterFromContextQuery(c, h.logger)
+	if err != nil {
+		h.logger.Errorw("Failed to construct filter from context query", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	var (
+		subscriptions []*subs.Subscription
+		ids           []string
+	)
+	// Закончившиеся до начала прогноза подписки ничего в него не добавят
+	err = h.subsRepo.Stream(filter, func(subscription *subs.Subscription) error {
+		if subscription.EndDate != nil && subscription.EndDate.Before(opts.From) {
+			return nil
+		}
+		subscriptions = append(subscriptions, subscription)
+		ids = append(ids, subscription.ID)
+		return nil
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to read subscriptions for forecast", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	changes, err := h.pricingRepo.ListForSubscriptions(ids)
+	if err != nil {
+		h.logger.Errorw("Failed to read price changes for forecast", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	result := forecast.Build(subscriptions, changes, *opts)
+
+	response := ForecastResponse{
+		Message:   messageSuccess,
+		ChurnRate: opts.ChurnRate,
+		Total:     result.Total,
+		Expected:  result.Expected,
+		Months:    make([]*ForecastMonth, 0, len(result.Months)),
+	}
+	for _, month := range result.Months {
+		forecastMonth := &ForecastMonth{
+			Month:         month.Month.Format(subs.TimeParseFormat),
+			Total:         month.Total,
+			Expected:      month.Expected,
+			Subscriptions: make([]*ForecastContribution, 0, len(month.Subscriptions)),
+		}
+		for _, contribution := range month.Subscriptions {
+			forecastMonth.Subscriptions = append(forecastMonth.Subscriptions, &ForecastContribution{
+				SubscriptionID: contribution.SubscriptionID,
+				ServiceName:    contribution.Service,
+				Price:          contribution.Cost,
+				PriceChanged:   contribution.PriceChanged,
+			})
+		}
+		response.Months = append(response.Months, forecastMonth)
+	}
+
+	h.logger.Infow("Successfully built forecast", "months", opts.Months, "subscriptions", len(subscriptions))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ForecastHandler) forecastOptions(c *gin.Context) (*forecast.Options, error) {
+	now := time.Now().UTC()
+	opts := &forecast.Options{
+		From:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Months: defaultForecastMonths,
+	}
+
+	if monthsStr := c.Query("months"); monthsStr != "" {
+		months, err := strconv.Atoi(monthsStr)
+		if err != nil {
+			return nil, newFieldError("months", CodeInvalidNumber, ErrInvalidParam)
+		}
+		if months < 1 || months > forecast.MaxMonths {
+			return nil, newFieldError("months", CodeOutOfRange, ErrInvalidParam)
+		}
+		opts.Months = months
+	}
+
+	if churnStr := c.Query("churnRate"); churnStr != "" {
+		churnRate, err := strconv.ParseFloat(churnStr, 64)
+		if err != nil {
+			return nil, newFieldError("churnRate", CodeInvalidNumber, ErrInvalidParam)
+		}
+		// Условие записано через отрицание, чтобы отклонить и NaN
+		if !(churnRate >= 0 && churnRate < 1) {
+			return nil, newFieldError("churnRate", CodeOutOfRange, ErrChurnRate)
+		}
+		opts.ChurnRate = churnRate
+	}
+
+	var err error
+	if opts.Proration, err = prorationParam(c, h.logger, h.proration); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
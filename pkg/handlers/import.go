@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"online-subs/pkg/i18n"
+	"online-subs/pkg/importer"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	maxImportFileSize = 10 << 20
+
+	importFileField = "file"
+)
+
+var ErrImportFileTooLarge = errors.New("import file is too large")
+
+type ImportHandler struct {
+	csvImporter *importer.CSVImporter
+	// overlapPolicy - политика для пересекающихся строк, если запрос не передал свою в параметре overlapPolicy
+	overlapPolicy overlaps.Policy
+	logger        *zap.SugaredLogger
+}
+
+func NewImportHandler(csvImporter *importer.CSVImporter, overlapPolicy overlaps.Policy, logger *zap.SugaredLogger) *ImportHandler {
+	return &ImportHandler{
+		csvImporter:   csvImporter,
+		overlapPolicy: overlapPolicy,
+		logger:        logger,
+	}
+}
+
+type ImportRowResponse struct {
+	Line   int                `json:"line"`
+	Status importer.RowStatus `json:"status"`
+	ID     string             `json:"id,omitempty"`
+	Errors []FieldError       `json:"errors,omitempty"`
+}
+
+type ImportResponse struct {
+	Message    string              `json:"message"`
+	DryRun     bool                `json:"dry_run"`
+	Delimiter  string              `json:"delimiter"`
+	Total      int                 `json:"total"`
+	Valid      int                 `json:"valid"`
+	Invalid    int                 `json:"invalid"`
+	Duplicates int                 `json:"duplicates"`
+	Created    int                 `json:"created"`
+	Failed     int                 `json:"failed"`
+	Rows       []ImportRowResponse `json:"rows"`
+}
+
+// ImportCSV godoc
+// @Summary Import subscriptions from CSV
+// @Description Accepts a multipart form with a "file" field or a raw text/csv body (up to 10 MB, 10000 rows).
+// @Description Columns are matched by name (service_name, price, user_id, start_date, end_date), dates may be MM-YYYY or YYYY-MM-DD.
+// @Description With dryRun=true rows are only validated and checked for duplicates, nothing is written.
+// @Description overlapPolicy works as in /batch/create, rows rejected by it are reported as failed.
+// @Tags subscriptions
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param file formData file false "CSV file"
+// @Param dryRun query bool false "Validate only, write nothing"
+// @Param delimiter query string false "Column delimiter (single character or tab), detected from the header if omitted"
+// @Param columns query string false "Column mapping field:column, e.g. service_name:Service,price:Monthly price"
+// @Param overlapPolicy query string false "allow, warn or reject"
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 413 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/import/csv [post]
+func (h *ImportHandler) ImportCSV(c *gin.Context) {
+	h.logger.Debugw("handling ImportCSV()")
+
+	opts, err := h.buildImportOptions(c)
+	if err != nil {
+		h.logger.Errorw("Invalid import options", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	file, err := openImportFile(c)
+	if err != nil {
+		h.logger.Errorw("Failed to open import file", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			h.logger.Warnw("Failed to close import file", "error", errClose)
+		}
+	}()
+
+	report, err := h.csvImporter.Import(file, opts)
+	if err != nil {
+		h.logger.Errorw("Failed to import csv", "error", err)
+
+		respondProblem(c, importError(err))
+		return
+	}
+
+	localizer := i18n.FromContext(c)
+
+	rows := make([]ImportRowResponse, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		rows = append(rows, ImportRowResponse{
+			Line:   row.Line,
+			Status: row.Status,
+			ID:     row.ID,
+			Errors: importRowErrors(row.Errors, localizer),
+		})
+	}
+
+	h.logger.Infow("Successfully imported csv", "total", report.Total, "created", report.Created, "dryRun", report.DryRun)
+	c.JSON(http.StatusOK, ImportResponse{
+		Message:    messageSuccess,
+		DryRun:     report.DryRun,
+		Delimiter:  string(report.Delimiter),
+		Total:      report.Total,
+		Valid:      report.Valid,
+		Invalid:    report.Invalid,
+		Duplicates: report.Duplicates,
+		Created:    report.Created,
+		Failed:     report.Failed,
+		Rows:       rows,
+	})
+}
+
+func (h *ImportHandler) buildImportOptions(c *gin.Context) (*importer.Options, error) {
+	opts := &importer.Options{}
+
+	if dryRunStr := c.Query("dryRun"); dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			return nil, newFieldError("dryRun", CodeInvalidParam, ErrInvalidParam)
+		}
+		opts.DryRun = dryRun
+	}
+
+	delimiter, err := importer.ParseDelimiter(c.Query("delimiter"))
+	if err != nil {
+		return nil, newFieldError("delimiter", CodeInvalidParam, err)
+	}
+	opts.Delimiter = delimiter
+
+	mapping, err := importer.ParseMapping(c.Query("columns"))
+	if err != nil {
+		return nil, importError(err)
+	}
+	opts.Mapping = mapping
+
+	if opts.OverlapPolicy, err = overlapPolicyParam(c, h.logger, h.overlapPolicy); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// openImportFile - файл из поля file формы multipart или тело запроса целиком, не больше maxImportFileSize
+func openImportFile(c *gin.Context) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, nil
+	}
+
+	fileHeader, err := c.FormFile(importFileField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrImportFileTooLarge
+		}
+
+		return nil, newFieldError(importFileField, CodeRequired, ErrRequiredParam)
+	}
+
+	return fileHeader.Open()
+}
+
+// importError переводит ошибки разбора файла целиком в ошибки валидации запроса
+func importError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrImportFileTooLarge
+	}
+
+	column := ""
+	var columnErr *importer.ColumnError
+	if errors.As(err, &columnErr) {
+		column = columnErr.Column
+	}
+
+	switch {
+	case errors.Is(err, importer.ErrMissingColumn):
+		return newFieldError(importFileField, CodeRequired, importer.ErrMissingColumn, column)
+	case errors.Is(err, importer.ErrUnknownField):
+		return newFieldError("columns", CodeInvalidParam, importer.ErrUnknownField, column)
+	case errors.Is(err, importer.ErrInvalidMapping):
+		return newFieldError("columns", CodeInvalidParam, importer.ErrInvalidMapping)
+	case errors.Is(err, importer.ErrEmptyFile):
+		return newFieldError(importFileField, CodeRequired, importer.ErrEmptyFile)
+	case errors.Is(err, importer.ErrTooManyRows):
+		return newFieldError(importFileField, CodeOutOfRange, importer.ErrTooManyRows, importer.MaxRows)
+	case errors.Is(err, importer.ErrMalformedCSV):
+		return newFieldError(importFileField, CodeInvalidParam, importer.ErrMalformedCSV)
+	default:
+		return err
+	}
+}
+
+var importRowErrorCodes = map[error]ErrorCode{
+	importer.ErrEmptyService:    CodeRequired,
+	importer.ErrInvalidPrice:    CodeInvalidNumber,
+	importer.ErrInvalidUserID:   CodeInvalidUUID,
+	importer.ErrInvalidDate:     CodeInvalidDateFormat,
+	importer.ErrEndBeforeStart:  CodeInvalidPeriod,
+	importer.ErrDuplicateInFile: CodeDuplicate,
+	subs.ErrAlreadyExists:       CodeAlreadyExists,
+}
+
+func importRowErrors(rowErrors []importer.FieldError, localizer *i18n.Localizer) []FieldError {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(rowErrors))
+	for _, rowErr := range rowErrors {
+		code, ok := importRowErrorCodes[rowErr.Err]
+		cause := rowErr.Err
+		if !ok {
+			// Ошибки БД наружу не отдаём, как и в problem+json
+			code, cause = CodeInternal, ErrInternal
+		}
+
+		fields = append(fields, FieldError{
+			Field:   rowErr.Field,
+			Code:    code,
+			Message: localizeError(localizer, cause, cause.Error()),
+		})
+	}
+
+	return fields
+}
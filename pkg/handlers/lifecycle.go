@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"online-subs/pkg/subs"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelSub godoc
+// @Summary Cancel subscription at period end
+// @Description The subscription ends the day before the next charge, or with the trial if it is in progress. An earlier end date is kept.
+// @Description Allowed in the trial, active and paused statuses.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/cancel/{id} [post]
+func (h *SubsHandler) CancelSub(c *gin.Context) {
+	h.logger.Debugw("handling CancelSub()")
+
+	h.handleTransition(c, h.subsRepo.Cancel)
+}
+
+// ReactivateSub godoc
+// @Summary Reactivate subscription
+// @Description Removes the cancellation and the end date. For a cancelled or expired subscription the days since it ended are recorded as a pause.
+// @Description Allowed in the cancellation_scheduled, cancelled and expired statuses.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/reactivate/{id} [post]
+func (h *SubsHandler) ReactivateSub(c *gin.Context) {
+	h.logger.Debugw("handling ReactivateSub()")
+
+	h.handleTransition(c, h.subsRepo.Reactivate)
+}
+
+func (h *SubsHandler) handleTransition(c *gin.Context, transition func(id string, day time.Time) (*subs.Subscription, error)) {
+	id := c.Param("id")
+
+	today := subs.Today()
+
+	subscription, err := transition(id, today)
+	if err != nil {
+		h.logger.Errorw("Failed to change subscription status", "id", id, "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	view := &viewOptions{now: today}
+
+	h.logger.Infow("Successfully changed subscription status", "id", id, "status", subscription.StatusAt(today))
+	c.JSON(http.StatusOK, SubscriptionResponse{
+		Message:      messageSuccess,
+		Subscription: view.render(subscription),
+	})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var ErrMergeSelf = errors.New("target subscription must not be among merged ones")
+
+type OverlapsHandler struct {
+	checker *overlaps.Checker
+	logger  *zap.SugaredLogger
+}
+
+func NewOverlapsHandler(checker *overlaps.Checker, logger *zap.SugaredLogger) *OverlapsHandler {
+	return &OverlapsHandler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+type mergeRequest struct {
+	// TargetID - подписка, которая остаётся
+	TargetID string   `json:"target_id"`
+	IDs      []string `json:"ids"`
+}
+
+type OverlapGroupDTO struct {
+	UserID      string  `json:"user_id"`
+	ServiceName string  `json:"service_name" example:"Netflix"`
+	StartDate   string  `json:"start_date" example:"2026-01-01"`
+	EndDate     *string `json:"end_date" example:"2026-12-31"`
+	// ExtraMonthlyCost - сколько в месяц сейчас учитывается дважды
+	ExtraMonthlyCost int64              `json:"extra_monthly_cost"`
+	Subscriptions    []*SubscriptionDTO `json:"subscriptions"`
+}
+
+type OverlapsResponse struct {
+	Message string             `json:"message"`
+	Groups  []*OverlapGroupDTO `json:"groups"`
+}
+
+// ListOverlaps godoc
+// @Summary Report overlapping subscriptions
+// @Description Groups subscriptions of the user to the same service (catalog service or normalized name) whose periods overlap.
+// @Description extra_monthly_cost is what totals count twice today: prices of the active subscriptions in the group except the most expensive one.
+// @Tags overlaps
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Success 200 {object} OverlapsResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/overlaps [get]
+func (h *OverlapsHandler) ListOverlaps(c *gin.Context) {
+	h.logger.Debugw("handling ListOverlaps()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	groups, err := h.checker.Report(userID)
+	if err != nil {
+		h.logger.Errorw("Failed to report overlapping subscriptions", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	view := &viewOptions{now: subs.Today()}
+	dtos := make([]*OverlapGroupDTO, 0, len(groups))
+	for _, group := range groups {
+		dto := &OverlapGroupDTO{
+			UserID:           group.UserID.String(),
+			ServiceName:      group.Service,
+			StartDate:        group.StartDate.Format(subs.DateFormat),
+			ExtraMonthlyCost: group.ExtraMonthlyCost,
+			Subscriptions:    view.renderAll(group.Subscriptions),
+		}
+		if group.EndDate != nil {
+			endDate := group.EndDate.Format(subs.DateFormat)
+			dto.EndDate = &endDate
+		}
+		dtos = append(dtos, dto)
+	}
+
+	h.logger.Infow("Successfully reported overlapping subscriptions", "groups", len(dtos))
+	c.JSON(http.StatusOK, OverlapsResponse{
+		Message: messageSuccess,
+		Groups:  dtos,
+	})
+}
+
+// MergeSubscriptions godoc
+// @Summary Merge overlapping subscriptions
+// @Description Keeps target_id and stretches its period over the merged subscriptions, which are deleted with their pauses and charges.
+// @Description Tags are combined, price and billing day of the target are kept. All subscriptions must belong to the same user,
+// @Description refer to the same service and overlap as one group, see GET /overlaps.
+// @Tags overlaps
+// @Accept json
+// @Produce json
+// @Param request body mergeRequest true "Target and merged subscription IDs"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/overlaps/merge [post]
+func (h *OverlapsHandler) MergeSubscriptions(c *gin.Context) {
+	h.logger.Debugw("handling MergeSubscriptions()")
+
+	var request mergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	if request.TargetID == "" {
+		respondProblem(c, newFieldError("target_id", CodeRequired, ErrRequiredParam))
+		return
+	}
+
+	var ids []string
+	for _, id := range request.IDs {
+		if id == request.TargetID {
+			respondProblem(c, newFieldError("ids", CodeInvalidParam, ErrMergeSelf))
+			return
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		respondProblem(c, newFieldError("ids", CodeRequired, ErrRequiredParam))
+		return
+	}
+	if len(ids) > subs.MaxBatchSize {
+		respondProblem(c, newFieldError("ids", CodeOutOfRange, ErrTooManyValues, subs.MaxBatchSize))
+		return
+	}
+
+	subscription, err := h.checker.Merge(request.TargetID, ids)
+	if err != nil {
+		h.logger.Errorw("Failed to merge subscriptions", "id", request.TargetID, "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	view := &viewOptions{now: subs.Today()}
+
+	h.logger.Infow("Successfully merged subscriptions", "id", request.TargetID, "merged", len(ids))
+	c.JSON(http.StatusOK, SubscriptionResponse{
+		Message:      messageSuccess,
+		Subscription: view.render(subscription),
+	})
+}
+
+// overlapPolicyParam - политика пересечений из параметра overlapPolicy, fallback если он не передан
+func overlapPolicyParam(c *gin.Context, logger *zap.SugaredLogger, fallback overlaps.Policy) (overlaps.Policy, error) {
+	value := c.Query("overlapPolicy")
+	if value == "" {
+		return fallback, nil
+	}
+
+	policy, err := overlaps.ParsePolicy(value)
+	if err != nil {
+		logger.Errorw("Unknown overlap policy", "policy", value)
+
+		return "", newFieldError("overlapPolicy", CodeInvalidParam, err, value)
+	}
+
+	return policy, nil
+}
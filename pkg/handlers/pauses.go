@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"online-subs/pkg/subs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type pauseRequest struct {
+	// StartDate по умолчанию - текущий месяц, без EndDate пауза длится до возобновления
+	StartDate *string `json:"start_date" example:"2025-06-01"`
+	EndDate   *string `json:"end_date" example:"2025-08-31"`
+}
+
+type resumeRequest struct {
+	// ResumeDate - первый оплачиваемый месяц после паузы, по умолчанию текущий
+	ResumeDate *string `json:"resume_date" example:"2025-09-01"`
+}
+
+type PauseDTO struct {
+	ID        string  `json:"id"`
+	StartDate string  `json:"start_date" example:"2025-06-01"`
+	EndDate   *string `json:"end_date" example:"2025-08-31"`
+}
+
+// PauseSub godoc
+// @Summary Pause subscription
+// @Description Charges falling on paused days are skipped and the subscription is not active on them. Without end_date the pause lasts until resumed.
+// @Description The body may be omitted to pause from today.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param request body pauseRequest false "Pause"
+// @Success 201 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/pause/{id} [post]
+func (h *SubsHandler) PauseSub(c *gin.Context) {
+	h.logger.Debugw("handling PauseSub()")
+
+	var request pauseRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	pause := &subs.Pause{StartDate: subs.Today()}
+	if request.StartDate != nil {
+		startDate, err := subs.ParseDate(*request.StartDate)
+		if err != nil {
+			h.logger.Errorw("Invalid pause start date format", "error", err)
+
+			respondProblem(c, newFieldError("start_date", CodeInvalidDateFormat, ErrDateFormat))
+			return
+		}
+		pause.StartDate = startDate
+	}
+
+	if request.EndDate != nil {
+		endDate, err := subs.ParseEndDate(*request.EndDate)
+		if err != nil {
+			h.logger.Errorw("Invalid pause end date format", "error", err)
+
+			respondProblem(c, newFieldError("end_date", CodeInvalidDateFormat, ErrDateFormat))
+			return
+		}
+
+		if endDate.Before(pause.StartDate) {
+			h.logger.Errorw(ErrEndBeforeStart.Error(), "startDate", pause.StartDate, "endDate", endDate)
+
+			respondProblem(c, newFieldError("end_date", CodeInvalidPeriod, ErrEndBeforeStart))
+			return
+		}
+		pause.EndDate = &endDate
+	}
+
+	id := c.Param("id")
+	pauseID, err := h.subsRepo.Pause(id, pause)
+	if err != nil {
+		h.logger.Errorw("Failed to pause subscription", "error", err)
+
+		if errors.Is(err, subs.ErrPauseOutsidePeriod) {
+			err = newFieldError("start_date", CodeInvalidPeriod, subs.ErrPauseOutsidePeriod)
+		}
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully paused subscription", "id", id, "pauseID", pauseID)
+	c.JSON(http.StatusCreated, BasicResponse{
+		Message: messageSuccess,
+		ID:      pauseID,
+	})
+}
+
+// ResumeSub godoc
+// @Summary Resume subscription
+// @Description Ends the nearest unfinished pause the day before resume_date, a pause that has not started by then is cancelled.
+// @Description The body may be omitted to resume from today.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param request body resumeRequest false "Resume"
+// @Success 200 {object} BasicResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/resume/{id} [post]
+func (h *SubsHandler) ResumeSub(c *gin.Context) {
+	h.logger.Debugw("handling ResumeSub()")
+
+	var request resumeRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	day := subs.Today()
+	if request.ResumeDate != nil {
+		resumeDate, err := subs.ParseDate(*request.ResumeDate)
+		if err != nil {
+			h.logger.Errorw("Invalid resume date format", "error", err)
+
+			respondProblem(c, newFieldError("resume_date", CodeInvalidDateFormat, ErrDateFormat))
+			return
+		}
+		day = resumeDate
+	}
+
+	id := c.Param("id")
+	if err := h.subsRepo.Resume(id, day); err != nil {
+		h.logger.Errorw("Failed to resume subscription", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully resumed subscription", "id", id, "day", day)
+	c.JSON(http.StatusOK, BasicResponse{
+		Message: messageSuccess,
+		ID:      id,
+	})
+}
+
+// bindOptionalJSON разбирает тело запроса, если оно есть. Пустое тело оставляет request нулевым
+func bindOptionalJSON(c *gin.Context, request any) error {
+	if err := c.ShouldBindJSON(request); err != nil && !errors.Is(err, io.EOF) {
+		return bindingError(err)
+	}
+
+	return nil
+}
+
+func pauseDTOs(pauses []*subs.Pause) []*PauseDTO {
+	if pauses == nil {
+		return nil
+	}
+
+	dtos := make([]*PauseDTO, 0, len(pauses))
+	for _, pause := range pauses {
+		dto := &PauseDTO{
+			ID:        pause.ID,
+			StartDate: pause.StartDate.Format(subs.DateFormat),
+		}
+		if pause.EndDate != nil {
+			endDate := pause.EndDate.Format(subs.DateFormat)
+			dto.EndDate = &endDate
+		}
+		dtos = append(dtos, dto)
+	}
+
+	return dtos
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"online-subs/pkg/budgets"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/categories"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/export"
+	"online-subs/pkg/feeds"
+	"online-subs/pkg/i18n"
+	"online-subs/pkg/importer"
+	"online-subs/pkg/middleware"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/pricing"
+	"online-subs/pkg/statements"
+	"online-subs/pkg/subs"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:subs-service:problem:"
+)
+
+type ErrorCode string
+
+// Коды стабильны и не зависят от текста сообщений - клиенты должны опираться только на них
+const (
+	CodeValidationFailed  ErrorCode = "validation_failed"
+	CodeMalformedBody     ErrorCode = "malformed_body"
+	CodeWrongParams       ErrorCode = "wrong_params"
+	CodeNotFound          ErrorCode = "subscription_not_found"
+	CodeAlreadyExists     ErrorCode = "subscription_already_exists"
+	CodeInternal          ErrorCode = "internal_error"
+	CodeInvalidDateFormat ErrorCode = "invalid_date_format"
+	CodeInvalidUUID       ErrorCode = "invalid_uuid"
+	CodeInvalidNumber     ErrorCode = "invalid_number"
+	CodeInvalidType       ErrorCode = "invalid_type"
+	CodeRequired          ErrorCode = "required"
+	CodeOutOfRange        ErrorCode = "out_of_range"
+	CodeInvalidPeriod     ErrorCode = "invalid_period"
+	CodeInvalidParam      ErrorCode = "invalid_param"
+	CodeDuplicate         ErrorCode = "duplicate"
+	CodePayloadTooLarge   ErrorCode = "payload_too_large"
+	CodeFeedNotFound      ErrorCode = "feed_not_found"
+
+	CodePriceChangeNotFound      ErrorCode = "price_change_not_found"
+	CodePriceChangeAlreadyExists ErrorCode = "price_change_already_exists"
+	CodeBudgetNotFound           ErrorCode = "budget_not_found"
+	CodeBudgetAlreadyExists      ErrorCode = "budget_already_exists"
+	CodeCatalogNotFound          ErrorCode = "catalog_service_not_found"
+	CodeCatalogAlreadyExists     ErrorCode = "catalog_service_already_exists"
+	CodeCategoryNotFound         ErrorCode = "category_not_found"
+	CodeCategoryAlreadyExists    ErrorCode = "category_already_exists"
+	CodeCategoryHasChildren      ErrorCode = "category_has_children"
+	CodeUnknownCategory          ErrorCode = "unknown_category"
+	CodePauseConflict            ErrorCode = "pause_conflict"
+	CodeNotPaused                ErrorCode = "subscription_not_paused"
+	CodeInvalidTransition        ErrorCode = "invalid_transition"
+	CodeChargeNotFound           ErrorCode = "charge_not_found"
+	CodeCandidateNotFound        ErrorCode = "candidate_not_found"
+	CodeCandidateResolved        ErrorCode = "candidate_resolved"
+	CodeOverlap                  ErrorCode = "subscription_overlap"
+	CodeMergeMismatch            ErrorCode = "merge_mismatch"
+)
+
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+
+	cause error
+	args  []any
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func newFieldError(field string, code ErrorCode, err error, args ...any) *ValidationError {
+	return &ValidationError{
+		Fields: []FieldError{{
+			Field:   field,
+			Code:    code,
+			Message: err.Error(),
+			cause:   err,
+			args:    args,
+		}},
+	}
+}
+
+// ProblemResponse - тело ошибки в формате RFC 7807 (application/problem+json)
+type ProblemResponse struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      ErrorCode    `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	cause error
+}
+
+func newProblem(status int, code ErrorCode, cause error) *ProblemResponse {
+	return &ProblemResponse{
+		Type:   problemTypePrefix + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: cause.Error(),
+		Code:   code,
+		cause:  cause,
+	}
+}
+
+func problemFromError(err error) *ProblemResponse {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		problem := newProblem(http.StatusBadRequest, CodeValidationFailed, ErrValidationFailed)
+		problem.Errors = validationErr.Fields
+		return problem
+	}
+
+	switch {
+	case errors.Is(err, ErrMalformedBody):
+		return newProblem(http.StatusBadRequest, CodeMalformedBody, ErrMalformedBody)
+	case errors.Is(err, ErrImportFileTooLarge):
+		return newProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, ErrImportFileTooLarge)
+	case errors.Is(err, feeds.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeFeedNotFound, feeds.ErrNotFound)
+	case errors.Is(err, budgets.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeBudgetNotFound, budgets.ErrNotFound)
+	case errors.Is(err, budgets.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, CodeBudgetAlreadyExists, budgets.ErrAlreadyExists)
+	case errors.Is(err, catalog.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeCatalogNotFound, catalog.ErrNotFound)
+	case errors.Is(err, catalog.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, CodeCatalogAlreadyExists, catalog.ErrAlreadyExists)
+	case errors.Is(err, categories.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeCategoryNotFound, categories.ErrNotFound)
+	case errors.Is(err, categories.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, CodeCategoryAlreadyExists, categories.ErrAlreadyExists)
+	case errors.Is(err, categories.ErrHasChildren):
+		return newProblem(http.StatusConflict, CodeCategoryHasChildren, categories.ErrHasChildren)
+	case errors.Is(err, subs.ErrUnknownCategory):
+		return newProblem(http.StatusBadRequest, CodeUnknownCategory, subs.ErrUnknownCategory)
+	case errors.Is(err, subs.ErrPauseOverlap):
+		return newProblem(http.StatusConflict, CodePauseConflict, subs.ErrPauseOverlap)
+	case errors.Is(err, subs.ErrNotPaused):
+		return newProblem(http.StatusConflict, CodeNotPaused, subs.ErrNotPaused)
+	case errors.Is(err, subs.ErrInvalidTransition):
+		return newProblem(http.StatusConflict, CodeInvalidTransition, subs.ErrInvalidTransition)
+	case errors.Is(err, charges.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeChargeNotFound, charges.ErrNotFound)
+	case errors.Is(err, charges.ErrInvalidTransition):
+		return newProblem(http.StatusConflict, CodeInvalidTransition, charges.ErrInvalidTransition)
+	case errors.Is(err, statements.ErrCandidateNotFound):
+		return newProblem(http.StatusNotFound, CodeCandidateNotFound, statements.ErrCandidateNotFound)
+	case errors.Is(err, statements.ErrCandidateResolved):
+		return newProblem(http.StatusConflict, CodeCandidateResolved, statements.ErrCandidateResolved)
+	case errors.Is(err, overlaps.ErrOverlap):
+		return newProblem(http.StatusConflict, CodeOverlap, overlaps.ErrOverlap)
+	case errors.Is(err, overlaps.ErrMergeMismatch):
+		return newProblem(http.StatusConflict, CodeMergeMismatch, overlaps.ErrMergeMismatch)
+	case errors.Is(err, pricing.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodePriceChangeNotFound, pricing.ErrNotFound)
+	case errors.Is(err, pricing.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, CodePriceChangeAlreadyExists, pricing.ErrAlreadyExists)
+	case errors.Is(err, subs.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeNotFound, subs.ErrNotFound)
+	case errors.Is(err, subs.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, CodeAlreadyExists, subs.ErrAlreadyExists)
+	case errors.Is(err, subs.ErrWrongParams):
+		return newProblem(http.StatusBadRequest, CodeWrongParams, subs.ErrWrongParams)
+	default:
+		// Детали ошибок БД наружу не отдаём, они есть в логах по request_id
+		return newProblem(http.StatusInternalServerError, CodeInternal, ErrInternal)
+	}
+}
+
+func respondProblem(c *gin.Context, err error) {
+	problem := problemFromError(err)
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = middleware.GetRequestID(c)
+
+	localizeProblem(problem, i18n.FromContext(c))
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// Ключи каталогов сообщений, сами тексты лежат в pkg/i18n/locales
+var errorMessageKeys = map[error]string{
+	ErrDateFormat:           "error.invalid_date_format",
+	ErrInvalidParam:         "error.invalid_param",
+	ErrMalformedBody:        "error.malformed_body",
+	ErrNegativeCost:         "error.negative_cost",
+	ErrEndBeforeStart:       "error.end_before_start",
+	ErrRequiredParam:        "error.required_param",
+	ErrPriceRange:           "error.price_range",
+	ErrEndDateRange:         "error.end_date_range",
+	ErrStatusFlags:          "error.status_flags",
+	ErrTrialPeriod:          "error.trial_period",
+	ErrBillingDay:           "error.billing_day",
+	ErrTooManyValues:        "error.too_many_values",
+	ErrUnknownField:         "error.unknown_field",
+	ErrUnknownExpand:        "error.unknown_expand",
+	ErrUnexpectedType:       "error.unexpected_type",
+	ErrValidationFailed:     "error.validation_failed",
+	ErrInternal:             "error.internal",
+	ErrBatchMode:            "error.invalid_batch_mode",
+	ErrBatchSize:            "error.invalid_batch_size",
+	ErrImportFileTooLarge:   "error.import_file_too_large",
+	export.ErrUnknownFormat: "error.export.unknown_format",
+	subs.ErrNotFound:        "error.subscription_not_found",
+	subs.ErrAlreadyExists:   "error.subscription_already_exists",
+	subs.ErrWrongParams:     "error.wrong_params",
+	subs.ErrInvalidCursor:   "error.invalid_cursor",
+
+	subs.ErrUnknownSortField:   "error.unknown_sort_field",
+	subs.ErrDuplicateSortField: "error.duplicate_sort_field",
+	subs.ErrUnknownGroupBy:     "error.unknown_group_by",
+	subs.ErrUnknownMetric:      "error.unknown_metric",
+
+	feeds.ErrNotFound: "error.feed_not_found",
+
+	pricing.ErrNotFound:        "error.price_change_not_found",
+	pricing.ErrAlreadyExists:   "error.price_change_already_exists",
+	ErrEffectiveDateNotFuture:  "error.effective_date_not_future",
+	ErrEffectiveDateOutOfRange: "error.effective_date_out_of_range",
+	ErrChurnRate:               "error.churn_rate",
+
+	budgets.ErrNotFound:         "error.budget_not_found",
+	budgets.ErrAlreadyExists:    "error.budget_already_exists",
+	budgets.ErrInvalidThreshold: "error.invalid_budget_thresholds",
+	ErrBudgetAmount:             "error.budget_amount",
+
+	catalog.ErrNotFound:      "error.catalog_service_not_found",
+	catalog.ErrAlreadyExists: "error.catalog_service_already_exists",
+	ErrNameTooLong:           "error.name_too_long",
+	ErrInvalidURL:            "error.invalid_url",
+
+	categories.ErrNotFound:       "error.category_not_found",
+	categories.ErrAlreadyExists:  "error.category_already_exists",
+	categories.ErrParentNotFound: "error.parent_category_not_found",
+	categories.ErrCycle:          "error.category_cycle",
+	categories.ErrTooDeep:        "error.category_too_deep",
+	categories.ErrHasChildren:    "error.category_has_children",
+	subs.ErrUnknownCategory:      "error.unknown_category",
+	subs.ErrInvalidTag:           "error.invalid_tags",
+
+	subs.ErrPauseOutsidePeriod: "error.pause_outside_period",
+	subs.ErrPauseOverlap:       "error.pause_overlap",
+	subs.ErrNotPaused:          "error.subscription_not_paused",
+	subs.ErrInvalidTransition:  "error.invalid_transition",
+	subs.ErrUnknownStatus:      "error.unknown_status",
+	subs.ErrUnknownProration:   "error.unknown_proration",
+
+	charges.ErrNotFound:          "error.charge_not_found",
+	charges.ErrUnknownStatus:     "error.unknown_charge_status",
+	charges.ErrInvalidTransition: "error.invalid_charge_transition",
+	charges.ErrNegativeAmount:    "error.negative_amount",
+
+	statements.ErrUnknownFormat:      "error.statement.unknown_format",
+	statements.ErrEmptyStatement:     "error.statement.empty",
+	statements.ErrMalformedStatement: "error.statement.malformed",
+	statements.ErrMissingColumn:      "error.statement.missing_column",
+	statements.ErrTooManyRows:        "error.statement.too_many_rows",
+	statements.ErrInvalidDate:        "error.statement.invalid_date",
+	statements.ErrInvalidAmount:      "error.statement.invalid_amount",
+
+	statements.ErrCandidateNotFound:     "error.candidate_not_found",
+	statements.ErrCandidateResolved:     "error.candidate_resolved",
+	statements.ErrUnknownCandidateState: "error.unknown_candidate_status",
+	statements.ErrConfidenceRange:       "error.confidence_range",
+	statements.ErrCandidatePrice:        "error.candidate_price",
+
+	overlaps.ErrUnknownPolicy: "error.unknown_overlap_policy",
+	overlaps.ErrOverlap:       "error.subscription_overlap",
+	overlaps.ErrMergeMismatch: "error.merge_mismatch",
+	ErrMergeSelf:              "error.merge_self",
+
+	importer.ErrEmptyFile:        "error.import.empty_file",
+	importer.ErrMissingColumn:    "error.import.missing_column",
+	importer.ErrUnknownField:     "error.import.unknown_field",
+	importer.ErrInvalidMapping:   "error.import.invalid_mapping",
+	importer.ErrInvalidDelimiter: "error.import.invalid_delimiter",
+	importer.ErrTooManyRows:      "error.import.too_many_rows",
+	importer.ErrMalformedCSV:     "error.import.malformed_csv",
+	importer.ErrEmptyService:     "error.import.empty_service",
+	importer.ErrInvalidPrice:     "error.import.invalid_price",
+	importer.ErrInvalidUserID:    "error.import.invalid_user_id",
+	importer.ErrInvalidDate:      "error.import.invalid_date",
+	importer.ErrEndBeforeStart:   "error.end_before_start",
+	importer.ErrDuplicateInFile:  "error.import.duplicate_in_file",
+}
+
+func localizeError(localizer *i18n.Localizer, err error, fallback string, args ...any) string {
+	key, ok := errorMessageKeys[err]
+	if !ok {
+		return fallback
+	}
+
+	return localizer.Message(key, fallback, args...)
+}
+
+func localizeProblem(problem *ProblemResponse, localizer *i18n.Localizer) {
+	problem.Title = localizer.Message("title."+string(problem.Code), problem.Title)
+	problem.Detail = localizeError(localizer, problem.cause, problem.Detail)
+
+	for i := range problem.Errors {
+		field := &problem.Errors[i]
+		field.Message = localizeError(localizer, field.cause, field.Message, field.args...)
+	}
+}
+
+func bindingError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return newFieldError(typeErr.Field, CodeInvalidType, ErrUnexpectedType, typeErr.Type.String())
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrMalformedBody
+	}
+
+	return newFieldError("body", CodeValidationFailed, err)
+}
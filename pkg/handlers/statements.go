@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/i18n"
+	"online-subs/pkg/importer"
+	"online-subs/pkg/statements"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// По умолчанию сверка без выписки охватывает текущий и два предыдущих месяца
+const defaultReconcileMonths = 3
+
+type StatementsHandler struct {
+	reconciler       *statements.Reconciler
+	transactionsRepo statements.TransactionsRepo
+	logger           *zap.SugaredLogger
+}
+
+func NewStatementsHandler(reconciler *statements.Reconciler, transactionsRepo statements.TransactionsRepo,
+	logger *zap.SugaredLogger) *StatementsHandler {
+	return &StatementsHandler{
+		reconciler:       reconciler,
+		transactionsRepo: transactionsRepo,
+		logger:           logger,
+	}
+}
+
+type TransactionDTO struct {
+	ID          string  `json:"id"`
+	Date        string  `json:"date" example:"2026-10-15"`
+	Amount      int64   `json:"amount"`
+	Currency    string  `json:"currency" example:"RUB"`
+	Description string  `json:"description"`
+	ChargeID    *string `json:"charge_id,omitempty"`
+}
+
+type MatchDTO struct {
+	Transaction *TransactionDTO `json:"transaction"`
+	Charge      *ChargeDTO      `json:"charge"`
+}
+
+type ReconciliationDTO struct {
+	PeriodStart string `json:"period_start" example:"2026-10-01"`
+	PeriodEnd   string `json:"period_end" example:"2026-10-31"`
+	// Matched - списания, отмеченные оплаченными по операциям выписки
+	Matched   []*MatchDTO       `json:"matched"`
+	Unmatched []*TransactionDTO `json:"unmatched"`
+	// Missing - ожидаемые списания периода без операции
+	Missing []*ChargeDTO `json:"missing"`
+	// Suggestions - предложенные кандидаты в подписки, принимаются через POST /candidates/{id}/accept
+	Suggestions []*CandidateDTO `json:"suggestions"`
+}
+
+type StatementRowDTO struct {
+	Row     int       `json:"row"`
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+type StatementImportResponse struct {
+	Message      string             `json:"message"`
+	Format       string             `json:"format" example:"ofx"`
+	Transactions int                `json:"transactions"`
+	Imported     int                `json:"imported"`
+	Duplicates   int                `json:"duplicates"`
+	Credits      int                `json:"credits"`
+	Invalid      []*StatementRowDTO `json:"invalid"`
+	// Reconciliation отсутствует, если в выписке нет списаний
+	Reconciliation *ReconciliationDTO `json:"reconciliation,omitempty"`
+}
+
+type ReconcileResponse struct {
+	Message        string             `json:"message"`
+	Reconciliation *ReconciliationDTO `json:"reconciliation"`
+}
+
+type TransactionsListResponse struct {
+	Message      string            `json:"message"`
+	Transactions []*TransactionDTO `json:"transactions"`
+	Meta         *Metadata         `json:"meta"`
+}
+
+// ImportStatement godoc
+// @Summary Import bank statement
+// @Description Accepts a CSV or OFX statement as a multipart form with a "file" field or a raw body (up to 10 MB, 10000 transactions).
+// @Description CSV columns are matched by name (date, amount, description, optional currency and id), debits are negative.
+// @Description Debits are stored once per user and matched to expected charges of the statement period: same currency, date within window days,
+// @Description amount within tolerance percent and the description containing the service name or a catalog alias. Matched charges are marked paid.
+// @Description The response lists unmatched transactions, expected charges missing from the statement and recurring merchants proposed as subscription candidates.
+// @Tags statements
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Accept application/x-ofx
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Param file formData file false "Statement file"
+// @Param format query string false "csv or ofx, detected from the content if omitted"
+// @Param delimiter query string false "CSV column delimiter, detected from the header if omitted"
+// @Param window query int false "Days between expected and actual charge, 3 by default, at most 15"
+// @Param tolerance query int false "Amount deviation in percent, 10 by default, at most 50"
+// @Success 200 {object} StatementImportResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 413 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/statements/import [post]
+func (h *StatementsHandler) ImportStatement(c *gin.Context) {
+	h.logger.Debugw("handling ImportStatement()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	opts := &statements.Options{}
+	if formatStr := c.Query("format"); formatStr != "" {
+		if opts.Format, err = statements.ParseFormat(formatStr); err != nil {
+			h.logger.Errorw("Unknown statement format", "format", formatStr)
+
+			respondProblem(c, newFieldError("format", CodeInvalidParam, statements.ErrUnknownFormat, formatStr))
+			return
+		}
+	}
+
+	if opts.Delimiter, err = importer.ParseDelimiter(c.Query("delimiter")); err != nil {
+		respondProblem(c, newFieldError("delimiter", CodeInvalidParam, err))
+		return
+	}
+
+	if opts.Window, opts.Tolerance, err = h.matchParams(c); err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	file, err := openImportFile(c)
+	if err != nil {
+		h.logger.Errorw("Failed to open statement file", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			h.logger.Warnw("Failed to close statement file", "error", errClose)
+		}
+	}()
+
+	report, err := h.reconciler.Import(userID, file, opts)
+	if err != nil {
+		h.logger.Errorw("Failed to import statement", "error", err)
+
+		respondProblem(c, statementError(err))
+		return
+	}
+
+	localizer := i18n.FromContext(c)
+
+	invalid := make([]*StatementRowDTO, 0, len(report.Invalid))
+	for _, row := range report.Invalid {
+		invalid = append(invalid, &StatementRowDTO{
+			Row:     row.Row,
+			Field:   row.Field,
+			Code:    statementRowCodes[row.Err],
+			Message: localizeError(localizer, row.Err, row.Err.Error()),
+		})
+	}
+
+	h.logger.Infow("Successfully imported statement", "userID", userID, "imported", report.Imported)
+	c.JSON(http.StatusOK, StatementImportResponse{
+		Message:        messageSuccess,
+		Format:         string(report.Format),
+		Transactions:   report.Transactions,
+		Imported:       report.Imported,
+		Duplicates:     report.Duplicates,
+		Credits:        report.Credits,
+		Invalid:        invalid,
+		Reconciliation: reconciliationDTO(report.Reconciliation),
+	})
+}
+
+// Reconcile godoc
+// @Summary Reconcile imported transactions
+// @Description Matches unmatched transactions of the period against expected charges again, e.g. after adding subscriptions.
+// @Description The period defaults to the current and two previous months.
+// @Tags statements
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Param dateFrom query string false "From date YYYY-MM-DD or MM-YYYY"
+// @Param dateTo query string false "To date YYYY-MM-DD or MM-YYYY"
+// @Param window query int false "Days between expected and actual charge, 3 by default, at most 15"
+// @Param tolerance query int false "Amount deviation in percent, 10 by default, at most 50"
+// @Success 200 {object} ReconcileResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/statements/reconcile [post]
+func (h *StatementsHandler) Reconcile(c *gin.Context) {
+	h.logger.Debugw("handling Reconcile()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	from, to, err := h.periodParams(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	window, tolerance, err := h.matchParams(c)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	reconciliation, err := h.reconciler.Reconcile(userID, from, to, window, tolerance)
+	if err != nil {
+		h.logger.Errorw("Failed to reconcile transactions", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully reconciled transactions", "userID", userID, "matched", len(reconciliation.Matched))
+	c.JSON(http.StatusOK, ReconcileResponse{
+		Message:        messageSuccess,
+		Reconciliation: reconciliationDTO(reconciliation),
+	})
+}
+
+// ListTransactions godoc
+// @Summary List bank transactions
+// @Description Imported debits of the user ordered by date. matched=false lists transactions without a charge.
+// @Tags statements
+// @Produce json
+// @Param userID query string true "User UUID"
+// @Param matched query bool false "Only matched or only unmatched transactions"
+// @Param dateFrom query string false "From date YYYY-MM-DD or MM-YYYY"
+// @Param dateTo query string false "To date YYYY-MM-DD or MM-YYYY"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} TransactionsListResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/transactions [get]
+func (h *StatementsHandler) ListTransactions(c *gin.Context) {
+	h.logger.Debugw("handling ListTransactions()")
+
+	userID, err := requiredUserID(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user ID", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	filter := &statements.TransactionFilter{UserID: userID}
+	if c.Query("matched") != "" {
+		matched, err := parseBoolParam(c, h.logger, "matched")
+		if err != nil {
+			respondProblem(c, err)
+			return
+		}
+		filter.Matched = &matched
+	}
+
+	if filter.DateFrom, err = parseDateParam(c, h.logger, "dateFrom", subs.ParseDate); err != nil {
+		respondProblem(c, err)
+		return
+	}
+	if filter.DateTo, err = parseDateParam(c, h.logger, "dateTo", subs.ParseEndDate); err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	page, limit := utils.GetPageAndLimitFromContext(c)
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	transactions, total, err := h.transactionsRepo.List(filter)
+	if err != nil {
+		h.logger.Errorw("Failed to list transactions", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	pages := utils.CountPages(total, int64(limit))
+	c.JSON(http.StatusOK, TransactionsListResponse{
+		Message:      messageSuccess,
+		Transactions: transactionDTOs(transactions),
+		Meta: &Metadata{
+			Total: &total,
+			Page:  page,
+			Limit: limit,
+			Pages: &pages,
+		},
+	})
+}
+
+func (h *StatementsHandler) matchParams(c *gin.Context) (int, int, error) {
+	window, err := h.intParam(c, "window", statements.DefaultWindow, statements.MaxWindow)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tolerance, err := h.intParam(c, "tolerance", statements.DefaultTolerance, statements.MaxTolerance)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return window, tolerance, nil
+}
+
+func (h *StatementsHandler) intParam(c *gin.Context, name string, fallback, maxValue int) (int, error) {
+	valueStr := c.Query(name)
+	if valueStr == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 || value > maxValue {
+		h.logger.Errorw("Invalid "+name, "value", valueStr)
+
+		return 0, newFieldError(name, CodeOutOfRange, ErrInvalidParam)
+	}
+
+	return value, nil
+}
+
+func (h *StatementsHandler) periodParams(c *gin.Context) (time.Time, time.Time, error) {
+	to := subs.Today()
+	from := subs.MonthStart(to).AddDate(0, 1-defaultReconcileMonths, 0)
+
+	dateFrom, err := parseDateParam(c, h.logger, "dateFrom", subs.ParseDate)
+	if err != nil {
+		return from, to, err
+	}
+	if dateFrom != nil {
+		from = *dateFrom
+	}
+
+	dateTo, err := parseDateParam(c, h.logger, "dateTo", subs.ParseEndDate)
+	if err != nil {
+		return from, to, err
+	}
+	if dateTo != nil {
+		to = *dateTo
+	}
+
+	if to.Before(from) {
+		h.logger.Errorw(ErrEndBeforeStart.Error(), "dateFrom", from, "dateTo", to)
+
+		return from, to, newFieldError("dateTo", CodeInvalidPeriod, ErrEndBeforeStart)
+	}
+
+	return from, to, nil
+}
+
+var statementRowCodes = map[error]ErrorCode{
+	statements.ErrInvalidDate:   CodeInvalidDateFormat,
+	statements.ErrInvalidAmount: CodeInvalidNumber,
+}
+
+// statementError переводит ошибки разбора выписки целиком в ошибки валидации запроса
+func statementError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrImportFileTooLarge
+	}
+
+	switch {
+	case errors.Is(err, statements.ErrMissingColumn):
+		var columnErr *statements.ColumnError
+		errors.As(err, &columnErr)
+		return newFieldError(importFileField, CodeRequired, statements.ErrMissingColumn, columnErr.Column)
+	case errors.Is(err, statements.ErrEmptyStatement):
+		return newFieldError(importFileField, CodeRequired, statements.ErrEmptyStatement)
+	case errors.Is(err, statements.ErrTooManyRows):
+		return newFieldError(importFileField, CodeOutOfRange, statements.ErrTooManyRows, statements.MaxTransactions)
+	case errors.Is(err, statements.ErrMalformedStatement):
+		return newFieldError(importFileField, CodeInvalidParam, statements.ErrMalformedStatement)
+	default:
+		return err
+	}
+}
+
+func reconciliationDTO(reconciliation *statements.Reconciliation) *ReconciliationDTO {
+	if reconciliation == nil {
+		return nil
+	}
+
+	dto := &ReconciliationDTO{
+		PeriodStart: reconciliation.PeriodStart.Format(subs.DateFormat),
+		PeriodEnd:   reconciliation.PeriodEnd.Format(subs.DateFormat),
+		Matched:     make([]*MatchDTO, 0, len(reconciliation.Matched)),
+		Unmatched:   transactionDTOs(reconciliation.Unmatched),
+		Missing:     make([]*ChargeDTO, 0, len(reconciliation.Missing)),
+		Suggestions: candidateDTOs(reconciliation.Suggestions),
+	}
+
+	for _, m := range reconciliation.Matched {
+		dto.Matched = append(dto.Matched, &MatchDTO{
+			Transaction: transactionDTO(m.Transaction),
+			Charge:      chargeDTO(m.Charge),
+		})
+	}
+	for _, charge := range reconciliation.Missing {
+		dto.Missing = append(dto.Missing, chargeDTO(charge))
+	}
+	return dto
+}
+
+func transactionDTOs(transactions []*statements.Transaction) []*TransactionDTO {
+	dtos := make([]*TransactionDTO, 0, len(transactions))
+	for _, transaction := range transactions {
+		dtos = append(dtos, transactionDTO(transaction))
+	}
+
+	return dtos
+}
+
+func transactionDTO(transaction *statements.Transaction) *TransactionDTO {
+	return &TransactionDTO{
+		ID:          transaction.ID,
+		Date:        transaction.Date.Format(subs.DateFormat),
+		Amount:      transaction.Amount,
+		Currency:    transaction.Currency,
+		Description: transaction.Description,
+		ChargeID:    transaction.ChargeID,
+	}
+}
@@ -3,9 +3,12 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"online-subs/pkg/overlaps"
 	"online-subs/pkg/subs"
 	"online-subs/pkg/utils"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,43 +17,78 @@ import (
 )
 
 type SubsHandler struct {
-	subsRepo subs.SubscriptionsRepo
-	logger   *zap.SugaredLogger
+	subsRepo        subs.SubscriptionsRepo
+	overlapsChecker *overlaps.Checker
+	// proration - политика расчёта сумм, если запрос не передал свою в параметре proration
+	proration subs.Proration
+	// prices - запланированные изменения цен для сводки, nil означает текущие цены
+	prices subs.PriceSource
+	// overlapPolicy - политика создания пересекающихся подписок, если запрос не передал свою в параметре overlapPolicy
+	overlapPolicy overlaps.Policy
+	logger        *zap.SugaredLogger
 }
 
-func NewSubsHandler(subsRepo subs.SubscriptionsRepo, logger *zap.SugaredLogger) *SubsHandler {
+func NewSubsHandler(subsRepo subs.SubscriptionsRepo, overlapsChecker *overlaps.Checker, proration subs.Proration,
+	prices subs.PriceSource, overlapPolicy overlaps.Policy, logger *zap.SugaredLogger) *SubsHandler {
 	return &SubsHandler{
-		subsRepo: subsRepo,
-		logger:   logger,
+		subsRepo:        subsRepo,
+		overlapsChecker: overlapsChecker,
+		proration:       proration,
+		prices:          prices,
+		overlapPolicy:   overlapPolicy,
+		logger:          logger,
 	}
 }
 
 const (
 	messageSuccess = "success"
+
+	// Ограничение на размер IN-списков в фильтре, чтобы запрос не разрастался до тысяч параметров
+	maxFilterListSize = 100
+	maxSearchLength   = 255
 )
 
 var (
-	ErrDateFormat   = errors.New("invalid start date format, expected MM-YYYY")
-	ErrInvalidParam = errors.New("invalid param")
+	ErrDateFormat     = errors.New("invalid date format, expected YYYY-MM-DD or MM-YYYY")
+	ErrInvalidParam   = errors.New("invalid param")
+	ErrMalformedBody  = errors.New("malformed request body")
+	ErrNegativeCost   = errors.New("price must not be negative")
+	ErrEndBeforeStart = errors.New("end date must not be before start date")
+	ErrRequiredParam  = errors.New("param is required")
+	ErrPriceRange     = errors.New("priceMin must not exceed priceMax")
+	ErrEndDateRange   = errors.New("endDateFrom must not be after endDateTo")
+	ErrStatusFlags    = errors.New("activeOnly and endedOnly are mutually exclusive")
+	ErrTooManyValues  = errors.New("too many values in list")
+	ErrTrialPeriod    = errors.New("trial must lie within the subscription period")
+	ErrBillingDay     = errors.New("billing day must be between 1 and 31")
+
+	ErrUnexpectedType   = errors.New("unexpected value type")
+	ErrValidationFailed = errors.New("request validation failed")
+	ErrInternal         = errors.New("internal server error")
 )
 
 type basicRequest struct {
 	ServiceName string    `json:"service_name"`
 	Cost        int32     `json:"price"`
 	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date"`
+	// Даты в формате YYYY-MM-DD, MM-YYYY означает первое число месяца для начала и последнее для окончания
+	StartDate  string  `json:"start_date" example:"2025-07-15"`
+	EndDate    *string `json:"end_date" example:"2025-12-31"`
+	CategoryID *string `json:"category_id"`
+	// BillingDay по умолчанию совпадает с днём start_date
+	BillingDay *int `json:"billing_day" example:"15"`
+	// TrialStartDate по умолчанию совпадает с start_date, достаточно указать trial_end_date
+	TrialStartDate *string `json:"trial_start_date" example:"2025-07-15"`
+	TrialEndDate   *string `json:"trial_end_date" example:"2025-08-14"`
+	// Tags при обновлении: отсутствие поля оставляет метки как есть, [] снимает все
+	Tags []string `json:"tags" example:"work,family"`
 }
 
 // Для корректной генерации сваггера
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 type SubscriptionResponse struct {
-	Message      string             `json:"message"`
-	Subscription *subs.Subscription `json:"subscription"`
+	Message      string           `json:"message"`
+	Subscription *SubscriptionDTO `json:"subscription"`
 }
 
 type BasicResponse struct {
@@ -58,17 +96,27 @@ type BasicResponse struct {
 	ID      string `json:"id"`
 }
 
+// CreateResponse - Overlaps перечисляет пересекающиеся подписки на тот же сервис при политике warn
+type CreateResponse struct {
+	Message  string             `json:"message"`
+	ID       string             `json:"id"`
+	Overlaps []*SubscriptionDTO `json:"overlaps,omitempty"`
+}
+
 type ListResponse struct {
-	Message       string               `json:"message"`
-	Subscriptions []*subs.Subscription `json:"subscriptions"`
-	Meta          *Metadata            `json:"meta"`
+	Message       string             `json:"message"`
+	Subscriptions []*SubscriptionDTO `json:"subscriptions"`
+	Meta          *Metadata          `json:"meta"`
 }
 
+// Total и Pages не возвращаются при withTotal=false, Page - в режиме курсоров
 type Metadata struct {
-	Total int64 `json:"total"`
-	Page  int   `json:"page"`
-	Limit int   `json:"limit"`
-	Pages int64 `json:"pages"`
+	Total      *int64 `json:"total,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Pages      *int64 `json:"pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 type CostResponse struct {
@@ -78,50 +126,51 @@ type CostResponse struct {
 
 // CreateSub godoc
 // @Summary Create subscription
+// @Description overlapPolicy decides what happens when the user already has a subscription to the same service for an overlapping period:
+// @Description allow creates it silently, warn creates it and lists the overlapping subscriptions, reject responds 409. The default comes from OVERLAP_POLICY.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param request body basicRequest true "Subscription payload"
-// @Success 201 {object} BasicResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Param overlapPolicy query string false "allow, warn or reject"
+// @Success 201 {object} CreateResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 409 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/create [post]
 func (h *SubsHandler) CreateSub(c *gin.Context) {
 	h.logger.Debugw("handling CreateSub()")
 
+	policy, err := overlapPolicyParam(c, h.logger, h.overlapPolicy)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
 	newSub, err := h.buildSubscriptionFromContext(c)
 
 	if err != nil {
 		h.logger.Errorw("error creating new sub", "error", err)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		respondProblem(c, err)
 		return
 	}
 
-	var lastInsertedID string
-	if lastInsertedID, err = h.subsRepo.Create(newSub); err != nil {
+	lastInsertedID, overlapping, err := h.overlapsChecker.Create(newSub, policy)
+	if err != nil {
 		h.logger.Errorw("Failed to create subscription", "error", err)
 
-		if errors.Is(err, subs.ErrAlreadyExists) {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error: "Subscription already exists",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "failed to create subscription",
-			})
-		}
-
+		respondProblem(c, err)
 		return
 	}
 
-	h.logger.Infow("Successfully created subscription", "id", lastInsertedID)
+	h.logger.Infow("Successfully created subscription", "id", lastInsertedID, "overlaps", len(overlapping))
 
-	c.JSON(http.StatusCreated, BasicResponse{
-		Message: messageSuccess,
-		ID:      lastInsertedID,
+	view := &viewOptions{now: subs.Today()}
+	c.JSON(http.StatusCreated, CreateResponse{
+		Message:  messageSuccess,
+		ID:       lastInsertedID,
+		Overlaps: view.renderAll(overlapping),
 	})
 }
 
@@ -131,54 +180,165 @@ func (h *SubsHandler) buildSubscriptionFromContext(c *gin.Context) (*subs.Subscr
 	if err := c.ShouldBindJSON(&request); err != nil {
 		h.logger.Errorw("Failed to bind JSON", "error", err)
 
-		return nil, err
+		return nil, bindingError(err)
+	}
+
+	return h.buildSubscription(&request)
+}
+
+func (h *SubsHandler) buildSubscription(request *basicRequest) (*subs.Subscription, error) {
+	if len([]rune(request.ServiceName)) > subs.MaxServiceLength {
+		h.logger.Errorw(ErrNameTooLong.Error(), "service", request.ServiceName)
+
+		return nil, newFieldError("service_name", CodeOutOfRange, ErrNameTooLong, subs.MaxServiceLength)
 	}
 
-	startDate, err := time.Parse(subs.TimeParseFormat, request.StartDate)
+	if request.Cost < 0 {
+		h.logger.Errorw("Negative cost", "cost", request.Cost)
+
+		return nil, newFieldError("price", CodeOutOfRange, ErrNegativeCost)
+	}
+
+	startDate, err := subs.ParseDate(request.StartDate)
 	if err != nil {
 		h.logger.Errorw(ErrDateFormat.Error(), "error", err)
 
-		return nil, ErrDateFormat
+		return nil, newFieldError("start_date", CodeInvalidDateFormat, ErrDateFormat)
+	}
+
+	var billingDay int
+	if request.BillingDay != nil {
+		if *request.BillingDay < 1 || *request.BillingDay > 31 {
+			h.logger.Errorw(ErrBillingDay.Error(), "billingDay", *request.BillingDay)
+
+			return nil, newFieldError("billing_day", CodeOutOfRange, ErrBillingDay)
+		}
+		billingDay = *request.BillingDay
 	}
 
 	var endDate *time.Time
 	if request.EndDate != nil {
-		endDateVal, err := time.Parse(subs.TimeParseFormat, *request.EndDate)
+		endDateVal, err := subs.ParseEndDate(*request.EndDate)
 		if err != nil {
 			h.logger.Errorw("Invalid end date format", "error", err)
 
-			return nil, ErrDateFormat
+			return nil, newFieldError("end_date", CodeInvalidDateFormat, ErrDateFormat)
+		}
+
+		if endDateVal.Before(startDate) {
+			h.logger.Errorw(ErrEndBeforeStart.Error(), "startDate", startDate, "endDate", endDateVal)
+
+			return nil, newFieldError("end_date", CodeInvalidPeriod, ErrEndBeforeStart)
 		}
+
 		endDate = &endDateVal
 	}
 
+	trialStart, trialEnd, err := h.buildTrial(request, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if request.Tags != nil {
+		if tags, err = subs.NormalizeTags(request.Tags); err != nil {
+			h.logger.Errorw("Invalid tags", "tags", request.Tags, "error", err)
+
+			return nil, newFieldError("tags", CodeInvalidParam, err, subs.MaxTags, subs.MaxTagLength)
+		}
+	}
+
 	return &subs.Subscription{
-		Service:   request.ServiceName,
-		Cost:      request.Cost,
-		UserID:    request.UserID,
-		StartDate: startDate,
-		EndDate:   endDate,
+		Service:    request.ServiceName,
+		Cost:       request.Cost,
+		UserID:     request.UserID,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		BillingDay: billingDay,
+		CategoryID: request.CategoryID,
+		Tags:       tags,
+
+		TrialStartDate: trialStart,
+		TrialEndDate:   trialEnd,
 	}, nil
 }
 
+// buildTrial проверяет, что пробный период лежит внутри подписки. Без trial_end_date пробного периода нет
+func (h *SubsHandler) buildTrial(request *basicRequest, startDate time.Time, endDate *time.Time) (*time.Time, *time.Time, error) {
+	if request.TrialEndDate == nil {
+		if request.TrialStartDate != nil {
+			h.logger.Errorw("Trial start without trial end", "trialStartDate", *request.TrialStartDate)
+
+			return nil, nil, newFieldError("trial_end_date", CodeRequired, ErrRequiredParam)
+		}
+		return nil, nil, nil
+	}
+
+	trialEnd, err := subs.ParseEndDate(*request.TrialEndDate)
+	if err != nil {
+		h.logger.Errorw("Invalid trial end date format", "error", err)
+
+		return nil, nil, newFieldError("trial_end_date", CodeInvalidDateFormat, ErrDateFormat)
+	}
+
+	trialStart := startDate
+	if request.TrialStartDate != nil {
+		if trialStart, err = subs.ParseDate(*request.TrialStartDate); err != nil {
+			h.logger.Errorw("Invalid trial start date format", "error", err)
+
+			return nil, nil, newFieldError("trial_start_date", CodeInvalidDateFormat, ErrDateFormat)
+		}
+	}
+
+	if trialStart.Before(startDate) {
+		h.logger.Errorw(ErrTrialPeriod.Error(), "startDate", startDate, "trialStartDate", trialStart)
+
+		return nil, nil, newFieldError("trial_start_date", CodeInvalidPeriod, ErrTrialPeriod)
+	}
+
+	if trialEnd.Before(trialStart) {
+		h.logger.Errorw(ErrEndBeforeStart.Error(), "trialStartDate", trialStart, "trialEndDate", trialEnd)
+
+		return nil, nil, newFieldError("trial_end_date", CodeInvalidPeriod, ErrEndBeforeStart)
+	}
+
+	if endDate != nil && endDate.Before(trialEnd) {
+		h.logger.Errorw(ErrTrialPeriod.Error(), "endDate", *endDate, "trialEndDate", trialEnd)
+
+		return nil, nil, newFieldError("trial_end_date", CodeInvalidPeriod, ErrTrialPeriod)
+	}
+
+	return &trialStart, &trialEnd, nil
+}
+
 // GetSubByID godoc
 // @Summary Get subscription by ID
 // @Tags subscriptions
 // @Produce json
 // @Param id path string true "Subscription ID"
+// @Param fields query string false "Comma separated response fields, e.g. id,service_name,price"
+// @Param expand query string false "Computed fields to add: months_active, total_spent, next_charge_date"
 // @Success 200 {object} SubscriptionResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/get/{id} [get]
 func (h *SubsHandler) GetSubByID(c *gin.Context) {
 
 	h.logger.Debugw("handling GetSubByID()")
 
+	view, err := parseViewOptions(c)
+	if err != nil {
+		h.logger.Errorw("Failed to parse view options", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
 	id := c.Param("id")
 
 	subscription, err := h.subsRepo.ReadByID(id)
-	h.handleGetSubscriptionResponse(c, subscription, err)
+	h.handleGetSubscriptionResponse(c, view, subscription, err)
 }
 
 // GetByParams godoc
@@ -187,58 +347,60 @@ func (h *SubsHandler) GetSubByID(c *gin.Context) {
 // @Produce json
 // @Param service query string true "Service name"
 // @Param userID query string true "User UUID"
-// @Param startDate query string true "Start date MM-YYYY"
+// @Param startDate query string true "Start date YYYY-MM-DD or MM-YYYY"
+// @Param fields query string false "Comma separated response fields, e.g. id,service_name,price"
+// @Param expand query string false "Computed fields to add: months_active, total_spent, next_charge_date"
 // @Success 200 {object} SubscriptionResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/get/query [get]
 func (h *SubsHandler) GetByParams(c *gin.Context) {
 	h.logger.Debugw("handling GetByParams()")
 
-	filter, err := h.constructFilterFromContextQuery(c)
+	view, err := parseViewOptions(c)
+	if err != nil {
+		h.logger.Errorw("Failed to parse view options", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	filter, err := constructFilterFromContextQuery(c, h.logger)
 	if err != nil {
 		h.logger.Errorw("Failed to construct filter from context query", "error", err)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		respondProblem(c, err)
 		return
 	}
 
 	if filter.Service == nil || filter.UserID == nil || filter.StartDate == nil {
 		h.logger.Errorw("Insufficient filter params for a unique instance", "error", ErrInvalidParam)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: ErrInvalidParam.Error(),
-		})
+		respondProblem(c, requiredParamsError(map[string]bool{
+			"service":   filter.Service == nil,
+			"userID":    filter.UserID == nil,
+			"startDate": filter.StartDate == nil,
+		}))
 		return
 	}
 
 	subscription, err := h.subsRepo.ReadByParams(filter)
-	h.handleGetSubscriptionResponse(c, subscription, err)
+	h.handleGetSubscriptionResponse(c, view, subscription, err)
 }
 
-func (h *SubsHandler) handleGetSubscriptionResponse(c *gin.Context, subscription *subs.Subscription, err error) {
+func (h *SubsHandler) handleGetSubscriptionResponse(c *gin.Context, view *viewOptions, subscription *subs.Subscription, err error) {
 	if err != nil {
 		h.logger.Errorw("Failed to read subscription", "error", err)
-		if errors.Is(err, subs.ErrNotFound) {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error: "Subscription not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to read subscription",
-			})
-		}
 
+		respondProblem(c, err)
 		return
 	}
 
 	h.logger.Infow("Successfully read subscription", "id", subscription.ID)
 	c.JSON(http.StatusOK, SubscriptionResponse{
 		Message:      messageSuccess,
-		Subscription: subscription,
+		Subscription: view.render(subscription),
 	})
 }
 
@@ -250,9 +412,9 @@ func (h *SubsHandler) handleGetSubscriptionResponse(c *gin.Context, subscription
 // @Param id path string true "Subscription ID"
 // @Param request body basicRequest true "Subscription payload"
 // @Success 200 {object} BasicResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/update/{id} [patch]
 func (h *SubsHandler) UpdateSub(c *gin.Context) {
 	h.logger.Debugw("handling UpdateSub()")
@@ -264,9 +426,7 @@ func (h *SubsHandler) UpdateSub(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorw("error creating new sub", "error", err)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		respondProblem(c, err)
 		return
 	}
 
@@ -274,16 +434,7 @@ func (h *SubsHandler) UpdateSub(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorw("Failed to update subscription", "error", err)
 
-		if errors.Is(err, subs.ErrNotFound) {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error: "Subscription not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to update subscription",
-			})
-		}
-
+		respondProblem(c, err)
 		return
 	}
 
@@ -301,9 +452,9 @@ func (h *SubsHandler) UpdateSub(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Subscription ID"
 // @Success 200 {object} BasicResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 404 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/delete/{id} [delete]
 func (h *SubsHandler) DeleteSub(c *gin.Context) {
 	h.logger.Debugw("handling DeleteSub()")
@@ -314,16 +465,7 @@ func (h *SubsHandler) DeleteSub(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorw("Failed to delete subscription", "error", err)
 
-		if errors.Is(err, subs.ErrNotFound) {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error: "Subscription not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to delete subscription",
-			})
-		}
-
+		respondProblem(c, err)
 		return
 	}
 
@@ -338,28 +480,53 @@ func (h *SubsHandler) DeleteSub(c *gin.Context) {
 // @Summary List subscriptions
 // @Tags subscriptions
 // @Produce json
+// @Description Supports page/limit (offset) pagination and keyset pagination: pass meta.next_cursor or meta.prev_cursor as cursor.
+// @Description A cursor is bound to the sort it was issued for and takes precedence over page.
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
-// @Param sort query string false "Sort (cost_asc\|cost_desc\|service_asc\|service_desc\|start_date)"
+// @Param cursor query string false "Opaque cursor from meta.next_cursor or meta.prev_cursor"
+// @Param fields query string false "Comma separated response fields, e.g. id,service_name,price"
+// @Param expand query string false "Computed fields to add: months_active, total_spent, next_charge_date"
+// @Param withTotal query bool false "Count total rows (default true), set to false to skip the COUNT query"
+// @Param sort query string false "Sort expression like service,-cost,start_date over service, cost, start_date, end_date, user_id and id; -start_date by default"
 // @Param service query string false "Service name"
 // @Param userID query string false "User UUID"
-// @Param startDate query string false "Start date MM-YYYY"
-// @Param endDate query string false "End date MM-YYYY"
+// @Param startDate query string false "Start date YYYY-MM-DD or MM-YYYY"
+// @Param endDate query string false "End date YYYY-MM-DD or MM-YYYY (last day of the month)"
 // @Param price query int false "Cost"
+// @Param priceMin query int false "Minimal cost, inclusive"
+// @Param priceMax query int false "Maximal cost, inclusive"
+// @Param services query []string false "Service names, comma separated or repeated" collectionFormat(csv)
+// @Param serviceIDs query []string false "Catalog service IDs, comma separated or repeated" collectionFormat(csv)
+// @Param categoryID query string false "Category ID, subcategories included"
+// @Param tags query []string false "Subscriptions with any of the tags, comma separated or repeated" collectionFormat(csv)
+// @Param status query []string false "Statuses today: trial, active, paused, cancellation_scheduled, cancelled, expired" collectionFormat(csv)
+// @Param userIDs query []string false "User UUIDs, comma separated or repeated" collectionFormat(csv)
+// @Param search query string false "Case-insensitive substring of the service name"
+// @Param activeOnly query bool false "Only subscriptions active today"
+// @Param endedOnly query bool false "Only subscriptions ended before today"
+// @Param endDateFrom query string false "End date from YYYY-MM-DD or MM-YYYY, inclusive"
+// @Param endDateTo query string false "End date to YYYY-MM-DD or MM-YYYY, inclusive"
 // @Success 200 {object} ListResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/list [get]
 func (h *SubsHandler) List(c *gin.Context) {
 	h.logger.Debugw("handling List()")
 
-	filter, err := h.constructFilterFromContextQuery(c)
+	view, err := parseViewOptions(c)
+	if err != nil {
+		h.logger.Errorw("Failed to parse view options", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	filter, err := constructFilterFromContextQuery(c, h.logger)
 	if err != nil {
 		h.logger.Errorw("Failed to construct filter from context query", "error", err)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		respondProblem(c, err)
 		return
 	}
 
@@ -368,28 +535,59 @@ func (h *SubsHandler) List(c *gin.Context) {
 	offset := (page - 1) * limit
 	filter.Offset = &offset
 
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := subs.DecodeCursor(cursorStr)
+		if err != nil {
+			h.logger.Errorw("Failed to decode cursor", "error", err)
+
+			respondProblem(c, newFieldError("cursor", CodeInvalidParam, err))
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	if withTotalStr := c.Query("withTotal"); withTotalStr != "" {
+		withTotal, err := strconv.ParseBool(withTotalStr)
+		if err != nil {
+			h.logger.Errorw("Failed to parse withTotal", "error", err)
+
+			respondProblem(c, newFieldError("withTotal", CodeInvalidParam, ErrInvalidParam))
+			return
+		}
+		filter.SkipTotal = !withTotal
+	}
+
 	subsData, err := h.subsRepo.List(filter)
 	if err != nil {
 		h.logger.Errorw("Failed to list subscriptions", "error", err)
 
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to list subscriptions",
-		})
+		if errors.Is(err, subs.ErrInvalidCursor) {
+			err = newFieldError("cursor", CodeInvalidParam, err)
+		}
+
+		respondProblem(c, err)
 		return
 	}
 
-	pages := utils.CountPages(subsData.Total, int64(limit))
-
 	meta := &Metadata{
-		Total: subsData.Total,
-		Page:  page,
-		Limit: limit,
-		Pages: pages,
+		Limit:      limit,
+		NextCursor: subsData.NextCursor,
+		PrevCursor: subsData.PrevCursor,
+	}
+
+	if filter.Cursor == nil {
+		meta.Page = page
+	}
+
+	if !filter.SkipTotal {
+		pages := utils.CountPages(subsData.Total, int64(limit))
+		meta.Total = &subsData.Total
+		meta.Pages = &pages
 	}
 
 	c.JSON(http.StatusOK, ListResponse{
 		Message:       messageSuccess,
-		Subscriptions: subsData.Subscriptions,
+		Subscriptions: view.renderAll(subsData.Subscriptions),
 		Meta:          meta,
 	})
 }
@@ -398,33 +596,38 @@ func (h *SubsHandler) List(c *gin.Context) {
 // @Summary Get total subscription cost for period
 // @Tags subscriptions
 // @Produce json
-// @Param startDate query string true "Start date MM-YYYY"
-// @Param endDate query string true "End date MM-YYYY"
+// @Param startDate query string true "Start date YYYY-MM-DD or MM-YYYY"
+// @Param endDate query string true "End date YYYY-MM-DD or MM-YYYY (last day of the month)"
 // @Param service query string false "Service name"
 // @Param userID query string false "User UUID"
+// @Param proration query string false "Proration policy: none, daily, by_billing_day; the service default if omitted"
 // @Success 200 {object} CostResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
 // @Router /subscriptions/v1/total [get]
 func (h *SubsHandler) GetTotalCost(c *gin.Context) {
 	h.logger.Debugw("handling GetTotalCost()")
 
-	filter, err := h.constructFilterFromContextQuery(c)
+	filter, err := constructFilterFromContextQuery(c, h.logger)
 	if err != nil {
 		h.logger.Errorw("Failed to construct filter from context query", "error", err)
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		respondProblem(c, err)
 		return
 	}
 
 	if filter.StartDate == nil || filter.EndDate == nil {
-		h.logger.Errorw(ErrDateFormat.Error(), "error", err)
+		h.logger.Errorw("Missing period bounds", "error", ErrRequiredParam)
+
+		respondProblem(c, requiredParamsError(map[string]bool{
+			"startDate": filter.StartDate == nil,
+			"endDate":   filter.EndDate == nil,
+		}))
+		return
+	}
 
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: ErrDateFormat.Error(),
-		})
+	if filter.Proration, err = prorationParam(c, h.logger, h.proration); err != nil {
+		respondProblem(c, err)
 		return
 	}
 
@@ -432,9 +635,7 @@ func (h *SubsHandler) GetTotalCost(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorw("Failed to get total cost", "error", err)
 
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to get total cost",
-		})
+		respondProblem(c, err)
 		return
 	}
 
@@ -445,32 +646,40 @@ func (h *SubsHandler) GetTotalCost(c *gin.Context) {
 	})
 }
 
-func (h *SubsHandler) constructFilterFromContextQuery(c *gin.Context) (*subs.SubscriptionFilter, error) {
-	h.logger.Debugw("constructFilterFromContextQuery()")
+func constructFilterFromContextQuery(c *gin.Context, logger *zap.SugaredLogger) (*subs.SubscriptionFilter, error) {
+	logger.Debugw("constructFilterFromContextQuery()")
 
 	var filter subs.SubscriptionFilter
 
-	if sort := c.Query("sort"); sort != "" {
-		filter.Sort = &sort
+	sort, err := subs.ParseSort(c.Query("sort"))
+	if err != nil {
+		logger.Errorw("Failed to parse sort", "error", err)
+
+		var sortErr *subs.SortFieldError
+		if errors.As(err, &sortErr) {
+			return nil, newFieldError("sort", CodeInvalidParam, sortErr.Err, sortErr.Field)
+		}
+		return nil, newFieldError("sort", CodeInvalidParam, err)
 	}
+	filter.Sort = sort
 
 	if startDateStr := c.Query("startDate"); startDateStr != "" {
-		startDate, err := time.Parse(subs.TimeParseFormat, startDateStr)
+		startDate, err := subs.ParseDate(startDateStr)
 		if err != nil {
-			h.logger.Errorw(ErrDateFormat.Error(), "error", err)
+			logger.Errorw(ErrDateFormat.Error(), "error", err)
 
-			return nil, ErrDateFormat
+			return nil, newFieldError("startDate", CodeInvalidDateFormat, ErrDateFormat)
 		}
 
 		filter.StartDate = &startDate
 	}
 
 	if endDateStr := c.Query("endDate"); endDateStr != "" {
-		endDate, err := time.Parse(subs.TimeParseFormat, endDateStr)
+		endDate, err := subs.ParseEndDate(endDateStr)
 		if err != nil {
-			h.logger.Errorw(ErrDateFormat.Error(), "error", err)
+			logger.Errorw(ErrDateFormat.Error(), "error", err)
 
-			return nil, ErrDateFormat
+			return nil, newFieldError("endDate", CodeInvalidDateFormat, ErrDateFormat)
 		}
 
 		filter.EndDate = &endDate
@@ -483,24 +692,231 @@ func (h *SubsHandler) constructFilterFromContextQuery(c *gin.Context) (*subs.Sub
 	if userIDStr := c.Query("userID"); userIDStr != "" {
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			h.logger.Errorw("Failed to parse user ID", "error", err)
+			logger.Errorw("Failed to parse user ID", "error", err)
 
-			return nil, err
+			return nil, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam)
 		}
 
 		filter.UserID = &userID
 	}
 
-	if costStr := c.Query("price"); costStr != "" {
-		cost64, err := strconv.ParseInt(costStr, 10, 32)
-		if err != nil {
-			h.logger.Errorw("Failed to parse price", "error", err)
+	if filter.Cost, err = parseCostParam(c, logger, "price"); err != nil {
+		return nil, err
+	}
 
-			return nil, ErrInvalidParam
+	if err = fillRangeFilter(c, logger, &filter); err != nil {
+		return nil, err
+	}
+
+	if err = fillListFilter(c, logger, &filter); err != nil {
+		return nil, err
+	}
+
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		if len([]rune(search)) > maxSearchLength {
+			logger.Errorw("Search query is too long", "length", len(search))
+
+			return nil, newFieldError("search", CodeOutOfRange, ErrInvalidParam)
 		}
-		cost := int32(cost64)
-		filter.Cost = &cost
+		filter.Search = &search
+	}
+
+	if filter.ActiveOnly, err = parseBoolParam(c, logger, "activeOnly"); err != nil {
+		return nil, err
+	}
+	if filter.EndedOnly, err = parseBoolParam(c, logger, "endedOnly"); err != nil {
+		return nil, err
+	}
+	if filter.ActiveOnly && filter.EndedOnly {
+		logger.Errorw("Conflicting status flags", "error", ErrStatusFlags)
+
+		return nil, newFieldError("endedOnly", CodeInvalidParam, ErrStatusFlags)
 	}
 
 	return &filter, nil
 }
+
+func fillRangeFilter(c *gin.Context, logger *zap.SugaredLogger, filter *subs.SubscriptionFilter) error {
+	var err error
+	if filter.CostMin, err = parseCostParam(c, logger, "priceMin"); err != nil {
+		return err
+	}
+	if filter.CostMax, err = parseCostParam(c, logger, "priceMax"); err != nil {
+		return err
+	}
+	if filter.CostMin != nil && filter.CostMax != nil && *filter.CostMin > *filter.CostMax {
+		logger.Errorw("Invalid price range", "error", ErrPriceRange)
+
+		return newFieldError("priceMax", CodeOutOfRange, ErrPriceRange)
+	}
+
+	if filter.EndDateFrom, err = parseDateParam(c, logger, "endDateFrom", subs.ParseDate); err != nil {
+		return err
+	}
+	if filter.EndDateTo, err = parseDateParam(c, logger, "endDateTo", subs.ParseEndDate); err != nil {
+		return err
+	}
+	if filter.EndDateFrom != nil && filter.EndDateTo != nil && filter.EndDateFrom.After(*filter.EndDateTo) {
+		logger.Errorw("Invalid end date range", "error", ErrEndDateRange)
+
+		return newFieldError("endDateTo", CodeInvalidPeriod, ErrEndDateRange)
+	}
+
+	return nil
+}
+
+func fillListFilter(c *gin.Context, logger *zap.SugaredLogger, filter *subs.SubscriptionFilter) error {
+	services := queryList(c, "services")
+	if len(services) > maxFilterListSize {
+		logger.Errorw("Too many services in filter", "count", len(services))
+
+		return newFieldError("services", CodeOutOfRange, ErrTooManyValues, maxFilterListSize)
+	}
+	filter.Services = services
+
+	serviceIDs := queryList(c, "serviceIDs")
+	if len(serviceIDs) > maxFilterListSize {
+		logger.Errorw("Too many service IDs in filter", "count", len(serviceIDs))
+
+		return newFieldError("serviceIDs", CodeOutOfRange, ErrTooManyValues, maxFilterListSize)
+	}
+	filter.ServiceIDs = serviceIDs
+
+	if categoryID := c.Query("categoryID"); categoryID != "" {
+		filter.CategoryID = &categoryID
+	}
+
+	tags := queryList(c, "tags")
+	if len(tags) > maxFilterListSize {
+		logger.Errorw("Too many tags in filter", "count", len(tags))
+
+		return newFieldError("tags", CodeOutOfRange, ErrTooManyValues, maxFilterListSize)
+	}
+	for _, tag := range tags {
+		filter.Tags = append(filter.Tags, subs.NormalizeTag(tag))
+	}
+
+	for _, value := range queryList(c, "status") {
+		status, err := subs.ParseStatus(value)
+		if err != nil {
+			logger.Errorw("Unknown status in filter", "status", value)
+
+			return newFieldError("status", CodeInvalidParam, subs.ErrUnknownStatus, value)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	userIDs := queryList(c, "userIDs")
+	if len(userIDs) > maxFilterListSize {
+		logger.Errorw("Too many user IDs in filter", "count", len(userIDs))
+
+		return newFieldError("userIDs", CodeOutOfRange, ErrTooManyValues, maxFilterListSize)
+	}
+	for _, userIDStr := range userIDs {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			logger.Errorw("Failed to parse user ID", "error", err)
+
+			return newFieldError("userIDs", CodeInvalidUUID, ErrInvalidParam)
+		}
+		filter.UserIDs = append(filter.UserIDs, userID)
+	}
+
+	return nil
+}
+
+func parseCostParam(c *gin.Context, logger *zap.SugaredLogger, name string) (*int32, error) {
+	costStr := c.Query(name)
+	if costStr == "" {
+		return nil, nil
+	}
+
+	cost64, err := strconv.ParseInt(costStr, 10, 32)
+	if err != nil {
+		logger.Errorw("Failed to parse "+name, "error", err)
+
+		return nil, newFieldError(name, CodeInvalidNumber, ErrInvalidParam)
+	}
+	cost := int32(cost64)
+
+	return &cost, nil
+}
+
+// parseDateParam разбирает дату функцией parse: subs.ParseDate для начала промежутка, subs.ParseEndDate для конца
+func parseDateParam(c *gin.Context, logger *zap.SugaredLogger, name string, parse func(string) (time.Time, error)) (*time.Time, error) {
+	dateStr := c.Query(name)
+	if dateStr == "" {
+		return nil, nil
+	}
+
+	date, err := parse(dateStr)
+	if err != nil {
+		logger.Errorw(ErrDateFormat.Error(), "error", err)
+
+		return nil, newFieldError(name, CodeInvalidDateFormat, ErrDateFormat)
+	}
+
+	return &date, nil
+}
+
+// prorationParam - политика из параметра proration, без него - fallback
+func prorationParam(c *gin.Context, logger *zap.SugaredLogger, fallback subs.Proration) (subs.Proration, error) {
+	value := c.Query("proration")
+	if value == "" {
+		return fallback, nil
+	}
+
+	proration, err := subs.ParseProration(value)
+	if err != nil {
+		logger.Errorw("Invalid proration", "error", err, "value", value)
+
+		return "", newFieldError("proration", CodeInvalidParam, err, value)
+	}
+
+	return proration, nil
+}
+
+func parseBoolParam(c *gin.Context, logger *zap.SugaredLogger, name string) (bool, error) {
+	valueStr := c.Query(name)
+	if valueStr == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		logger.Errorw("Failed to parse "+name, "error", err)
+
+		return false, newFieldError(name, CodeInvalidParam, ErrInvalidParam)
+	}
+
+	return value, nil
+}
+
+// queryList принимает и повторяющийся параметр (?services=a&services=b), и список через запятую (?services=a,b)
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(name) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+func requiredParamsError(missing map[string]bool) error {
+	validationErr := &ValidationError{}
+	for _, field := range []string{"service", "userID", "startDate", "endDate"} {
+		if missing[field] {
+			validationErr.Fields = append(validationErr.Fields, FieldError{
+				Field:   field,
+				Code:    CodeRequired,
+				Message: ErrRequiredParam.Error(),
+			})
+		}
+	}
+
+	return validationErr
+}
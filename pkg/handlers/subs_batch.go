@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"online-subs/pkg/i18n"
+	"online-subs/pkg/subs"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	batchStatusCreated = "created"
+	batchStatusUpdated = "updated"
+	batchStatusDeleted = "deleted"
+	batchStatusFailed  = "failed"
+	batchStatusSkipped = "skipped"
+)
+
+var (
+	ErrBatchMode = errors.New("invalid batch mode, expected atomic or best_effort")
+	ErrBatchSize = errors.New("invalid batch size")
+)
+
+type batchCreateRequest struct {
+	Mode  subs.BatchMode `json:"mode" example:"atomic"`
+	Items []basicRequest `json:"items"`
+}
+
+type batchUpdateItem struct {
+	ID string `json:"id"`
+	basicRequest
+}
+
+type batchUpdateRequest struct {
+	Mode  subs.BatchMode    `json:"mode" example:"best_effort"`
+	Items []batchUpdateItem `json:"items"`
+}
+
+type batchDeleteRequest struct {
+	Mode subs.BatchMode `json:"mode" example:"atomic"`
+	IDs  []string       `json:"ids"`
+}
+
+type BatchItemError struct {
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+type BatchItemResult struct {
+	Index  int             `json:"index"`
+	Status string          `json:"status"`
+	ID     string          `json:"id,omitempty"`
+	Error  *BatchItemError `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Message   string            `json:"message"`
+	Mode      subs.BatchMode    `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// CreateBatch godoc
+// @Summary Create subscriptions in batch
+// @Description mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.
+// @Description Responds 200 when every item succeeded and 207 otherwise, see per-item results.
+// @Description overlapPolicy works as in /create and also compares items with each other, reject fails the overlapping items.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body batchCreateRequest true "Batch payload"
+// @Param overlapPolicy query string false "allow, warn or reject"
+// @Success 200 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/batch/create [post]
+func (h *SubsHandler) CreateBatch(c *gin.Context) {
+	h.logger.Debugw("handling CreateBatch()")
+
+	policy, err := overlapPolicyParam(c, h.logger, h.overlapPolicy)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	var request batchCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	mode, err := validateBatch(request.Mode, len(request.Items), "items")
+	if err != nil {
+		h.logger.Errorw("Invalid batch", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	newSubs := make([]*subs.Subscription, len(request.Items))
+	itemErrs := make([]error, len(request.Items))
+	for i := range request.Items {
+		newSubs[i], itemErrs[i] = h.buildSubscription(&request.Items[i])
+	}
+
+	h.processBatch(c, mode, batchStatusCreated, itemErrs, func(valid []int) ([]*subs.BatchResult, error) {
+		batch := make([]*subs.Subscription, 0, len(valid))
+		for _, i := range valid {
+			batch = append(batch, newSubs[i])
+		}
+
+		return h.overlapsChecker.CreateBatch(batch, mode, policy)
+	})
+}
+
+// UpdateBatch godoc
+// @Summary Update subscriptions in batch
+// @Description mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.
+// @Description Responds 200 when every item succeeded and 207 otherwise, see per-item results.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body batchUpdateRequest true "Batch payload"
+// @Success 200 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/batch/update [patch]
+func (h *SubsHandler) UpdateBatch(c *gin.Context) {
+	h.logger.Debugw("handling UpdateBatch()")
+
+	var request batchUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	mode, err := validateBatch(request.Mode, len(request.Items), "items")
+	if err != nil {
+		h.logger.Errorw("Invalid batch", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	updates := make([]*subs.BatchUpdate, len(request.Items))
+	itemErrs := make([]error, len(request.Items))
+	for i := range request.Items {
+		item := &request.Items[i]
+		if item.ID == "" {
+			itemErrs[i] = newFieldError("id", CodeRequired, ErrRequiredParam)
+			continue
+		}
+
+		var subUpdates *subs.Subscription
+		if subUpdates, itemErrs[i] = h.buildSubscription(&item.basicRequest); itemErrs[i] == nil {
+			updates[i] = &subs.BatchUpdate{ID: item.ID, Subscription: subUpdates}
+		}
+	}
+
+	h.processBatch(c, mode, batchStatusUpdated, itemErrs, func(valid []int) ([]*subs.BatchResult, error) {
+		batch := make([]*subs.BatchUpdate, 0, len(valid))
+		for _, i := range valid {
+			batch = append(batch, updates[i])
+		}
+
+		return h.subsRepo.UpdateBatch(batch, mode)
+	})
+}
+
+// DeleteBatch godoc
+// @Summary Delete subscriptions in batch
+// @Description mode=atomic (default) applies all items in one transaction, mode=best_effort applies each item independently.
+// @Description Responds 200 when every item succeeded and 207 otherwise, see per-item results.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body batchDeleteRequest true "Batch payload"
+// @Success 200 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/batch/delete [post]
+func (h *SubsHandler) DeleteBatch(c *gin.Context) {
+	h.logger.Debugw("handling DeleteBatch()")
+
+	var request batchDeleteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorw("Failed to bind JSON", "error", err)
+
+		respondProblem(c, bindingError(err))
+		return
+	}
+
+	mode, err := validateBatch(request.Mode, len(request.IDs), "ids")
+	if err != nil {
+		h.logger.Errorw("Invalid batch", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	itemErrs := make([]error, len(request.IDs))
+	for i, id := range request.IDs {
+		if id == "" {
+			itemErrs[i] = newFieldError("id", CodeRequired, ErrRequiredParam)
+		}
+	}
+
+	h.processBatch(c, mode, batchStatusDeleted, itemErrs, func(valid []int) ([]*subs.BatchResult, error) {
+		batch := make([]string, 0, len(valid))
+		for _, i := range valid {
+			batch = append(batch, request.IDs[i])
+		}
+
+		return h.subsRepo.DeleteBatch(batch, mode)
+	})
+}
+
+func validateBatch(mode subs.BatchMode, size int, field string) (subs.BatchMode, error) {
+	switch mode {
+	case "":
+		mode = subs.BatchModeAtomic
+	case subs.BatchModeAtomic, subs.BatchModeBestEffort:
+	default:
+		return "", newFieldError("mode", CodeInvalidParam, ErrBatchMode)
+	}
+
+	if size == 0 || size > subs.MaxBatchSize {
+		return "", newFieldError(field, CodeOutOfRange, ErrBatchSize, subs.MaxBatchSize)
+	}
+
+	return mode, nil
+}
+
+// processBatch отправляет в репозиторий только прошедшие валидацию элементы и собирает ответ по исходным индексам
+func (h *SubsHandler) processBatch(c *gin.Context, mode subs.BatchMode, okStatus string, itemErrs []error,
+	run func(valid []int) ([]*subs.BatchResult, error)) {
+	localizer := i18n.FromContext(c)
+	results := make([]BatchItemResult, len(itemErrs))
+
+	valid := make([]int, 0, len(itemErrs))
+	for i, err := range itemErrs {
+		results[i].Index = i
+		if err != nil {
+			results[i].Status = batchStatusFailed
+			results[i].Error = newBatchItemError(err, localizer)
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	switch {
+	case mode == subs.BatchModeAtomic && len(valid) != len(itemErrs):
+		// В атомарном режиме невалидный элемент отменяет весь батч ещё до похода в базу
+		for _, i := range valid {
+			results[i].Status = batchStatusSkipped
+		}
+
+	case len(valid) > 0:
+		repoResults, err := run(valid)
+		if err != nil {
+			h.logger.Errorw("Failed to process batch", "error", err)
+
+			respondProblem(c, err)
+			return
+		}
+
+		for j, i := range valid {
+			results[i].ID = repoResults[j].ID
+
+			switch {
+			case repoResults[j].Err == nil:
+				results[i].Status = okStatus
+			case errors.Is(repoResults[j].Err, subs.ErrSkipped):
+				results[i].Status = batchStatusSkipped
+			default:
+				results[i].Status = batchStatusFailed
+				results[i].Error = newBatchItemError(repoResults[j].Err, localizer)
+			}
+		}
+	}
+
+	response := BatchResponse{
+		Message: messageSuccess,
+		Mode:    mode,
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Status == okStatus {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	h.logger.Infow("Batch processed", "mode", mode, "succeeded", response.Succeeded, "failed", response.Failed)
+	c.JSON(status, response)
+}
+
+func newBatchItemError(err error, localizer *i18n.Localizer) *BatchItemError {
+	problem := problemFromError(err)
+	localizeProblem(problem, localizer)
+
+	return &BatchItemError{
+		Code:    problem.Code,
+		Message: problem.Detail,
+		Errors:  problem.Errors,
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"online-subs/pkg/subs"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	fieldID             = "id"
+	fieldServiceName    = "service_name"
+	fieldServiceID      = "service_id"
+	fieldCategoryID     = "category_id"
+	fieldTags           = "tags"
+	fieldPrice          = "price"
+	fieldUserID         = "user_id"
+	fieldStartDate      = "start_date"
+	fieldEndDate        = "end_date"
+	fieldBillingDay     = "billing_day"
+	fieldTrialStartDate = "trial_start_date"
+	fieldTrialEndDate   = "trial_end_date"
+	fieldPauses         = "pauses"
+	fieldStatus         = "status"
+	fieldCancelledAt    = "cancelled_at"
+	fieldMonthsActive   = "months_active"
+	fieldTotalSpent     = "total_spent"
+	fieldNextChargeDate = "next_charge_date"
+)
+
+var (
+	ErrUnknownField  = errors.New("unknown response field")
+	ErrUnknownExpand = errors.New("unknown expand value")
+)
+
+var (
+	baseFields = []string{fieldID, fieldServiceName, fieldServiceID, fieldPrice, fieldUserID, fieldStartDate, fieldEndDate,
+		fieldBillingDay, fieldCategoryID, fieldTags, fieldTrialStartDate, fieldTrialEndDate,
+		fieldPauses, fieldStatus, fieldCancelledAt}
+	computedFields = []string{fieldMonthsActive, fieldTotalSpent, fieldNextChargeDate}
+)
+
+// SubscriptionDTO - представление подписки в ответах API. Даты в формате YYYY-MM-DD, end_date - последний день подписки.
+// Вычисляемые поля заполняются только при expand или при явном упоминании в fields
+type SubscriptionDTO struct {
+	ID             string      `json:"id"`
+	ServiceName    string      `json:"service_name" example:"Yandex Plus"`
+	ServiceID      *string     `json:"service_id"`
+	Price          int32       `json:"price" example:"400"`
+	UserID         string      `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate      string      `json:"start_date" example:"2025-07-15"`
+	EndDate        *string     `json:"end_date" example:"2025-12-31"`
+	BillingDay     int         `json:"billing_day" example:"15"`
+	CategoryID     *string     `json:"category_id"`
+	Tags           []string    `json:"tags"`
+	TrialStartDate *string     `json:"trial_start_date" example:"2025-07-15"`
+	TrialEndDate   *string     `json:"trial_end_date" example:"2025-08-14"`
+	Pauses         []*PauseDTO `json:"pauses"`
+	// Status - состояние на текущий день
+	Status         string     `json:"status" example:"active"`
+	CancelledAt    *time.Time `json:"cancelled_at"`
+	MonthsActive   *int       `json:"months_active,omitempty"`
+	TotalSpent     *int64     `json:"total_spent,omitempty"`
+	NextChargeDate *string    `json:"next_charge_date,omitempty" example:"2025-08-15"`
+
+	fields []string
+}
+
+type subscriptionDTOAlias SubscriptionDTO
+
+// MarshalJSON выводит ключи в порядке fields (или базовые поля и expand), запрошенное, но пустое вычисляемое поле отдаётся как null
+func (dto *SubscriptionDTO) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal((*subscriptionDTOAlias)(dto))
+	if err != nil || dto.fields == nil {
+		return raw, err
+	}
+
+	var values map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range dto.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(field)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		if value, ok := values[field]; ok {
+			buf.Write(value)
+		} else {
+			buf.WriteString("null")
+		}
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// viewOptions - разобранные параметры fields и expand
+type viewOptions struct {
+	fields  []string
+	expand  []string
+	visible []string
+	now     time.Time
+}
+
+func parseViewOptions(c *gin.Context) (*viewOptions, error) {
+	view := &viewOptions{now: subs.Today()}
+
+	for _, field := range queryList(c, "fields") {
+		if !slices.Contains(baseFields, field) && !slices.Contains(computedFields, field) {
+			return nil, newFieldError("fields", CodeInvalidParam, ErrUnknownField, field)
+		}
+		view.fields = append(view.fields, field)
+	}
+
+	for _, expand := range queryList(c, "expand") {
+		if !slices.Contains(computedFields, expand) {
+			return nil, newFieldError("expand", CodeInvalidParam, ErrUnknownExpand, expand)
+		}
+		view.expand = append(view.expand, expand)
+	}
+
+	view.visible = view.visibleFields()
+
+	return view, nil
+}
+
+// computes сообщает, нужно ли считать вычисляемое поле: оно запрошено через expand или названо в fields
+func (v *viewOptions) computes(field string) bool {
+	return slices.Contains(v.expand, field) || slices.Contains(v.fields, field)
+}
+
+func (v *viewOptions) render(subscription *subs.Subscription) *SubscriptionDTO {
+	dto := &SubscriptionDTO{
+		ID:          subscription.ID,
+		ServiceName: subscription.Service,
+		ServiceID:   subscription.ServiceID,
+		Price:       subscription.Cost,
+		UserID:      subscription.UserID.String(),
+		StartDate:   subscription.StartDate.Format(subs.DateFormat),
+		BillingDay:  subscription.Anchor(),
+		CategoryID:  subscription.CategoryID,
+		Tags:        subscription.Tags,
+		Pauses:      pauseDTOs(subscription.Pauses),
+		Status:      string(subscription.StatusAt(v.now)),
+		CancelledAt: subscription.CancelledAt,
+		fields:      v.visible,
+	}
+
+	if subscription.EndDate != nil {
+		endDate := subscription.EndDate.Format(subs.DateFormat)
+		dto.EndDate = &endDate
+	}
+
+	if subscription.TrialStartDate != nil && subscription.TrialEndDate != nil {
+		trialStart := subscription.TrialStartDate.Format(subs.DateFormat)
+		trialEnd := subscription.TrialEndDate.Format(subs.DateFormat)
+		dto.TrialStartDate, dto.TrialEndDate = &trialStart, &trialEnd
+	}
+
+	if v.computes(fieldMonthsActive) {
+		monthsActive := subscription.MonthsActive(v.now)
+		dto.MonthsActive = &monthsActive
+	}
+
+	if v.computes(fieldTotalSpent) {
+		totalSpent := subscription.TotalPaid(v.now)
+		dto.TotalSpent = &totalSpent
+	}
+
+	if v.computes(fieldNextChargeDate) {
+		if next := subscription.NextChargeDate(v.now); next != nil {
+			nextCharge := next.Format(subs.DateFormat)
+			dto.NextChargeDate = &nextCharge
+		}
+	}
+
+	return dto
+}
+
+// visibleFields - ключи ответа: fields (или все базовые поля) и затем expand. nil - обычная сериализация структуры
+func (v *viewOptions) visibleFields() []string {
+	if v.fields == nil && v.expand == nil {
+		return nil
+	}
+
+	visible := slices.Clip(v.fields)
+	if visible == nil {
+		visible = slices.Clip(baseFields)
+	}
+
+	for _, expand := range v.expand {
+		if !slices.Contains(visible, expand) {
+			visible = append(visible, expand)
+		}
+	}
+
+	return visible
+}
+
+func (v *viewOptions) renderAll(subscriptions []*subs.Subscription) []*SubscriptionDTO {
+	dtos := make([]*SubscriptionDTO, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		dtos = append(dtos, v.render(subscription))
+	}
+
+	return dtos
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"online-subs/pkg/subs"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEndingWithin = 3
+	maxEndingWithin     = 24
+)
+
+type MonthOverMonth struct {
+	PreviousSpend int64 `json:"previous_spend"`
+	CurrentSpend  int64 `json:"current_spend"`
+	Change        int64 `json:"change"`
+	// ChangePercent - null, если в прошлом месяце трат не было
+	ChangePercent *float64 `json:"change_percent"`
+}
+
+type UserSummaryResponse struct {
+	Message        string             `json:"message"`
+	UserID         string             `json:"user_id"`
+	Month          string             `json:"month" example:"10-2026"`
+	ActiveCount    int                `json:"active_count"`
+	MonthlySpend   int64              `json:"monthly_spend"`
+	YearSpend      int64              `json:"year_spend"`
+	MostExpensive  *SubscriptionDTO   `json:"most_expensive"`
+	EndingSoon     []*SubscriptionDTO `json:"ending_soon"`
+	MonthOverMonth MonthOverMonth     `json:"month_over_month"`
+}
+
+// UserSummary godoc
+// @Summary User spending summary
+// @Description Overview for the user's home screen: active subscriptions and spend in the current month, spend since January,
+// @Description the most expensive active subscription, subscriptions ending soon and the change against the previous month.
+// @Tags analytics
+// @Produce json
+// @Param userID path string true "User UUID"
+// @Param endingWithin query int false "Months ahead to look for ending subscriptions, 3 by default, at most 24"
+// @Param proration query string false "Proration policy: none, daily, by_billing_day; the service default if omitted"
+// @Success 200 {object} UserSummaryResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/users/{userID}/summary [get]
+func (h *SubsHandler) UserSummary(c *gin.Context) {
+	h.logger.Debugw("handling UserSummary()")
+
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		h.logger.Errorw("Failed to parse user ID", "error", err)
+
+		respondProblem(c, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam))
+		return
+	}
+
+	endingWithin := defaultEndingWithin
+	if endingWithinStr := c.Query("endingWithin"); endingWithinStr != "" {
+		endingWithin, err = strconv.Atoi(endingWithinStr)
+		if err != nil || endingWithin < 1 || endingWithin > maxEndingWithin {
+			h.logger.Errorw("Invalid endingWithin", "value", endingWithinStr)
+
+			respondProblem(c, newFieldError("endingWithin", CodeOutOfRange, ErrInvalidParam))
+			return
+		}
+	}
+
+	proration, err := prorationParam(c, h.logger, h.proration)
+	if err != nil {
+		respondProblem(c, err)
+		return
+	}
+
+	var subscriptions []*subs.Subscription
+	err = h.subsRepo.Stream(&subs.SubscriptionFilter{UserID: &userID}, func(subscription *subs.Subscription) error {
+		subscriptions = append(subscriptions, subscription)
+		return nil
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to read user subscriptions", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	prices, err := subs.LoadPrices(h.prices, subscriptions)
+	if err != nil {
+		h.logger.Errorw("Failed to load prices", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	summary := subs.Summarize(subscriptions, prices, time.Now().UTC(), endingWithin, proration)
+	view := &viewOptions{now: subs.Today()}
+
+	response := UserSummaryResponse{
+		Message:      messageSuccess,
+		UserID:       userID.String(),
+		Month:        summary.Month.Format(subs.TimeParseFormat),
+		ActiveCount:  summary.ActiveCount,
+		MonthlySpend: summary.MonthlySpend,
+		YearSpend:    summary.YearSpend,
+		EndingSoon:   view.renderAll(summary.EndingSoon),
+		MonthOverMonth: MonthOverMonth{
+			PreviousSpend: summary.PreviousSpend,
+			CurrentSpend:  summary.MonthlySpend,
+			Change:        summary.MonthlySpend - summary.PreviousSpend,
+		},
+	}
+
+	if summary.MostExpensive != nil {
+		response.MostExpensive = view.render(summary.MostExpensive)
+	}
+
+	if summary.PreviousSpend != 0 {
+		percent := float64(response.MonthOverMonth.Change) / float64(summary.PreviousSpend) * 100
+		percent = math.Round(percent*100) / 100
+		response.MonthOverMonth.ChangePercent = &percent
+	}
+
+	h.logger.Infow("Successfully built user summary", "userID", userID, "subscriptions", len(subscriptions))
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagDTO struct {
+	Name          string `json:"name" example:"work"`
+	Subscriptions int64  `json:"subscriptions"`
+}
+
+type TagsResponse struct {
+	Message string    `json:"message"`
+	Tags    []*TagDTO `json:"tags"`
+}
+
+type DeleteTagResponse struct {
+	Message       string `json:"message"`
+	Subscriptions int64  `json:"subscriptions"`
+}
+
+// ListTags godoc
+// @Summary List tags
+// @Description Every tag in use with the number of subscriptions carrying it.
+// @Tags tags
+// @Produce json
+// @Success 200 {object} TagsResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/tags [get]
+func (h *SubsHandler) ListTags(c *gin.Context) {
+	h.logger.Debugw("handling ListTags()")
+
+	usages, err := h.subsRepo.ListTags()
+	if err != nil {
+		h.logger.Errorw("Failed to list tags", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	dtos := make([]*TagDTO, 0, len(usages))
+	for _, usage := range usages {
+		dtos = append(dtos, &TagDTO{Name: usage.Name, Subscriptions: usage.Count})
+	}
+
+	c.JSON(http.StatusOK, TagsResponse{
+		Message: messageSuccess,
+		Tags:    dtos,
+	})
+}
+
+// DeleteTag godoc
+// @Summary Remove tag from all subscriptions
+// @Tags tags
+// @Produce json
+// @Param tag path string true "Tag"
+// @Success 200 {object} DeleteTagResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/tags/{tag} [delete]
+func (h *SubsHandler) DeleteTag(c *gin.Context) {
+	h.logger.Debugw("handling DeleteTag()")
+
+	count, err := h.subsRepo.DeleteTag(c.Param("tag"))
+	if err != nil {
+		h.logger.Errorw("Failed to delete tag", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	h.logger.Infow("Successfully deleted tag", "tag", c.Param("tag"), "subscriptions", count)
+	c.JSON(http.StatusOK, DeleteTagResponse{
+		Message:       messageSuccess,
+		Subscriptions: count,
+	})
+}
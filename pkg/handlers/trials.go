@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"online-subs/pkg/subs"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EndingTrialsResponse struct {
+	Message string `json:"message"`
+	UserID  string `json:"user_id"`
+	// Subscriptions упорядочены по trial_end_date, next_charge_date - первое платное списание
+	Subscriptions []*SubscriptionDTO `json:"subscriptions"`
+}
+
+// EndingTrials godoc
+// @Summary Trials ending soon
+// @Description Subscriptions whose trial ends from today to the end of the within-th month, ordered by trial end, so the user can cancel before the first charge.
+// @Description next_charge_date is the first paid charge.
+// @Tags subscriptions
+// @Produce json
+// @Param userID path string true "User UUID"
+// @Param within query int false "Months ahead including the current one, 3 by default, at most 24"
+// @Success 200 {object} EndingTrialsResponse
+// @Failure 400 {object} ProblemResponse
+// @Failure 500 {object} ProblemResponse
+// @Router /subscriptions/v1/users/{userID}/trials/ending [get]
+func (h *SubsHandler) EndingTrials(c *gin.Context) {
+	h.logger.Debugw("handling EndingTrials()")
+
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		h.logger.Errorw("Failed to parse user ID", "error", err)
+
+		respondProblem(c, newFieldError("userID", CodeInvalidUUID, ErrInvalidParam))
+		return
+	}
+
+	within := defaultEndingWithin
+	if withinStr := c.Query("within"); withinStr != "" {
+		within, err = strconv.Atoi(withinStr)
+		if err != nil || within < 1 || within > maxEndingWithin {
+			h.logger.Errorw("Invalid within", "value", withinStr)
+
+			respondProblem(c, newFieldError("within", CodeOutOfRange, ErrInvalidParam))
+			return
+		}
+	}
+
+	today := subs.Today()
+	last := subs.MonthEnd(today.AddDate(0, within-1, 1-today.Day()))
+
+	subscriptions, err := h.subsRepo.ListEndingTrials(&subs.SubscriptionFilter{
+		UserID:       &userID,
+		TrialEndFrom: &today,
+		TrialEndTo:   &last,
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to list ending trials", "error", err)
+
+		respondProblem(c, err)
+		return
+	}
+
+	view := &viewOptions{now: today, expand: []string{fieldNextChargeDate}}
+
+	h.logger.Infow("Successfully listed ending trials", "userID", userID, "count", len(subscriptions))
+	c.JSON(http.StatusOK, EndingTrialsResponse{
+		Message:       messageSuccess,
+		UserID:        userID.String(),
+		Subscriptions: view.renderAll(subscriptions),
+	})
+}
@@ -0,0 +1,131 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var localesFS embed.FS
+
+const (
+	LangEn = "en"
+	LangRu = "ru"
+
+	DefaultLang = LangEn
+
+	localizerKey = "localizer"
+)
+
+type Bundle struct {
+	catalogs map[string]map[string]string
+	matcher  language.Matcher
+	tags     []language.Tag
+}
+
+func NewBundle() (*Bundle, error) {
+	// Порядок важен: первый язык используется, если ничего из Accept-Language не подошло
+	langs := []string{DefaultLang, LangRu}
+
+	bundle := &Bundle{
+		catalogs: make(map[string]map[string]string, len(langs)),
+	}
+
+	for _, lang := range langs {
+		raw, err := localesFS.ReadFile(path.Join("locales", lang+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("read %s catalog: %w", lang, err)
+		}
+
+		catalog := make(map[string]string)
+		if err = json.Unmarshal(raw, &catalog); err != nil {
+			return nil, fmt.Errorf("parse %s catalog: %w", lang, err)
+		}
+
+		bundle.catalogs[lang] = catalog
+		bundle.tags = append(bundle.tags, language.Make(lang))
+	}
+
+	bundle.matcher = language.NewMatcher(bundle.tags)
+
+	return bundle, nil
+}
+
+func (b *Bundle) Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLang
+	}
+
+	_, index, _ := b.matcher.Match(tags...)
+	base, _ := b.tags[index].Base()
+
+	return base.String()
+}
+
+func (b *Bundle) Localizer(lang string) *Localizer {
+	return &Localizer{
+		bundle: b,
+		lang:   lang,
+	}
+}
+
+type Localizer struct {
+	bundle *Bundle
+	lang   string
+}
+
+func (l *Localizer) Lang() string {
+	if l == nil {
+		return DefaultLang
+	}
+
+	return l.lang
+}
+
+// Message возвращает перевод по ключу, при его отсутствии - перевод на язык по умолчанию, затем fallback
+func (l *Localizer) Message(key, fallback string, args ...any) string {
+	template := fallback
+
+	if l != nil && l.bundle != nil {
+		if msg, ok := l.bundle.catalogs[l.lang][key]; ok {
+			template = msg
+		} else if msg, ok = l.bundle.catalogs[DefaultLang][key]; ok {
+			template = msg
+		}
+	}
+
+	if len(args) == 0 || !strings.Contains(template, "%") {
+		return template
+	}
+
+	return fmt.Sprintf(template, args...)
+}
+
+func Middleware(bundle *Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := bundle.Negotiate(c.GetHeader("Accept-Language"))
+
+		c.Set(localizerKey, bundle.Localizer(lang))
+		c.Header("Content-Language", lang)
+		c.Header("Vary", "Accept-Language")
+
+		c.Next()
+	}
+}
+
+func FromContext(c *gin.Context) *Localizer {
+	if value, ok := c.Get(localizerKey); ok {
+		if localizer, ok := value.(*Localizer); ok {
+			return localizer
+		}
+	}
+
+	return nil
+}
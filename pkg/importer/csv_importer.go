@@ -0,0 +1,445 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CSVImporter struct {
+	subsRepo        subs.SubscriptionsRepo
+	overlapsChecker *overlaps.Checker
+	resolver        subs.ServiceResolver
+	logger          *zap.SugaredLogger
+}
+
+// NewCSVImporter - resolver может быть nil, тогда дубликаты ищутся только по нормализованному названию
+func NewCSVImporter(subsRepo subs.SubscriptionsRepo, overlapsChecker *overlaps.Checker, resolver subs.ServiceResolver,
+	logger *zap.SugaredLogger) *CSVImporter {
+	return &CSVImporter{
+		subsRepo:        subsRepo,
+		overlapsChecker: overlapsChecker,
+		resolver:        resolver,
+		logger:          logger,
+	}
+}
+
+func (imp *CSVImporter) Import(r io.Reader, opts *Options) (*Report, error) {
+	imp.logger.Debugw("import subscriptions from csv", "options", opts)
+
+	bufReader := bufio.NewReader(r)
+
+	delimiter := opts.Delimiter
+	if delimiter == 0 {
+		detected, err := DetectDelimiter(bufReader)
+		if err != nil {
+			imp.logger.Errorw("error detecting delimiter", "error", err)
+			return nil, err
+		}
+		delimiter = detected
+	}
+
+	csvReader := csv.NewReader(bufReader)
+	csvReader.Comma = delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		imp.logger.Errorw("error reading csv header", "error", err)
+		if errors.Is(err, io.EOF) {
+			return nil, ErrEmptyFile
+		}
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCSV, err)
+	}
+
+	columns, err := resolveColumns(header, opts.Mapping)
+	if err != nil {
+		imp.logger.Errorw("error resolving csv columns", "error", err, "header", header)
+		return nil, err
+	}
+
+	report := &Report{
+		DryRun:    opts.DryRun,
+		Delimiter: delimiter,
+	}
+
+	seen := make(map[string]int)
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			imp.logger.Errorw("error reading csv row", "error", err)
+			return nil, fmt.Errorf("%w: %v", ErrMalformedCSV, err)
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		if isBlank(record) {
+			continue
+		}
+
+		if report.Total == MaxRows {
+			imp.logger.Errorw("too many rows in csv", "max", MaxRows)
+			return nil, ErrTooManyRows
+		}
+		report.Total++
+
+		row := parseRow(line, record, columns)
+		report.Rows = append(report.Rows, row)
+
+		if row.Status == RowStatusInvalid {
+			continue
+		}
+
+		key, err := imp.uniqueKey(row.Subscription)
+		if err != nil {
+			imp.logger.Errorw("error resolving service", "error", err, "line", line)
+			return nil, err
+		}
+		if firstLine, ok := seen[key]; ok {
+			row.Status = RowStatusDuplicate
+			row.Errors = append(row.Errors, FieldError{Field: FieldStartDate, Err: ErrDuplicateInFile})
+			imp.logger.Debugw("duplicate row in csv", "line", line, "firstLine", firstLine)
+			continue
+		}
+		seen[key] = line
+	}
+
+	if err = imp.markExisting(report.Rows); err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		if err = imp.createValid(report.Rows, opts.OverlapPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case RowStatusValid:
+			report.Valid++
+		case RowStatusCreated:
+			report.Valid++
+			report.Created++
+		case RowStatusInvalid:
+			report.Invalid++
+		case RowStatusDuplicate:
+			report.Duplicates++
+		case RowStatusFailed:
+			report.Failed++
+		}
+	}
+
+	imp.logger.Infow("csv import finished", "dryRun", report.DryRun, "total", report.Total,
+		"created", report.Created, "invalid", report.Invalid, "duplicates", report.Duplicates, "failed", report.Failed)
+	return report, nil
+}
+
+// markExisting проверяет строки на конфликт с уникальным индексом (lower(service), user_id, start_date).
+// Подписки с теми же пользователем и датой начала читаются пачками, сервис сравнивается по ключу uniqueKey
+func (imp *CSVImporter) markExisting(rows []*RowResult) error {
+	var starts []subs.UserStart
+	for _, row := range rows {
+		if row.Status == RowStatusValid {
+			starts = append(starts, subs.UserStart{UserID: row.Subscription.UserID, StartDate: row.Subscription.StartDate})
+		}
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	existing, err := imp.subsRepo.ListByUserStarts(starts)
+	if err != nil {
+		imp.logger.Errorw("error checking existing subscriptions", "error", err)
+		return err
+	}
+
+	existingIDs := make(map[string]string, len(existing))
+	for _, subscription := range existing {
+		// Сохранённые подписки уже приведены к каталогу
+		existingIDs[existingKey(subscription.Service, subscription)] = subscription.ID
+	}
+
+	for _, row := range rows {
+		if row.Status != RowStatusValid {
+			continue
+		}
+
+		key, err := imp.uniqueKey(row.Subscription)
+		if err != nil {
+			imp.logger.Errorw("error resolving service", "error", err, "line", row.Line)
+			return err
+		}
+
+		if id, ok := existingIDs[key]; ok {
+			row.Status = RowStatusDuplicate
+			row.ID = id
+			row.Errors = append(row.Errors, FieldError{Field: FieldStartDate, Err: subs.ErrAlreadyExists})
+		}
+	}
+
+	return nil
+}
+
+func (imp *CSVImporter) createValid(rows []*RowResult, policy overlaps.Policy) error {
+	pending := make([]*RowResult, 0, len(rows))
+	for _, row := range rows {
+		if row.Status == RowStatusValid {
+			pending = append(pending, row)
+		}
+	}
+
+	for start := 0; start < len(pending); start += subs.MaxBatchSize {
+		chunk := pending[start:min(start+subs.MaxBatchSize, len(pending))]
+
+		batch := make([]*subs.Subscription, len(chunk))
+		for i, row := range chunk {
+			batch[i] = row.Subscription
+		}
+
+		results, err := imp.overlapsChecker.CreateBatch(batch, subs.BatchModeBestEffort, policy)
+		if err != nil {
+			imp.logger.Errorw("error creating imported subscriptions", "error", err)
+			return err
+		}
+
+		for i, result := range results {
+			row := chunk[i]
+			switch {
+			case result.Err == nil:
+				row.Status = RowStatusCreated
+				row.ID = result.ID
+			case errors.Is(result.Err, subs.ErrAlreadyExists):
+				// Строку могли вставить между проверкой и записью
+				row.Status = RowStatusDuplicate
+				row.Errors = append(row.Errors, FieldError{Field: FieldStartDate, Err: subs.ErrAlreadyExists})
+			default:
+				row.Status = RowStatusFailed
+				row.Errors = append(row.Errors, FieldError{Err: result.Err})
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseRow(line int, record []string, columns map[string]int) *RowResult {
+	row := &RowResult{
+		Line:   line,
+		Status: RowStatusValid,
+	}
+
+	value := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	fail := func(field string, err error) {
+		row.Errors = append(row.Errors, FieldError{Field: field, Err: err})
+	}
+
+	sub := &subs.Subscription{}
+
+	if sub.Service = value(FieldService); sub.Service == "" {
+		fail(FieldService, ErrEmptyService)
+	}
+
+	if cost, err := strconv.ParseInt(value(FieldPrice), 10, 32); err != nil || cost < 0 {
+		fail(FieldPrice, ErrInvalidPrice)
+	} else {
+		sub.Cost = int32(cost)
+	}
+
+	if userID, err := uuid.Parse(value(FieldUserID)); err != nil {
+		fail(FieldUserID, ErrInvalidUserID)
+	} else {
+		sub.UserID = userID
+	}
+
+	if startDate, err := subs.ParseDate(value(FieldStartDate)); err != nil {
+		fail(FieldStartDate, ErrInvalidDate)
+	} else {
+		sub.StartDate = startDate
+	}
+
+	if endDateStr := value(FieldEndDate); endDateStr != "" {
+		if endDate, err := subs.ParseEndDate(endDateStr); err != nil {
+			fail(FieldEndDate, ErrInvalidDate)
+		} else if endDate.Before(sub.StartDate) {
+			fail(FieldEndDate, ErrEndBeforeStart)
+		} else {
+			sub.EndDate = &endDate
+		}
+	}
+
+	if len(row.Errors) > 0 {
+		row.Status = RowStatusInvalid
+		return row
+	}
+
+	row.Subscription = sub
+	return row
+}
+
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		normalized := normalizeColumnName(name)
+		if _, ok := positions[normalized]; !ok {
+			positions[normalized] = i
+		}
+	}
+
+	columns := make(map[string]int, len(defaultColumnNames))
+	for field, aliases := range defaultColumnNames {
+		if custom, ok := mapping[field]; ok {
+			aliases = []string{custom}
+		}
+
+		for _, alias := range aliases {
+			if index, ok := positions[normalizeColumnName(alias)]; ok {
+				columns[field] = index
+				break
+			}
+		}
+	}
+
+	for field := range mapping {
+		if _, ok := defaultColumnNames[field]; !ok {
+			return nil, &ColumnError{Column: field, Err: ErrUnknownField}
+		}
+	}
+
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			return nil, &ColumnError{Column: field, Err: ErrMissingColumn}
+		}
+	}
+
+	return columns, nil
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// DetectDelimiter выбирает самый частый из кандидатов в первой строке, кавычки учитываются
+func DetectDelimiter(r *bufio.Reader) (rune, error) {
+	firstLine, err := r.Peek(r.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+
+	if len(firstLine) == 0 {
+		return 0, ErrEmptyFile
+	}
+
+	counts := make(map[rune]int, len(delimiterCandidates))
+	inQuotes := false
+	for _, ch := range string(firstLine) {
+		if ch == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if inQuotes {
+			continue
+		}
+		if ch == '\n' {
+			break
+		}
+		counts[ch]++
+	}
+
+	best := delimiterCandidates[0]
+	for _, candidate := range delimiterCandidates[1:] {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+
+	return best, nil
+}
+
+func ParseMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" || strings.TrimSpace(column) == "" {
+			return nil, &ColumnError{Column: pair, Err: ErrInvalidMapping}
+		}
+		if _, known := defaultColumnNames[field]; !known {
+			return nil, &ColumnError{Column: field, Err: ErrUnknownField}
+		}
+
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+func ParseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\n' || runes[0] == '\r' {
+		return 0, ErrInvalidDelimiter
+	}
+
+	return runes[0], nil
+}
+
+// uniqueKey - ключ строки по уникальному индексу: сервис приводится к каталогу так же, как это сделает Create,
+// и сравнивается, как в индексе, только без учёта регистра
+func (imp *CSVImporter) uniqueKey(sub *subs.Subscription) (string, error) {
+	service := sub.Service
+	if imp.resolver != nil {
+		resolved, err := imp.resolver.Resolve(service)
+		if err != nil {
+			return "", err
+		}
+		service = resolved.Name
+	}
+
+	return existingKey(service, sub), nil
+}
+
+// existingKey повторяет выражение уникального индекса lower(service), user_id, start_date
+func existingKey(service string, sub *subs.Subscription) string {
+	return strings.ToLower(service) + "|" + sub.UserID.String() + "|" + sub.StartDate.Format(subs.DateFormat)
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
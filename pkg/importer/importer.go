@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"errors"
+
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+)
+
+const (
+	FieldService   = "service_name"
+	FieldPrice     = "price"
+	FieldUserID    = "user_id"
+	FieldStartDate = "start_date"
+	FieldEndDate   = "end_date"
+
+	MaxRows = 10000
+)
+
+type RowStatus string
+
+const (
+	RowStatusValid     RowStatus = "valid"
+	RowStatusCreated   RowStatus = "created"
+	RowStatusInvalid   RowStatus = "invalid"
+	RowStatusDuplicate RowStatus = "duplicate"
+	RowStatusFailed    RowStatus = "failed"
+)
+
+type Options struct {
+	// Delimiter равный 0 означает автоопределение по строке заголовка
+	Delimiter rune
+	// Mapping - поле подписки -> название колонки в файле, не указанные поля ищутся по стандартным названиям
+	Mapping map[string]string
+	DryRun  bool
+	// OverlapPolicy - политика для строк, пересекающихся с подписками пользователя на тот же сервис, пустая означает PolicyWarn
+	OverlapPolicy overlaps.Policy
+}
+
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+type RowResult struct {
+	Line         int
+	Status       RowStatus
+	ID           string
+	Subscription *subs.Subscription
+	Errors       []FieldError
+}
+
+type Report struct {
+	DryRun     bool
+	Delimiter  rune
+	Total      int
+	Valid      int
+	Invalid    int
+	Duplicates int
+	Created    int
+	Failed     int
+	Rows       []*RowResult
+}
+
+var (
+	ErrEmptyFile        = errors.New("file is empty")
+	ErrMissingColumn    = errors.New("required column is missing")
+	ErrUnknownField     = errors.New("unknown subscription field in column mapping")
+	ErrInvalidMapping   = errors.New("column mapping must look like field:column,field:column")
+	ErrInvalidDelimiter = errors.New("delimiter must be a single character")
+	ErrTooManyRows      = errors.New("too many rows in file")
+	ErrMalformedCSV     = errors.New("malformed csv")
+	ErrEmptyService     = errors.New("service name is empty")
+	ErrInvalidPrice     = errors.New("price must be a non-negative integer")
+	ErrInvalidUserID    = errors.New("user id must be a valid UUID")
+	ErrInvalidDate      = errors.New("invalid date, expected MM-YYYY or YYYY-MM-DD")
+	ErrEndBeforeStart   = errors.New("end date is before start date")
+	ErrDuplicateInFile  = errors.New("duplicate of another row in the file")
+)
+
+type ColumnError struct {
+	Column string
+	Err    error
+}
+
+func (e *ColumnError) Error() string {
+	return e.Err.Error() + ": " + e.Column
+}
+
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
+
+// Стандартные названия колонок, сравниваются без учёта регистра и пробелов по краям
+var defaultColumnNames = map[string][]string{
+	FieldService:   {"service_name", "service", "сервис"},
+	FieldPrice:     {"price", "cost", "цена", "стоимость"},
+	FieldUserID:    {"user_id", "userid", "user"},
+	FieldStartDate: {"start_date", "startdate", "start", "дата начала"},
+	FieldEndDate:   {"end_date", "enddate", "end", "дата окончания"},
+}
+
+var requiredFields = []string{FieldService, FieldPrice, FieldUserID, FieldStartDate}
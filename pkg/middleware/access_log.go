@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redactedSegment = "***"
+
+// AccessLog - журнал запросов в формате gin.Logger, в котором параметры пути маршрутов routes заменены на ***.
+// Маршруты задаются как в gin, например /calendar/:token/feed.ics, так токен из ссылки не попадает в логи
+func AccessLog(routes ...string) gin.HandlerFunc {
+	patterns := make([]*regexp.Regexp, 0, len(routes))
+	for _, route := range routes {
+		patterns = append(patterns, routePattern(route))
+	}
+
+	return gin.LoggerWithFormatter(func(params gin.LogFormatterParams) string {
+		params.Path = redactPath(params.Path, patterns)
+
+		var statusColor, methodColor, resetColor string
+		if params.IsOutputColor() {
+			statusColor = params.StatusCodeColor()
+			methodColor = params.MethodColor()
+			resetColor = params.ResetColor()
+		}
+
+		if params.Latency > time.Minute {
+			params.Latency = params.Latency.Truncate(time.Second)
+		}
+
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, params.StatusCode, resetColor,
+			params.Latency,
+			params.ClientIP,
+			methodColor, params.Method, resetColor,
+			params.Path,
+			params.ErrorMessage,
+		)
+	})
+}
+
+// routePattern превращает маршрут gin в выражение, где каждый :param - группа для замены
+func routePattern(route string) *regexp.Regexp {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "([^/?]+)"
+		} else {
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+
+	return regexp.MustCompile("^" + strings.Join(segments, "/") + `(\?.*)?$`)
+}
+
+// redactPath заменяет параметры пути первого подходящего маршрута, строка запроса остаётся
+func redactPath(path string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatchIndex(path)
+		if match == nil {
+			continue
+		}
+
+		// Последняя группа - строка запроса, остальные - параметры пути
+		var redacted strings.Builder
+		last := 0
+		for group := 1; group < len(match)/2-1; group++ {
+			start, end := match[2*group], match[2*group+1]
+			redacted.WriteString(path[last:start])
+			redacted.WriteString(redactedSegment)
+			last = end
+		}
+		redacted.WriteString(path[last:])
+
+		return redacted.String()
+	}
+
+	return path
+}
@@ -0,0 +1,187 @@
+package overlaps
+
+import (
+	"errors"
+	"online-subs/pkg/subs"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Checker struct {
+	subsRepo subs.SubscriptionsRepo
+	resolver subs.ServiceResolver
+	logger   *zap.SugaredLogger
+}
+
+// NewChecker - resolver может быть nil, тогда сервисы сравниваются только по нормализованному названию
+func NewChecker(subsRepo subs.SubscriptionsRepo, resolver subs.ServiceResolver, logger *zap.SugaredLogger) *Checker {
+	return &Checker{
+		subsRepo: subsRepo,
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// Find - подписки пользователя на тот же сервис, пересекающиеся по сроку с ещё не сохранённой подпиской
+func (ch *Checker) Find(subscription *subs.Subscription) ([]*subs.Subscription, error) {
+	ch.logger.Debugw("find overlapping subscriptions", "service", subscription.Service, "userID", subscription.UserID)
+
+	probe, err := ch.probe(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ch.userSubscriptions(probe.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return overlapping(existing, probe), nil
+}
+
+// Create сохраняет подписку по политике policy: при PolicyReject пересечение возвращает ErrOverlap,
+// при PolicyWarn подписка сохраняется, а найденные пересечения возвращаются
+func (ch *Checker) Create(subscription *subs.Subscription, policy Policy) (string, []*subs.Subscription, error) {
+	return ch.CreateWith(subscription, policy, nil)
+}
+
+// CreateWith - Create, выполняющий followUp в транзакции создания подписки. Проверка и вставка идут
+// под блокировкой пользователя, так что параллельные создания не обходят политику
+func (ch *Checker) CreateWith(subscription *subs.Subscription, policy Policy,
+	followUp subs.CreateFollowUp) (string, []*subs.Subscription, error) {
+	ch.logger.Debugw("create subscription with overlap policy", "service", subscription.Service, "policy", policy)
+
+	if policy == PolicyAllow && followUp == nil {
+		id, err := ch.subsRepo.Create(subscription)
+		return id, nil, err
+	}
+
+	var found []*subs.Subscription
+	id, err := ch.subsRepo.CreateChecked(subscription, func(subscription *subs.Subscription, existing []*subs.Subscription) error {
+		found = ch.check(subscription, existing, policy)
+		if len(found) > 0 && policy == PolicyReject {
+			return ErrOverlap
+		}
+		return nil
+	}, followUp)
+	if err != nil {
+		if errors.Is(err, ErrOverlap) {
+			return "", found, err
+		}
+		return "", nil, err
+	}
+
+	return id, found, nil
+}
+
+// CreateBatch сохраняет пачку по политике policy. Подписки сверяются с сохранёнными и с предыдущими элементами пачки.
+// При PolicyReject пересекающиеся элементы получают ErrOverlap, в атомарном режиме остальные тогда получают subs.ErrSkipped
+func (ch *Checker) CreateBatch(subscriptions []*subs.Subscription, mode subs.BatchMode, policy Policy) ([]*subs.BatchResult, error) {
+	ch.logger.Debugw("create subscriptions batch with overlap policy", "count", len(subscriptions), "policy", policy)
+
+	if policy == PolicyAllow {
+		return ch.subsRepo.CreateBatch(subscriptions, mode)
+	}
+
+	return ch.subsRepo.CreateBatchChecked(subscriptions, mode, func(subscription *subs.Subscription, existing []*subs.Subscription) error {
+		if found := ch.check(subscription, existing, policy); len(found) > 0 && policy == PolicyReject {
+			return ErrOverlap
+		}
+		return nil
+	})
+}
+
+// check - пересечения подписки, уже приведённой к каталогу, с сохранёнными подписками пользователя existing
+func (ch *Checker) check(subscription *subs.Subscription, existing []*subs.Subscription, policy Policy) []*subs.Subscription {
+	if policy == PolicyAllow {
+		return nil
+	}
+
+	found := overlapping(existing, subscription)
+	if len(found) > 0 {
+		ch.logger.Warnw("subscription overlaps existing ones", "service", subscription.Service, "overlaps", len(found),
+			"policy", policy)
+	}
+
+	return found
+}
+
+// probe - копия подписки с сервисом, приведённым к каталогу так же, как это сделает Create:
+// сохранённые подписки уже приведены
+func (ch *Checker) probe(subscription *subs.Subscription) (*subs.Subscription, error) {
+	probe := *subscription
+	if ch.resolver != nil {
+		resolved, err := ch.resolver.Resolve(subscription.Service)
+		if err != nil {
+			return nil, err
+		}
+		probe.Service, probe.ServiceID = resolved.Name, resolved.ID
+	}
+
+	return &probe, nil
+}
+
+func (ch *Checker) userSubscriptions(userID uuid.UUID) ([]*subs.Subscription, error) {
+	var all []*subs.Subscription
+	err := ch.subsRepo.Stream(&subs.SubscriptionFilter{UserID: &userID}, func(subscription *subs.Subscription) error {
+		all = append(all, subscription)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// overlapping - подписки из existing на тот же сервис, что probe, пересекающиеся с ней по сроку
+func overlapping(existing []*subs.Subscription, probe *subs.Subscription) []*subs.Subscription {
+	key := Key(probe)
+
+	var found []*subs.Subscription
+	for _, subscription := range existing {
+		if (probe.ID == "" || subscription.ID != probe.ID) && Key(subscription) == key && subscription.Overlaps(probe) {
+			found = append(found, subscription)
+		}
+	}
+
+	return found
+}
+
+// Report - группы пересекающихся подписок пользователя userID
+func (ch *Checker) Report(userID uuid.UUID) ([]*Group, error) {
+	ch.logger.Debugw("report overlapping subscriptions", "userID", userID)
+
+	all, err := ch.userSubscriptions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := Groups(all, subs.Today())
+
+	ch.logger.Infow("overlapping subscriptions found", "userID", userID, "groups", len(groups))
+	return groups, nil
+}
+
+// Merge поглощает подписки ids подпиской id. Все они должны принадлежать одному пользователю,
+// относиться к одному сервису и образовывать одну группу пересечений
+func (ch *Checker) Merge(id string, ids []string) (*subs.Subscription, error) {
+	ch.logger.Debugw("merge overlapping subscriptions", "id", id, "ids", ids)
+
+	return ch.subsRepo.Merge(id, ids, func(target *subs.Subscription, merged []*subs.Subscription) error {
+		key := Key(target)
+		for _, subscription := range merged {
+			if subscription.UserID != target.UserID || Key(subscription) != key {
+				return ErrMergeMismatch
+			}
+		}
+
+		groups := Groups(append([]*subs.Subscription{target}, merged...), subs.Today())
+		if len(groups) != 1 || len(groups[0].Subscriptions) != len(merged)+1 {
+			return ErrMergeMismatch
+		}
+
+		return nil
+	})
+}
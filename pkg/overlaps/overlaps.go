@@ -0,0 +1,145 @@
+package overlaps
+
+import (
+	"errors"
+	"online-subs/pkg/catalog"
+	"online-subs/pkg/subs"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Policy - что делать при создании подписки, пересекающейся по сроку с подпиской того же пользователя на тот же сервис
+type Policy string
+
+const (
+	PolicyAllow  Policy = "allow"
+	PolicyWarn   Policy = "warn"
+	PolicyReject Policy = "reject"
+)
+
+var Policies = []Policy{PolicyAllow, PolicyWarn, PolicyReject}
+
+var (
+	ErrUnknownPolicy = errors.New("unknown overlap policy")
+	ErrOverlap       = errors.New("subscription overlaps another subscription of the user to the same service")
+	ErrMergeMismatch = errors.New("merged subscriptions must belong to the same user and service and overlap")
+)
+
+// ParsePolicy - пустое значение означает PolicyWarn
+func ParsePolicy(value string) (Policy, error) {
+	if value == "" {
+		return PolicyWarn, nil
+	}
+
+	for _, policy := range Policies {
+		if string(policy) == value {
+			return policy, nil
+		}
+	}
+
+	return "", ErrUnknownPolicy
+}
+
+// Group - подписки пользователя на один сервис, сроки которых пересекаются цепочкой
+type Group struct {
+	UserID  uuid.UUID
+	Service string
+	// StartDate и EndDate - общий срок группы, EndDate nil, если хотя бы одна подписка бессрочная
+	StartDate     time.Time
+	EndDate       *time.Time
+	Subscriptions []*subs.Subscription
+	// ExtraMonthlyCost - сколько в месяц сейчас считается дважды: цены действующих подписок группы, кроме самой дорогой
+	ExtraMonthlyCost int64
+}
+
+// Key - ключ сервиса подписки: сервис каталога или нормализованное название, если сервис не из каталога
+func Key(subscription *subs.Subscription) string {
+	if subscription.ServiceID != nil {
+		return "id:" + *subscription.ServiceID
+	}
+
+	return "name:" + catalog.NormalizeKey(subscription.Service)
+}
+
+// Groups находит группы пересекающихся подписок одного пользователя на один сервис на день today
+func Groups(subscriptions []*subs.Subscription, today time.Time) []*Group {
+	buckets := make(map[string][]*subs.Subscription)
+	var keys []string
+	for _, subscription := range subscriptions {
+		key := subscription.UserID.String() + "|" + Key(subscription)
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], subscription)
+	}
+
+	var groups []*Group
+	for _, key := range keys {
+		bucket := buckets[key]
+		if len(bucket) < 2 {
+			continue
+		}
+
+		slices.SortFunc(bucket, func(a, b *subs.Subscription) int {
+			if c := a.StartDate.Compare(b.StartDate); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+
+		var group *Group
+		for _, subscription := range bucket {
+			if group != nil && (group.EndDate == nil || !group.EndDate.Before(subscription.StartDate)) {
+				group.add(subscription)
+				continue
+			}
+
+			groups = appendGroup(groups, group, today)
+			group = &Group{
+				UserID:        subscription.UserID,
+				Service:       subscription.Service,
+				StartDate:     subscription.StartDate,
+				EndDate:       subscription.EndDate,
+				Subscriptions: []*subs.Subscription{subscription},
+			}
+		}
+		groups = appendGroup(groups, group, today)
+	}
+
+	slices.SortStableFunc(groups, func(a, b *Group) int {
+		if c := strings.Compare(a.UserID.String(), b.UserID.String()); c != 0 {
+			return c
+		}
+		return a.StartDate.Compare(b.StartDate)
+	})
+
+	return groups
+}
+
+func (g *Group) add(subscription *subs.Subscription) {
+	g.Subscriptions = append(g.Subscriptions, subscription)
+	if g.EndDate != nil && (subscription.EndDate == nil || subscription.EndDate.After(*g.EndDate)) {
+		g.EndDate = subscription.EndDate
+	}
+}
+
+// appendGroup добавляет группу, если в ней больше одной подписки, и считает двойной учёт на день today
+func appendGroup(groups []*Group, group *Group, today time.Time) []*Group {
+	if group == nil || len(group.Subscriptions) < 2 {
+		return groups
+	}
+
+	var total, highest int64
+	for _, subscription := range group.Subscriptions {
+		if subscription.InPeriod(today) {
+			total += int64(subscription.Cost)
+			highest = max(highest, int64(subscription.Cost))
+		}
+	}
+	group.ExtraMonthlyCost = total - highest
+
+	return append(groups, group)
+}
@@ -0,0 +1,50 @@
+package pricing
+
+import (
+	"errors"
+	"online-subs/pkg/subs"
+	"time"
+)
+
+// PriceChange - запланированная смена цены подписки с месяца EffectiveDate
+type PriceChange struct {
+	ID             string    `gorm:"primaryKey;type:char(40)"`
+	SubscriptionID string    `gorm:"type:char(40);not null;uniqueIndex:ux_price_changes_sub_date"`
+	EffectiveDate  time.Time `gorm:"type:date;not null;uniqueIndex:ux_price_changes_sub_date"`
+	Cost           int32     `gorm:"type:int;not null"`
+}
+
+type PriceChangesRepo interface {
+	Schedule(change *PriceChange) (string, error)
+	ListBySubscription(subscriptionID string) ([]*PriceChange, error)
+	// ListForSubscriptions возвращает изменения, сгруппированные по подписке и отсортированные по дате
+	ListForSubscriptions(subscriptionIDs []string) (map[string][]*PriceChange, error)
+	Delete(id string) error
+}
+
+var (
+	ErrNotFound      = errors.New("price change not found")
+	ErrAlreadyExists = errors.New("price change for this month is already scheduled")
+)
+
+// CostAt - цена подписки в месяце month с учётом изменений, changes отсортированы по дате
+func CostAt(cost int32, changes []*PriceChange, month time.Time) (int32, bool) {
+	changed := false
+	for _, change := range changes {
+		if change.EffectiveDate.After(month) {
+			break
+		}
+		cost = change.Cost
+		changed = true
+	}
+
+	return cost, changed
+}
+
+// Price - цена подписки по дням с учётом изменений changes, отсортированных по дате
+func Price(cost int32, changes []*PriceChange) subs.PriceFunc {
+	return func(day time.Time) int32 {
+		price, _ := CostAt(cost, changes, day)
+		return price
+	}
+}
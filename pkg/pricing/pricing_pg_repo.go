@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Размер пачки ID в одном IN, чтобы не упереться в лимит параметров запроса
+const idsChunkSize = 1000
+
+type PriceChangesPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewPriceChangesPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *PriceChangesPgRepo {
+	return &PriceChangesPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *PriceChangesPgRepo) Schedule(change *PriceChange) (string, error) {
+	repo.logger.Debugw("schedule price change", "change", change)
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return "", err
+	}
+
+	change.ID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	if err = repo.db.WithContext(ctx).Create(change).Error; err != nil {
+		repo.logger.Errorw("error scheduling price change", "error", err, "change", change)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return "", ErrAlreadyExists
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return "", subs.ErrNotFound
+		}
+		return "", err
+	}
+
+	repo.logger.Infow("price change scheduled", "change", change)
+	return change.ID, nil
+}
+
+func (repo *PriceChangesPgRepo) ListBySubscription(subscriptionID string) ([]*PriceChange, error) {
+	repo.logger.Debugw("list price changes", "subscriptionID", subscriptionID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	changes := []*PriceChange{}
+	res := repo.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).
+		Order("effective_date").Find(&changes)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error listing price changes", "subscriptionID", subscriptionID, "error", res.Error)
+		return nil, res.Error
+	}
+
+	return changes, nil
+}
+
+func (repo *PriceChangesPgRepo) ListForSubscriptions(subscriptionIDs []string) (map[string][]*PriceChange, error) {
+	repo.logger.Debugw("list price changes for subscriptions", "count", len(subscriptionIDs))
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.ExportSLATimeout)
+	defer cancel()
+
+	bySubscription := make(map[string][]*PriceChange)
+	for start := 0; start < len(subscriptionIDs); start += idsChunkSize {
+		end := min(start+idsChunkSize, len(subscriptionIDs))
+
+		var changes []*PriceChange
+		res := repo.db.WithContext(ctx).Where("subscription_id IN ?", subscriptionIDs[start:end]).
+			Order("subscription_id, effective_date").Find(&changes)
+
+		if res.Error != nil {
+			repo.logger.Errorw("error listing price changes for subscriptions", "error", res.Error)
+			return nil, res.Error
+		}
+
+		for _, change := range changes {
+			bySubscription[change.SubscriptionID] = append(bySubscription[change.SubscriptionID], change)
+		}
+	}
+
+	return bySubscription, nil
+}
+
+// Prices - цены подписок по дням для subs.PriceSource, подписки без запланированных изменений в ответ не попадают
+func (repo *PriceChangesPgRepo) Prices(subscriptions []*subs.Subscription) (map[string]subs.PriceFunc, error) {
+	ids := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+
+	changes, err := repo.ListForSubscriptions(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]subs.PriceFunc, len(changes))
+	for _, subscription := range subscriptions {
+		if subscriptionChanges, ok := changes[subscription.ID]; ok {
+			prices[subscription.ID] = Price(subscription.Cost, subscriptionChanges)
+		}
+	}
+
+	return prices, nil
+}
+
+func (repo *PriceChangesPgRepo) Delete(id string) error {
+	repo.logger.Debugw("delete price change", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	res := repo.db.WithContext(ctx).Where("id = ?", id).Delete(&PriceChange{})
+
+	if res.Error != nil {
+		repo.logger.Errorw("error deleting price change", "id", id, "error", res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		repo.logger.Warnw("failed deleting price change", "id", id)
+		return ErrNotFound
+	}
+
+	repo.logger.Infow("price change deleted", "id", id)
+	return nil
+}
@@ -0,0 +1,85 @@
+package statements
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultMinConfidence - кандидаты с меньшей уверенностью не предлагаются, если порог не задан явно
+const DefaultMinConfidence = 0.5
+
+type CandidateStatus string
+
+const (
+	CandidateProposed  CandidateStatus = "proposed"
+	CandidateAccepted  CandidateStatus = "accepted"
+	CandidateDismissed CandidateStatus = "dismissed"
+)
+
+var CandidateStatuses = []CandidateStatus{CandidateProposed, CandidateAccepted, CandidateDismissed}
+
+// Candidate - повторяющийся мерчант из выписок, предлагаемый как новая подписка.
+// Merchant - ключ группировки операций, по нему повторное обнаружение обновляет кандидата, а не создаёт нового.
+// Принятые и отклонённые кандидаты повторно не предлагаются
+type Candidate struct {
+	ID          string    `gorm:"primaryKey;type:char(40)"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:ux_recurring_candidates_merchant"`
+	Merchant    string    `gorm:"type:varchar(512);not null;uniqueIndex:ux_recurring_candidates_merchant"`
+	Currency    string    `gorm:"type:char(3);not null;uniqueIndex:ux_recurring_candidates_merchant"`
+	Service     string    `gorm:"type:varchar(255);not null"`
+	Price       int64     `gorm:"type:bigint;not null"`
+	StartDate   time.Time `gorm:"type:date;not null"`
+	LastDate    time.Time `gorm:"type:date;not null"`
+	BillingDay  int       `gorm:"type:smallint;not null"`
+	Occurrences int       `gorm:"not null"`
+	// Confidence - уверенность от 0 до 1, что это ежемесячная подписка
+	Confidence float64         `gorm:"not null"`
+	Status     CandidateStatus `gorm:"type:varchar(16);not null;default:proposed"`
+	// SubscriptionID - подписка, созданная при принятии кандидата
+	SubscriptionID *string   `gorm:"type:char(40)"`
+	CreatedAt      time.Time `gorm:"not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+func (Candidate) TableName() string {
+	return "recurring_candidates"
+}
+
+type CandidateFilter struct {
+	UserID        uuid.UUID
+	Statuses      []CandidateStatus
+	MinConfidence float64
+}
+
+type CandidatesRepo interface {
+	// Replace обновляет предложенных кандидатов пользователя найденными: новые добавляются, устаревшие предложения
+	// удаляются, принятые и отклонённые не меняются
+	Replace(userID uuid.UUID, candidates []*Candidate) error
+	// List упорядочивает кандидатов по убыванию уверенности
+	List(filter *CandidateFilter) ([]*Candidate, error)
+	ReadByID(id string) (*Candidate, error)
+	// Resolve переводит предложенного кандидата в status, subscriptionID - созданная подписка при принятии
+	Resolve(id string, status CandidateStatus, subscriptionID *string) error
+	// ResolveIn - Resolve в транзакции tx, например в транзакции создания подписки
+	ResolveIn(tx *gorm.DB, id string, status CandidateStatus, subscriptionID *string) error
+}
+
+var (
+	ErrCandidateNotFound     = errors.New("recurring candidate not found")
+	ErrCandidateResolved     = errors.New("recurring candidate is already accepted or dismissed")
+	ErrUnknownCandidateState = errors.New("unknown recurring candidate status")
+	ErrConfidenceRange       = errors.New("confidence must be between 0 and 1")
+)
+
+func ParseCandidateStatus(value string) (CandidateStatus, error) {
+	for _, status := range CandidateStatuses {
+		if string(status) == value {
+			return status, nil
+		}
+	}
+
+	return "", ErrUnknownCandidateState
+}
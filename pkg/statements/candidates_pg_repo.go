@@ -0,0 +1,162 @@
+package statements
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CandidatesPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewCandidatesPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *CandidatesPgRepo {
+	return &CandidatesPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *CandidatesPgRepo) Replace(userID uuid.UUID, candidates []*Candidate) error {
+	repo.logger.Debugw("replace recurring candidates", "userID", userID, "count", len(candidates))
+
+	now := time.Now().UTC()
+	merchants := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		id, err := utils.GenerateID()
+		if err != nil {
+			repo.logger.Errorw("error generating id", "err", err)
+			return err
+		}
+		candidate.ID = id
+		candidate.UserID = userID
+		candidate.Status = CandidateProposed
+		candidate.CreatedAt = now
+		candidate.UpdatedAt = now
+		merchants = append(merchants, candidate.Merchant)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("user_id = ? AND status = ?", userID, CandidateProposed)
+		if len(merchants) > 0 {
+			stale = stale.Where("merchant NOT IN ?", merchants)
+		}
+		if err := stale.Delete(&Candidate{}).Error; err != nil {
+			return err
+		}
+
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		// Повторно найденный кандидат обновляется, только пока пользователь его не принял и не отклонил
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "merchant"}, {Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"service", "price", "start_date", "last_date", "billing_day", "occurrences", "confidence", "updated_at",
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Eq{Column: clause.Column{Table: "recurring_candidates", Name: "status"}, Value: CandidateProposed},
+			}},
+		}).CreateInBatches(candidates, insertBatchSize).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error replacing recurring candidates", "userID", userID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *CandidatesPgRepo) List(filter *CandidateFilter) ([]*Candidate, error) {
+	repo.logger.Debugw("list recurring candidates", "filter", filter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	query := repo.db.WithContext(ctx).Where("user_id = ?", filter.UserID)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.MinConfidence > 0 {
+		query = query.Where("confidence >= ?", filter.MinConfidence)
+	}
+
+	candidates := []*Candidate{}
+	if err := query.Order("confidence DESC, id").Find(&candidates).Error; err != nil {
+		repo.logger.Errorw("error listing recurring candidates", "filter", filter, "error", err)
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+func (repo *CandidatesPgRepo) ReadByID(id string) (*Candidate, error) {
+	repo.logger.Debugw("read recurring candidate", "id", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	var candidate Candidate
+	if err := repo.db.WithContext(ctx).Where("id = ?", id).First(&candidate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCandidateNotFound
+		}
+
+		repo.logger.Errorw("error reading recurring candidate", "id", id, "error", err)
+		return nil, err
+	}
+
+	return &candidate, nil
+}
+
+func (repo *CandidatesPgRepo) Resolve(id string, status CandidateStatus, subscriptionID *string) error {
+	repo.logger.Debugw("resolve recurring candidate", "id", id, "status", status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	return repo.resolve(repo.db.WithContext(ctx), id, status, subscriptionID)
+}
+
+func (repo *CandidatesPgRepo) ResolveIn(tx *gorm.DB, id string, status CandidateStatus, subscriptionID *string) error {
+	repo.logger.Debugw("resolve recurring candidate in transaction", "id", id, "status", status)
+
+	return repo.resolve(tx, id, status, subscriptionID)
+}
+
+func (repo *CandidatesPgRepo) resolve(db *gorm.DB, id string, status CandidateStatus, subscriptionID *string) error {
+	res := db.Model(&Candidate{}).
+		Where("id = ? AND status = ?", id, CandidateProposed).
+		Updates(map[string]any{
+			"status":          status,
+			"subscription_id": subscriptionID,
+			"updated_at":      time.Now().UTC(),
+		})
+	if res.Error != nil {
+		repo.logger.Errorw("error resolving recurring candidate", "id", id, "error", res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		// Отличаем отсутствующего кандидата от уже разобранного
+		if _, err := repo.ReadByID(id); err != nil {
+			return err
+		}
+		return ErrCandidateResolved
+	}
+
+	return nil
+}
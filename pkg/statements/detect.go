@@ -0,0 +1,334 @@
+package statements
+
+import (
+	"errors"
+	"math"
+	"online-subs/pkg/overlaps"
+	"online-subs/pkg/subs"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// Повторяющиеся мерчанты ищутся по несопоставленным операциям за последние detectHistoryMonths месяцев
+	detectHistoryMonths = 13
+
+	// Меньше двух списаний не дают ни одного промежутка
+	minOccurrences = 2
+
+	// Ежемесячным считается мерчант, у которого медианный промежуток между списаниями в этих пределах
+	minMonthlyInterval = 25
+	maxMonthlyInterval = 35
+
+	// Последнее списание не старше freshDays - подписка скорее всего действует, старше staleDays - отменена и не предлагается
+	freshDays = 35
+	staleDays = 95
+
+	// Число промежутков, после которого их количество уже не добавляет уверенности
+	saturatedIntervals = 5
+)
+
+// Веса составляющих уверенности: регулярность промежутков, постоянство суммы, число списаний и их свежесть
+const (
+	intervalWeight = 0.35
+	amountWeight   = 0.25
+	countWeight    = 0.25
+	recencyWeight  = 0.15
+)
+
+var ErrCandidatePrice = errors.New("candidate price does not fit a subscription price")
+
+// Override - поля подписки, которые пользователь поправил при принятии кандидата
+type Override struct {
+	Service    *string
+	Price      *int32
+	StartDate  *time.Time
+	BillingDay *int
+}
+
+type Detector struct {
+	transactionsRepo TransactionsRepo
+	candidatesRepo   CandidatesRepo
+	subsRepo         subs.SubscriptionsRepo
+	overlapsChecker  *overlaps.Checker
+	aliases          AliasResolver
+	logger           *zap.SugaredLogger
+}
+
+func NewDetector(transactionsRepo TransactionsRepo, candidatesRepo CandidatesRepo, subsRepo subs.SubscriptionsRepo,
+	overlapsChecker *overlaps.Checker, aliases AliasResolver, logger *zap.SugaredLogger) *Detector {
+	return &Detector{
+		transactionsRepo: transactionsRepo,
+		candidatesRepo:   candidatesRepo,
+		subsRepo:         subsRepo,
+		overlapsChecker:  overlapsChecker,
+		aliases:          aliases,
+		logger:           logger,
+	}
+}
+
+// Detect ищет повторяющихся мерчантов среди несопоставленных операций пользователя, обновляет кандидатов
+// и возвращает предложенных с уверенностью не ниже minConfidence. Мерчанты, похожие на уже заведённые подписки, пропускаются
+func (d *Detector) Detect(userID uuid.UUID, tolerance int, minConfidence float64) ([]*Candidate, error) {
+	d.logger.Debugw("detect recurring payments", "userID", userID)
+
+	today := subs.Today()
+	from := subs.MonthStart(today).AddDate(0, -detectHistoryMonths, 0)
+	unmatched := false
+	history, _, err := d.transactionsRepo.List(&TransactionFilter{
+		UserID:   userID,
+		Matched:  &unmatched,
+		DateFrom: &from,
+		DateTo:   &today,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := d.knownNames(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := DetectCandidates(history, known, today, tolerance)
+	for _, candidate := range candidates {
+		candidate.UserID = userID
+	}
+
+	if err = d.candidatesRepo.Replace(userID, candidates); err != nil {
+		return nil, err
+	}
+
+	proposed, err := d.candidatesRepo.List(&CandidateFilter{
+		UserID:        userID,
+		Statuses:      []CandidateStatus{CandidateProposed},
+		MinConfidence: minConfidence,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Infow("recurring payments detected", "userID", userID, "found", len(candidates), "proposed", len(proposed))
+	return proposed, nil
+}
+
+// Accept создаёт подписку по предложенному кандидату с правками override по политике пересечений policy
+// и отмечает кандидата принятым. При PolicyWarn возвращает найденные пересечения
+func (d *Detector) Accept(id string, override *Override, policy overlaps.Policy) (*Candidate, string, []*subs.Subscription, error) {
+	d.logger.Debugw("accept recurring candidate", "id", id)
+
+	candidate, err := d.candidatesRepo.ReadByID(id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if candidate.Status != CandidateProposed {
+		return nil, "", nil, ErrCandidateResolved
+	}
+
+	subscription, err := candidate.Subscription(override)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Кандидат отмечается в транзакции создания подписки: параллельное принятие откатит свою подписку
+	subscriptionID, overlapping, err := d.overlapsChecker.CreateWith(subscription, policy,
+		func(tx *gorm.DB, subscription *subs.Subscription) error {
+			return d.candidatesRepo.ResolveIn(tx, id, CandidateAccepted, &subscription.ID)
+		})
+	if err != nil {
+		return nil, "", nil, err
+	}
+	candidate.Status = CandidateAccepted
+	candidate.SubscriptionID = &subscriptionID
+
+	d.logger.Infow("recurring candidate accepted", "id", id, "subscriptionID", subscriptionID, "overlaps", len(overlapping))
+	return candidate, subscriptionID, overlapping, nil
+}
+
+// Dismiss отклоняет кандидата, повторно он не предлагается
+func (d *Detector) Dismiss(id string) error {
+	d.logger.Debugw("dismiss recurring candidate", "id", id)
+
+	return d.candidatesRepo.Resolve(id, CandidateDismissed, nil)
+}
+
+// knownNames - названия и алиасы всех подписок пользователя
+func (d *Detector) knownNames(userID uuid.UUID) ([]string, error) {
+	var names []string
+	err := d.subsRepo.Stream(&subs.SubscriptionFilter{UserID: &userID}, func(subscription *subs.Subscription) error {
+		if slices.Contains(names, subscription.Service) {
+			return nil
+		}
+		names = append(names, subscription.Service)
+
+		if d.aliases == nil {
+			return nil
+		}
+		aliases, err := d.aliases.Aliases(subscription.Service)
+		if err != nil {
+			return err
+		}
+		names = append(names, aliases...)
+		return nil
+	})
+
+	return names, err
+}
+
+// Subscription - подписка из кандидата: бессрочная, с первого найденного списания, в день самых частых списаний
+func (c *Candidate) Subscription(override *Override) (*subs.Subscription, error) {
+	if c.Price > math.MaxInt32 {
+		return nil, ErrCandidatePrice
+	}
+
+	subscription := &subs.Subscription{
+		Service:    c.Service,
+		Cost:       int32(c.Price),
+		UserID:     c.UserID,
+		StartDate:  c.StartDate,
+		BillingDay: c.BillingDay,
+	}
+
+	if override == nil {
+		return subscription, nil
+	}
+	if override.Service != nil {
+		subscription.Service = *override.Service
+	}
+	if override.Price != nil {
+		subscription.Cost = *override.Price
+	}
+	if override.StartDate != nil {
+		subscription.StartDate = *override.StartDate
+	}
+	if override.BillingDay != nil {
+		subscription.BillingDay = *override.BillingDay
+	}
+
+	return subscription, nil
+}
+
+// serviceName - название сервиса кандидата из описания операции, обрезанное до длины колонки service подписок
+func serviceName(description string) string {
+	name := []rune(merchantName(description))
+	if len(name) <= subs.MaxServiceLength {
+		return string(name)
+	}
+
+	return strings.TrimSpace(string(name[:subs.MaxServiceLength]))
+}
+
+// DetectCandidates группирует операции по мерчанту и валюте и оценивает ежемесячные группы на день asOf.
+// Сумма кандидата - последнее списание, отклонение в пределах tolerance процентов считается той же ценой
+func DetectCandidates(transactions []*Transaction, known []string, asOf time.Time, tolerance int) []*Candidate {
+	groups := make(map[string][]*Transaction)
+	var keys []string
+	for _, transaction := range transactions {
+		merchant := merchantKey(transaction.Description)
+		if merchant == "" {
+			continue
+		}
+
+		key := merchant + "|" + transaction.Currency
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], transaction)
+	}
+
+	var candidates []*Candidate
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < minOccurrences || nameScore(group[0].Description, known) > 0 {
+			continue
+		}
+
+		if candidate := evaluate(group, asOf, tolerance); candidate != nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	return candidates
+}
+
+// evaluate оценивает группу операций одного мерчанта, nil - если списания не похожи на ежемесячные или давно прекратились
+func evaluate(group []*Transaction, asOf time.Time, tolerance int) *Candidate {
+	slices.SortFunc(group, func(a, b *Transaction) int { return a.Date.Compare(b.Date) })
+	first, last := group[0], group[len(group)-1]
+	if daysBetween(last.Date, asOf) > staleDays {
+		return nil
+	}
+
+	intervals := make([]int, 0, len(group)-1)
+	regular := 0
+	for i := 1; i < len(group); i++ {
+		days := daysBetween(group[i-1].Date, group[i].Date)
+		intervals = append(intervals, days)
+		if days >= minMonthlyInterval && days <= maxMonthlyInterval {
+			regular++
+		}
+	}
+
+	slices.Sort(intervals)
+	median := intervals[len(intervals)/2]
+	if median < minMonthlyInterval || median > maxMonthlyInterval {
+		return nil
+	}
+
+	similar := 0
+	for _, transaction := range group {
+		if absInt64(transaction.Amount-last.Amount)*100 <= int64(tolerance)*last.Amount {
+			similar++
+		}
+	}
+
+	intervalScore := float64(regular) / float64(len(intervals))
+	amountScore := float64(similar) / float64(len(group))
+	countScore := math.Min(float64(len(intervals)), saturatedIntervals) / saturatedIntervals
+	recencyScore := 1 - math.Max(float64(daysBetween(last.Date, asOf)-freshDays), 0)/(staleDays-freshDays)
+
+	confidence := intervalWeight*intervalScore + amountWeight*amountScore + countWeight*countScore + recencyWeight*recencyScore
+
+	return &Candidate{
+		Merchant:    merchantKey(last.Description),
+		Currency:    last.Currency,
+		Service:     serviceName(last.Description),
+		Price:       last.Amount,
+		StartDate:   first.Date,
+		LastDate:    last.Date,
+		BillingDay:  billingDay(group),
+		Occurrences: len(group),
+		Confidence:  math.Round(confidence*100) / 100,
+	}
+}
+
+// billingDay - самый частый день месяца среди списаний, при равенстве - день последнего
+func billingDay(group []*Transaction) int {
+	counts := make(map[int]int)
+	for _, transaction := range group {
+		counts[transaction.Date.Day()]++
+	}
+
+	best := group[len(group)-1].Date.Day()
+	for i := len(group) - 1; i >= 0; i-- {
+		if day := group[i].Date.Day(); counts[day] > counts[best] {
+			best = day
+		}
+	}
+
+	return best
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,156 @@
+package statements
+
+import (
+	"online-subs/pkg/charges"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Минимальная доля слов названия сервиса, найденных в описании операции
+const minNameScore = 0.5
+
+// Слова, которые банки добавляют к названиям мерчантов и которые ничего не говорят о сервисе
+var noiseWords = map[string]struct{}{
+	"com": {}, "ru": {}, "net": {}, "org": {}, "io": {}, "www": {}, "http": {}, "https": {},
+	"pos": {}, "card": {}, "payment": {}, "purchase": {}, "retail": {}, "subscription": {},
+	"оплата": {}, "покупка": {}, "карта": {}, "подписка": {}, "списание": {},
+}
+
+// merchantName - описание без чисел, коротких слов и банковского шума: "NETFLIX.COM 12345" превращается в "NETFLIX"
+func merchantName(value string) string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || strings.IndexFunc(field, unicode.IsLetter) < 0 {
+			continue
+		}
+		if _, ok := noiseWords[strings.ToLower(field)]; ok {
+			continue
+		}
+		words = append(words, field)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// merchantWords - слова merchantName в нижнем регистре
+func merchantWords(value string) []string {
+	return strings.Fields(strings.ToLower(merchantName(value)))
+}
+
+// merchantKey группирует операции одного мерчанта: "NETFLIX.COM 12345" и "Netflix com" дают один ключ
+func merchantKey(description string) string {
+	return strings.Join(merchantWords(description), " ")
+}
+
+// nameScore - насколько описание операции похоже на одно из названий сервиса, от 0 до 1.
+// Название целиком без пробелов внутри описания даёт 1, иначе - доля найденных слов названия
+func nameScore(description string, names []string) float64 {
+	compact := strings.Join(merchantWords(description), "")
+	if compact == "" {
+		return 0
+	}
+
+	best := 0.0
+	for _, name := range names {
+		words := merchantWords(name)
+		if len(words) == 0 {
+			continue
+		}
+
+		if strings.Contains(compact, strings.Join(words, "")) {
+			return 1
+		}
+
+		found := 0
+		for _, word := range words {
+			if len([]rune(word)) >= 3 && strings.Contains(compact, word) {
+				found++
+			}
+		}
+		best = max(best, float64(found)/float64(len(words)))
+	}
+
+	if best < minNameScore {
+		return 0
+	}
+	return best
+}
+
+type candidate struct {
+	transaction *Transaction
+	charge      *charges.Charge
+	score       float64
+}
+
+// match сопоставляет операции с ожидаемыми списаниями: валюта совпадает, день отстоит не больше чем на window дней,
+// сумма отличается не больше чем на tolerance процентов, описание похоже на название сервиса.
+// Пары выбираются жадно по убыванию оценки, каждая операция и каждое списание участвуют не больше чем в одной
+func match(transactions []*Transaction, open []*charges.Charge, names map[string][]string, window, tolerance int) []*Match {
+	var candidates []*candidate
+	for _, transaction := range transactions {
+		for _, charge := range open {
+			if charge.Amount <= 0 || charge.Currency != transaction.Currency {
+				continue
+			}
+
+			days := absInt64(int64(transaction.Date.Sub(charge.Date).Hours() / 24))
+			if days > int64(window) {
+				continue
+			}
+
+			diff := absInt64(transaction.Amount - charge.Amount)
+			if diff*100 > int64(tolerance)*charge.Amount {
+				continue
+			}
+
+			name := nameScore(transaction.Description, names[charge.Service])
+			if name == 0 {
+				continue
+			}
+
+			score := 2*name + 1 - float64(days)/float64(window+1) + 1 - float64(diff)/float64(charge.Amount)
+			candidates = append(candidates, &candidate{transaction: transaction, charge: charge, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if !candidates[i].transaction.Date.Equal(candidates[j].transaction.Date) {
+			return candidates[i].transaction.Date.Before(candidates[j].transaction.Date)
+		}
+		return candidates[i].charge.Date.Before(candidates[j].charge.Date)
+	})
+
+	usedTransactions := make(map[*Transaction]struct{})
+	usedCharges := make(map[*charges.Charge]struct{})
+
+	var matches []*Match
+	for _, c := range candidates {
+		if _, ok := usedTransactions[c.transaction]; ok {
+			continue
+		}
+		if _, ok := usedCharges[c.charge]; ok {
+			continue
+		}
+
+		usedTransactions[c.transaction] = struct{}{}
+		usedCharges[c.charge] = struct{}{}
+		matches = append(matches, &Match{Transaction: c.transaction, Charge: c.charge})
+	}
+
+	return matches
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
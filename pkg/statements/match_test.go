@@ -0,0 +1,187 @@
+package statements
+
+import (
+	"online-subs/pkg/charges"
+	"testing"
+	"time"
+)
+
+func day(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+
+	return parsed
+}
+
+func TestMerchantName(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{description: "NETFLIX.COM 12345", want: "NETFLIX"},
+		{description: "Оплата: Яндекс Плюс *1234", want: "Яндекс Плюс"},
+		{description: "POS SPOTIFY P1A2B3 STOCKHOLM", want: "SPOTIFY P1A2B3 STOCKHOLM"},
+		{description: "www.ivi.ru", want: "ivi"},
+		{description: "A 42 B", want: ""},
+		{description: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := merchantName(tt.description); got != tt.want {
+				t.Errorf("merchantName(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		names       []string
+		want        float64
+	}{
+		{name: "whole name", description: "NETFLIX.COM 12345", names: []string{"Netflix"}, want: 1},
+		{name: "name without spaces", description: "YANDEXPLUS MOSCOW", names: []string{"Yandex Plus"}, want: 1},
+		{name: "alias", description: "Оплата YANDEX PLUS", names: []string{"Яндекс Плюс", "Yandex Plus"}, want: 1},
+		{name: "half of the words", description: "APPLE.COM/BILL", names: []string{"Apple Music"}, want: 0.5},
+		{name: "below minimum", description: "APPLE.COM/BILL", names: []string{"Apple Music Family"}, want: 0},
+		{name: "short words ignored", description: "KION TV", names: []string{"TV Kino"}, want: 0},
+		{name: "other service", description: "SPOTIFY", names: []string{"Netflix"}, want: 0},
+		{name: "no merchant", description: "12345", names: []string{"Netflix"}, want: 0},
+		{name: "no names", description: "NETFLIX", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameScore(tt.description, tt.names); got != tt.want {
+				t.Errorf("nameScore(%q, %q) = %v, want %v", tt.description, tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	const (
+		window    = 3
+		tolerance = 5
+	)
+
+	names := map[string][]string{
+		"Netflix": {"Netflix"},
+		"Spotify": {"Spotify"},
+	}
+
+	charge := func(id, service, date string, amount int64) *charges.Charge {
+		return &charges.Charge{ID: id, Service: service, Date: day(t, date), Amount: amount, Currency: "RUB"}
+	}
+	transaction := func(id, description, date string, amount int64, currency string) *Transaction {
+		return &Transaction{ID: id, Description: description, Date: day(t, date), Amount: amount, Currency: currency}
+	}
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		charges      []*charges.Charge
+		// want - пары ID операции и ID списания
+		want map[string]string
+	}{
+		{
+			name:         "exact match",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-10", 79900, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{"t1": "c1"},
+		},
+		{
+			name:         "within window and tolerance",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-13", 83000, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{"t1": "c1"},
+		},
+		{
+			name:         "outside window",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-14", 79900, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{},
+		},
+		{
+			name:         "outside tolerance",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-10", 84000, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{},
+		},
+		{
+			name:         "other currency",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-10", 79900, "USD")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{},
+		},
+		{
+			name:         "other service",
+			transactions: []*Transaction{transaction("t1", "SPOTIFY", "2024-03-10", 79900, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:         map[string]string{},
+		},
+		{
+			name:         "free charge",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-10", 0, "RUB")},
+			charges:      []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 0)},
+			want:         map[string]string{},
+		},
+		{
+			// Одна операция на два списания: достаётся ближайшему по дате
+			name:         "closest charge wins",
+			transactions: []*Transaction{transaction("t1", "NETFLIX.COM", "2024-03-11", 79900, "RUB")},
+			charges: []*charges.Charge{
+				charge("c1", "Netflix", "2024-03-09", 79900),
+				charge("c2", "Netflix", "2024-03-11", 79900),
+			},
+			want: map[string]string{"t1": "c2"},
+		},
+		{
+			// Две операции на одно списание: вторая остаётся несопоставленной
+			name: "each charge once",
+			transactions: []*Transaction{
+				transaction("t1", "NETFLIX.COM", "2024-03-12", 79900, "RUB"),
+				transaction("t2", "NETFLIX.COM", "2024-03-10", 79900, "RUB"),
+			},
+			charges: []*charges.Charge{charge("c1", "Netflix", "2024-03-10", 79900)},
+			want:    map[string]string{"t2": "c1"},
+		},
+		{
+			name: "several services",
+			transactions: []*Transaction{
+				transaction("t1", "SPOTIFY P1A2B3", "2024-03-16", 29900, "RUB"),
+				transaction("t2", "NETFLIX.COM", "2024-03-10", 79900, "RUB"),
+			},
+			charges: []*charges.Charge{
+				charge("c1", "Netflix", "2024-03-10", 79900),
+				charge("c2", "Spotify", "2024-03-15", 29900),
+			},
+			want: map[string]string{"t1": "c2", "t2": "c1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			for _, m := range match(tt.transactions, tt.charges, names, window, tolerance) {
+				got[m.Transaction.ID] = m.Charge.ID
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("match() = %v, want %v", got, tt.want)
+			}
+			for transactionID, chargeID := range tt.want {
+				if got[transactionID] != chargeID {
+					t.Errorf("match() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package statements
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/importer"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FieldDate        = "date"
+	FieldAmount      = "amount"
+	FieldDescription = "description"
+	FieldCurrency    = "currency"
+	FieldID          = "id"
+)
+
+// Названия колонок выписок, сравниваются без учёта регистра и пробелов по краям
+var columnNames = map[string][]string{
+	FieldDate:        {"date", "transaction date", "posting date", "дата", "дата операции", "дата платежа"},
+	FieldAmount:      {"amount", "sum", "сумма", "сумма операции", "сумма платежа"},
+	FieldDescription: {"description", "merchant", "payee", "name", "описание", "назначение платежа", "контрагент", "получатель"},
+	FieldCurrency:    {"currency", "валюта", "валюта операции"},
+	FieldID:          {"id", "transaction id", "transaction_id", "reference", "fitid"},
+}
+
+var requiredColumns = []string{FieldDate, FieldAmount, FieldDescription}
+
+// Форматы дат в выписках, день идёт раньше месяца
+var dateLayouts = []string{
+	"2006-01-02",
+	"02.01.2006",
+	"02/01/2006",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"20060102",
+}
+
+// RowError - строка CSV или порядковый номер операции OFX, которую не удалось разобрать
+type RowError struct {
+	Row   int
+	Field string
+	Err   error
+}
+
+// Parsed - списания выписки без UserID. Поступления только подсчитываются
+type Parsed struct {
+	Transactions []*Transaction
+	Credits      int
+	Invalid      []RowError
+}
+
+// DetectFormat отличает OFX по заголовку, всё остальное считается CSV
+func DetectFormat(r *bufio.Reader) Format {
+	head, _ := r.Peek(512)
+	head = bytes.ToUpper(bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\ufeff"))))
+
+	if bytes.HasPrefix(head, []byte("OFXHEADER")) || bytes.HasPrefix(head, []byte("<OFX>")) ||
+		bytes.HasPrefix(head, []byte("<?XML")) && bytes.Contains(head, []byte("OFX")) {
+		return FormatOFX
+	}
+
+	return FormatCSV
+}
+
+// Parse разбирает выписку в формате format, delimiter 0 для CSV означает автоопределение
+func Parse(r io.Reader, format Format, delimiter rune) (*Parsed, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r, delimiter)
+	case FormatOFX:
+		return ParseOFX(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func ParseCSV(r io.Reader, delimiter rune) (*Parsed, error) {
+	bufReader := bufio.NewReader(r)
+
+	if delimiter == 0 {
+		detected, err := importer.DetectDelimiter(bufReader)
+		if err != nil {
+			if errors.Is(err, importer.ErrEmptyFile) {
+				return nil, ErrEmptyStatement
+			}
+			return nil, err
+		}
+		delimiter = detected
+	}
+
+	csvReader := csv.NewReader(bufReader)
+	csvReader.Comma = delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	csvReader.LazyQuotes = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrEmptyStatement
+		}
+		return nil, fmt.Errorf("%w: %v", ErrMalformedStatement, err)
+	}
+
+	columns, err := resolveColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &Parsed{}
+	seen := make(map[string]int)
+	rows := 0
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedStatement, err)
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+
+		if rows == MaxTransactions {
+			return nil, ErrTooManyRows
+		}
+		rows++
+
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		date, err := parseDate(value(FieldDate))
+		if err != nil {
+			parsed.Invalid = append(parsed.Invalid, RowError{Row: line, Field: FieldDate, Err: ErrInvalidDate})
+			continue
+		}
+
+		amount, err := parseAmount(value(FieldAmount))
+		if err != nil {
+			parsed.Invalid = append(parsed.Invalid, RowError{Row: line, Field: FieldAmount, Err: ErrInvalidAmount})
+			continue
+		}
+
+		externalID := value(FieldID)
+		if externalID == "" {
+			externalID = rowHash(seen, date, value(FieldAmount), value(FieldDescription))
+		}
+
+		parsed.add(&Transaction{
+			ExternalID:  externalID,
+			Date:        date,
+			Currency:    currencyOrDefault(value(FieldCurrency)),
+			Description: value(FieldDescription),
+		}, amount)
+	}
+
+	if rows == 0 {
+		return nil, ErrEmptyStatement
+	}
+
+	return parsed, nil
+}
+
+// Теги OFX: в SGML-версии у значений нет закрывающих тегов, в XML-версии есть, разбор одинаковый
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+func ParseOFX(r io.Reader) (*Parsed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Contains(bytes.ToUpper(data), []byte("<OFX>")) {
+		return nil, fmt.Errorf("%w: no OFX element", ErrMalformedStatement)
+	}
+
+	parsed := &Parsed{}
+	seen := make(map[string]int)
+	currency := charges.DefaultCurrency
+	rows := 0
+
+	var fields map[string]string
+	finish := func() error {
+		if fields == nil {
+			return nil
+		}
+		defer func() { fields = nil }()
+
+		if rows == MaxTransactions {
+			return ErrTooManyRows
+		}
+		rows++
+
+		date, err := parseDate(firstN(fields["DTPOSTED"], 8))
+		if err != nil {
+			parsed.Invalid = append(parsed.Invalid, RowError{Row: rows, Field: FieldDate, Err: ErrInvalidDate})
+			return nil
+		}
+
+		amount, err := parseAmount(fields["TRNAMT"])
+		if err != nil {
+			parsed.Invalid = append(parsed.Invalid, RowError{Row: rows, Field: FieldAmount, Err: ErrInvalidAmount})
+			return nil
+		}
+
+		description := fields["NAME"]
+		if description == "" {
+			description = fields["MEMO"]
+		}
+
+		externalID := fields["FITID"]
+		if externalID == "" {
+			externalID = rowHash(seen, date, fields["TRNAMT"], description)
+		}
+
+		parsed.add(&Transaction{
+			ExternalID:  externalID,
+			Date:        date,
+			Currency:    currencyOrDefault(fields["CURRENCY"]),
+			Description: description,
+		}, amount)
+		return nil
+	}
+
+	for _, match := range ofxTag.FindAllStringSubmatch(string(data), -1) {
+		closing, name := match[1] == "/", strings.ToUpper(match[2])
+		value := strings.TrimSpace(html.UnescapeString(match[3]))
+
+		switch {
+		case name == "STMTTRN":
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			if !closing {
+				fields = map[string]string{"CURRENCY": currency}
+			}
+		case name == "CURDEF" && !closing && value != "":
+			currency = value
+		case fields != nil && !closing && value != "":
+			// CURRENCY у операции - агрегат с CURSYM, NAME встречается и внутри PAYEE
+			if name == "CURSYM" {
+				name = "CURRENCY"
+			}
+			if _, ok := fields[name]; !ok || name == "CURRENCY" {
+				fields[name] = value
+			}
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	if rows == 0 {
+		return nil, ErrEmptyStatement
+	}
+
+	return parsed, nil
+}
+
+// add сохраняет списание с положительной суммой, поступления и нулевые операции только считает
+func (p *Parsed) add(transaction *Transaction, amount float64) {
+	if amount >= 0 {
+		p.Credits++
+		return
+	}
+
+	transaction.Amount = int64(math.Round(-amount))
+	transaction.Description = truncate(strings.Join(strings.Fields(transaction.Description), " "), 512)
+	p.Transactions = append(p.Transactions, transaction)
+}
+
+func resolveColumns(header []string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		normalized := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := positions[normalized]; !ok {
+			positions[normalized] = i
+		}
+	}
+
+	columns := make(map[string]int, len(columnNames))
+	for field, aliases := range columnNames {
+		for _, alias := range aliases {
+			if index, ok := positions[alias]; ok {
+				columns[field] = index
+				break
+			}
+		}
+	}
+
+	for _, field := range requiredColumns {
+		if _, ok := columns[field]; !ok {
+			return nil, &ColumnError{Column: field, Err: ErrMissingColumn}
+		}
+	}
+
+	return columns, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidDate
+}
+
+// thousandsOnly - запятые без точки, после каждой ровно три цифры: "1,299" и "1,299,000" - целые суммы
+var thousandsOnly = regexp.MustCompile(`^[-+]?\d{1,3}(,\d{3})+$`)
+
+// parseAmount понимает "-1 299,00", "−299.5", "1,299.00" и "1,299": десятичный разделитель - последний из точки
+// и запятой, кроме запятых, отделяющих группы из трёх цифр в сумме без точки
+func parseAmount(value string) (float64, error) {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\u2009', '\'':
+			return -1
+		case '\u2212':
+			return '-'
+		}
+		return r
+	}, value)
+
+	if thousandsOnly.MatchString(value) {
+		value = strings.ReplaceAll(value, ",", "")
+	} else if comma := strings.LastIndex(value, ","); comma > strings.LastIndex(value, ".") {
+		value = strings.ReplaceAll(value[:comma], ".", "") + "." + value[comma+1:]
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, ErrInvalidAmount
+	}
+
+	return amount, nil
+}
+
+// rowHash - ключ операции без собственного ID. Одинаковые операции в один день различаются порядковым номером
+func rowHash(seen map[string]int, date time.Time, amount, description string) string {
+	key := date.Format("2006-01-02") + "|" + amount + "|" + strings.ToLower(description)
+	seen[key]++
+
+	sum := sha1.Sum([]byte(key + "|" + strconv.Itoa(seen[key])))
+	return hex.EncodeToString(sum[:])
+}
+
+func currencyOrDefault(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "RUR" || len(currency) != 3 {
+		return charges.DefaultCurrency
+	}
+	return currency
+}
+
+func firstN(value string, n int) string {
+	if len(value) < n {
+		return value
+	}
+	return value[:n]
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package statements
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		err   error
+	}{
+		{value: "299", want: 299},
+		{value: "-299.50", want: -299.5},
+		{value: "−299.5", want: -299.5},
+		{value: "-1 299,00", want: -1299},
+		{value: "1 299,5", want: 1299.5},
+		{value: "1.299,00", want: 1299},
+		{value: "1,299.00", want: 1299},
+		{value: "1'299.00", want: 1299},
+		{value: "299,5", want: 299.5},
+		{value: "0,99", want: 0.99},
+		// Запятая без точки перед ровно тремя цифрами - разделитель тысяч
+		{value: "1,299", want: 1299},
+		{value: "-1,299", want: -1299},
+		{value: "1,299,000", want: 1299000},
+		{value: "1,2990", want: 1.299},
+		{value: "", err: ErrInvalidAmount},
+		{value: "abc", err: ErrInvalidAmount},
+		{value: "NaN", err: ErrInvalidAmount},
+		{value: "Inf", err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAmount(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("parseAmount(%q) error = %v, want %v", tt.value, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmount(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{value: "2024-03-05"},
+		{value: "05.03.2024"},
+		{value: "05/03/2024"},
+		{value: "2024-03-05 23:59:59"},
+		{value: "2024-03-05T10:15:00"},
+		{value: "05.03.2024 10:15:00"},
+		{value: "05.03.2024 10:15"},
+		{value: "20240305"},
+		{value: "03-2024", err: ErrInvalidDate},
+		{value: "2024-02-30", err: ErrInvalidDate},
+		{value: "", err: ErrInvalidDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDate(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("parseDate(%q) error = %v, want %v", tt.value, err, tt.err)
+			}
+			if tt.err == nil && !got.Equal(want) {
+				t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package statements
+
+import (
+	"bufio"
+	"io"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/subs"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Options struct {
+	// Format пустой - определяется по содержимому
+	Format Format
+	// Delimiter равный 0 означает автоопределение по строке заголовка CSV
+	Delimiter rune
+	Window    int
+	Tolerance int
+}
+
+// Reconciliation - итог сверки операций с реестром списаний за период
+type Reconciliation struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Matched     []*Match
+	Unmatched   []*Transaction
+	// Missing - ожидаемые списания периода без операции в выписке
+	Missing []*charges.Charge
+	// Suggestions - предложенные кандидаты в подписки из повторяющихся несопоставленных операций
+	Suggestions []*Candidate
+}
+
+type Report struct {
+	Format       Format
+	Transactions int
+	Imported     int
+	Duplicates   int
+	Credits      int
+	Invalid      []RowError
+	// Reconciliation nil, если в выписке нет ни одного списания
+	Reconciliation *Reconciliation
+}
+
+type Reconciler struct {
+	transactionsRepo TransactionsRepo
+	chargesRepo      charges.ChargesRepo
+	generator        *charges.Generator
+	detector         *Detector
+	aliases          AliasResolver
+	logger           *zap.SugaredLogger
+}
+
+func NewReconciler(transactionsRepo TransactionsRepo, chargesRepo charges.ChargesRepo, generator *charges.Generator,
+	detector *Detector, aliases AliasResolver, logger *zap.SugaredLogger) *Reconciler {
+	return &Reconciler{
+		transactionsRepo: transactionsRepo,
+		chargesRepo:      chargesRepo,
+		generator:        generator,
+		detector:         detector,
+		aliases:          aliases,
+		logger:           logger,
+	}
+}
+
+// Import сохраняет списания выписки пользователя и сверяет их с реестром за период выписки
+func (rc *Reconciler) Import(userID uuid.UUID, r io.Reader, opts *Options) (*Report, error) {
+	rc.logger.Debugw("import bank statement", "userID", userID, "options", opts)
+
+	bufReader := bufio.NewReader(r)
+
+	format := opts.Format
+	if format == "" {
+		format = DetectFormat(bufReader)
+	}
+
+	parsed, err := Parse(bufReader, format, opts.Delimiter)
+	if err != nil {
+		rc.logger.Errorw("error parsing bank statement", "format", format, "error", err)
+		return nil, err
+	}
+
+	report := &Report{
+		Format:       format,
+		Transactions: len(parsed.Transactions),
+		Credits:      parsed.Credits,
+		Invalid:      parsed.Invalid,
+	}
+	if len(parsed.Transactions) == 0 {
+		return report, nil
+	}
+
+	periodStart, periodEnd := parsed.Transactions[0].Date, parsed.Transactions[0].Date
+	for _, transaction := range parsed.Transactions {
+		transaction.UserID = userID
+		periodStart = minDate(periodStart, transaction.Date)
+		periodEnd = maxDate(periodEnd, transaction.Date)
+	}
+
+	if report.Imported, err = rc.transactionsRepo.Save(parsed.Transactions); err != nil {
+		return nil, err
+	}
+	report.Duplicates = report.Transactions - report.Imported
+
+	if report.Reconciliation, err = rc.Reconcile(userID, periodStart, periodEnd, opts.Window, opts.Tolerance); err != nil {
+		return nil, err
+	}
+
+	rc.logger.Infow("bank statement imported", "userID", userID, "format", format, "imported", report.Imported,
+		"duplicates", report.Duplicates, "matched", len(report.Reconciliation.Matched))
+	return report, nil
+}
+
+// Reconcile сверяет несопоставленные операции пользователя за [from, to] с ожидаемыми и неуспешными списаниями.
+// Реестр предварительно пересобирается, чтобы учесть последние изменения подписок
+func (rc *Reconciler) Reconcile(userID uuid.UUID, from, to time.Time, window, tolerance int) (*Reconciliation, error) {
+	rc.logger.Debugw("reconcile bank transactions", "userID", userID, "from", from, "to", to)
+
+	filter := &subs.SubscriptionFilter{UserID: &userID}
+	if _, err := rc.generator.Generate(filter, charges.HorizonEnd(maxDate(subs.Today(), to), charges.DefaultHorizon)); err != nil {
+		return nil, err
+	}
+
+	unmatched := false
+	transactions, _, err := rc.transactionsRepo.List(&TransactionFilter{
+		UserID:   userID,
+		Matched:  &unmatched,
+		DateFrom: &from,
+		DateTo:   &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chargesFrom, chargesTo := from.AddDate(0, 0, -window), to.AddDate(0, 0, window)
+	open, _, err := rc.chargesRepo.List(&charges.ChargeFilter{
+		UserID:   &userID,
+		Statuses: []charges.Status{charges.StatusExpected, charges.StatusFailed},
+		DateFrom: &chargesFrom,
+		DateTo:   &chargesTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := rc.serviceNames(open)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := rc.transactionsRepo.Apply(match(transactions, open, names, window, tolerance))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Reconciliation{
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Matched:     matched,
+	}
+
+	settled := make(map[string]struct{}, len(matched))
+	paid := make(map[string]struct{}, len(matched))
+	for _, m := range matched {
+		settled[m.Charge.ID] = struct{}{}
+		paid[m.Transaction.ID] = struct{}{}
+	}
+
+	for _, transaction := range transactions {
+		if _, ok := paid[transaction.ID]; !ok {
+			result.Unmatched = append(result.Unmatched, transaction)
+		}
+	}
+
+	// Списание в конце периода могло попасть в банк уже после выписки, такие не считаются пропущенными
+	missingTo := to.AddDate(0, 0, -window)
+	for _, charge := range open {
+		if _, ok := settled[charge.ID]; ok || charge.Status != charges.StatusExpected {
+			continue
+		}
+		if !charge.Date.Before(from) && !charge.Date.After(missingTo) {
+			result.Missing = append(result.Missing, charge)
+		}
+	}
+
+	if result.Suggestions, err = rc.detector.Detect(userID, tolerance, DefaultMinConfidence); err != nil {
+		return nil, err
+	}
+
+	rc.logger.Infow("bank transactions reconciled", "userID", userID, "matched", len(result.Matched),
+		"unmatched", len(result.Unmatched), "missing", len(result.Missing), "suggestions", len(result.Suggestions))
+	return result, nil
+}
+
+// serviceNames - написания сервисов списаний, с алиасами каталога, если он подключён
+func (rc *Reconciler) serviceNames(open []*charges.Charge) (map[string][]string, error) {
+	names := make(map[string][]string)
+	for _, charge := range open {
+		if _, ok := names[charge.Service]; ok {
+			continue
+		}
+
+		if rc.aliases == nil {
+			names[charge.Service] = []string{charge.Service}
+			continue
+		}
+
+		aliases, err := rc.aliases.Aliases(charge.Service)
+		if err != nil {
+			return nil, err
+		}
+		names[charge.Service] = append(aliases, charge.Service)
+	}
+
+	return names, nil
+}
+
+func minDate(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,114 @@
+package statements
+
+import (
+	"errors"
+	"online-subs/pkg/charges"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxTransactions - предел операций в одной выписке
+	MaxTransactions = 10000
+
+	// DefaultWindow - на сколько дней списание в банке может отстоять от ожидаемого дня
+	DefaultWindow = 3
+	MaxWindow     = 15
+	// DefaultTolerance - допустимое отклонение суммы от ожидаемой в процентах
+	DefaultTolerance = 10
+	MaxTolerance     = 50
+)
+
+// AliasResolver - все известные написания сервиса, например названия и алиасы из каталога
+type AliasResolver interface {
+	Aliases(name string) ([]string, error)
+}
+
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatOFX Format = "ofx"
+)
+
+var Formats = []Format{FormatCSV, FormatOFX}
+
+// Transaction - списание из банковской выписки. Поступления не сохраняются.
+// ExternalID - FITID из OFX, колонка id из CSV или хэш строки, по нему повторный импорт не создаёт дублей
+type Transaction struct {
+	ID          string    `gorm:"primaryKey;type:char(40)"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:ux_bank_tx_user_external;index:ix_bank_tx_user_date"`
+	ExternalID  string    `gorm:"type:varchar(255);not null;uniqueIndex:ux_bank_tx_user_external"`
+	Date        time.Time `gorm:"type:date;not null;index:ix_bank_tx_user_date"`
+	Amount      int64     `gorm:"type:bigint;not null"`
+	Currency    string    `gorm:"type:char(3);not null"`
+	Description string    `gorm:"type:varchar(512);not null"`
+	// ChargeID - сопоставленное ожидаемое списание, nil пока операция не сопоставлена
+	ChargeID  *string   `gorm:"type:char(40);index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (Transaction) TableName() string {
+	return "bank_transactions"
+}
+
+type TransactionFilter struct {
+	UserID uuid.UUID
+	// Matched nil - все операции, true - только сопоставленные, false - только несопоставленные
+	Matched  *bool
+	DateFrom *time.Time
+	DateTo   *time.Time
+
+	// Limit 0 - все операции без ограничения
+	Limit  int
+	Offset int
+}
+
+// Match - операция и ожидаемое списание, которое она оплачивает
+type Match struct {
+	Transaction *Transaction
+	Charge      *charges.Charge
+}
+
+type TransactionsRepo interface {
+	// Save сохраняет операции, уже импортированные ранее пропускаются. Возвращает число новых
+	Save(transactions []*Transaction) (int, error)
+	List(filter *TransactionFilter) ([]*Transaction, int64, error)
+	// Apply отмечает списания оплаченными фактической суммой операции и связывает их с операциями.
+	// Возвращает применённые сопоставления: уже сверенные к этому моменту списания и операции пропускаются
+	Apply(matches []*Match) ([]*Match, error)
+}
+
+var (
+	ErrUnknownFormat      = errors.New("unknown statement format")
+	ErrEmptyStatement     = errors.New("statement has no transactions")
+	ErrMalformedStatement = errors.New("malformed statement")
+	ErrMissingColumn      = errors.New("required column is missing")
+	ErrTooManyRows        = errors.New("too many transactions in statement")
+	ErrInvalidDate        = errors.New("invalid transaction date")
+	ErrInvalidAmount      = errors.New("invalid transaction amount")
+)
+
+func ParseFormat(value string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == value {
+			return format, nil
+		}
+	}
+
+	return "", ErrUnknownFormat
+}
+
+type ColumnError struct {
+	Column string
+	Err    error
+}
+
+func (e *ColumnError) Error() string {
+	return e.Err.Error() + ": " + e.Column
+}
+
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,151 @@
+package statements
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/charges"
+	"online-subs/pkg/subs"
+	"online-subs/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Размер пачки строк в одном INSERT
+const insertBatchSize = 1000
+
+type TransactionsPgRepo struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewTransactionsPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *TransactionsPgRepo {
+	return &TransactionsPgRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (repo *TransactionsPgRepo) Save(transactions []*Transaction) (int, error) {
+	repo.logger.Debugw("save bank transactions", "count", len(transactions))
+
+	now := time.Now().UTC()
+	for _, transaction := range transactions {
+		id, err := utils.GenerateID()
+		if err != nil {
+			repo.logger.Errorw("error generating id", "err", err)
+			return 0, err
+		}
+		transaction.ID = id
+		transaction.CreatedAt = now
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	// Уже импортированные операции пропускаются по уникальному индексу (user_id, external_id)
+	res := repo.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(transactions, insertBatchSize)
+	if res.Error != nil {
+		repo.logger.Errorw("error saving bank transactions", "error", res.Error)
+		return 0, res.Error
+	}
+
+	return int(res.RowsAffected), nil
+}
+
+func (repo *TransactionsPgRepo) List(filter *TransactionFilter) ([]*Transaction, int64, error) {
+	repo.logger.Debugw("list bank transactions", "filter", filter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.SLATimeout)
+	defer cancel()
+
+	query := repo.db.WithContext(ctx).Model(&Transaction{}).Where("user_id = ?", filter.UserID)
+	if filter.Matched != nil {
+		if *filter.Matched {
+			query = query.Where("charge_id IS NOT NULL")
+		} else {
+			query = query.Where("charge_id IS NULL")
+		}
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("date <= ?", *filter.DateTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		repo.logger.Errorw("error counting bank transactions", "filter", filter, "error", err)
+		return nil, 0, err
+	}
+
+	query = query.Order("date, id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	transactions := []*Transaction{}
+	if err := query.Find(&transactions).Error; err != nil {
+		repo.logger.Errorw("error listing bank transactions", "filter", filter, "error", err)
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+func (repo *TransactionsPgRepo) Apply(matches []*Match) ([]*Match, error) {
+	repo.logger.Debugw("apply bank matches", "count", len(matches))
+
+	ctx, cancel := context.WithTimeout(context.Background(), subs.BatchSLATimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	var applied []*Match
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range matches {
+			var charge charges.Charge
+			res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", m.Charge.ID).First(&charge)
+			if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if res.Error != nil {
+				return res.Error
+			}
+
+			// Списание могли сверить вручную после выборки кандидатов
+			amount := m.Transaction.Amount
+			if err := charge.Settle(charges.StatusPaid, &amount, now); err != nil {
+				continue
+			}
+
+			res = tx.Model(&Transaction{}).Where("id = ? AND charge_id IS NULL", m.Transaction.ID).Update("charge_id", charge.ID)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+
+			err := tx.Model(&charge).Select("status", "actual_amount", "settled_at").Updates(&charge).Error
+			if err != nil {
+				return err
+			}
+
+			m.Transaction.ChargeID = &charge.ID
+			m.Charge = &charge
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error applying bank matches", "error", err)
+		return nil, err
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,106 @@
+package subs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GroupBy string
+
+const (
+	GroupByService    GroupBy = "service"
+	GroupByServiceID  GroupBy = "service_id"
+	GroupByUserID     GroupBy = "user_id"
+	GroupByStartMonth GroupBy = "start_month"
+	GroupByEndMonth   GroupBy = "end_month"
+	// GroupByCategory - непосредственная категория подписки, GroupByRootCategory - её категория верхнего уровня
+	GroupByCategory     GroupBy = "category"
+	GroupByRootCategory GroupBy = "root_category"
+)
+
+// rootCategorySQL поднимается по дереву категорий от категории подписки до корня
+const rootCategorySQL = `(WITH RECURSIVE up AS (
+		SELECT id, parent_id FROM categories WHERE id = subscriptions.category_id
+		UNION ALL
+		SELECT c.id, c.parent_id FROM categories c JOIN up ON c.id = up.parent_id
+	) SELECT id FROM up WHERE parent_id IS NULL)`
+
+type Metric string
+
+const (
+	// MetricCount - число подписок в группе
+	MetricCount Metric = "count"
+	// MetricSumCost - суммарная ежемесячная стоимость
+	MetricSumCost Metric = "sum_cost"
+	// MetricTotalCost - сколько группа стоила за период, считается как GetTotalCost
+	MetricTotalCost Metric = "total_cost"
+	// MetricAvgPrice - средняя цена подписки
+	MetricAvgPrice Metric = "avg_price"
+)
+
+// MaxAggregateGroups ограничивает ответ, группировка по user_id на большой базе иначе вернёт всю таблицу
+const MaxAggregateGroups = 1000
+
+var (
+	ErrUnknownGroupBy = errors.New("unknown group by field")
+	ErrUnknownMetric  = errors.New("unknown metric")
+)
+
+var (
+	groupByColumns = map[GroupBy]string{
+		GroupByService:    "service",
+		GroupByServiceID:  "service_id",
+		GroupByUserID:     "user_id",
+		GroupByStartMonth: "date_trunc('month', start_date)::date",
+		GroupByEndMonth:   "date_trunc('month', end_date)::date",
+
+		GroupByCategory:     "category_id",
+		GroupByRootCategory: rootCategorySQL,
+	}
+	metricNames = []Metric{MetricCount, MetricSumCost, MetricTotalCost, MetricAvgPrice}
+)
+
+func ParseGroupBy(value string) (GroupBy, error) {
+	groupBy := GroupBy(value)
+	if _, ok := groupByColumns[groupBy]; !ok {
+		return "", ErrUnknownGroupBy
+	}
+
+	return groupBy, nil
+}
+
+func ParseMetric(value string) (Metric, error) {
+	for _, metric := range metricNames {
+		if string(metric) == value {
+			return metric, nil
+		}
+	}
+
+	return "", ErrUnknownMetric
+}
+
+// AggregateQuery - группировка подписок, подходящих под Filter. Для MetricTotalCost в фильтре обязателен период
+type AggregateQuery struct {
+	Filter  *SubscriptionFilter
+	GroupBy []GroupBy
+	Metrics []Metric
+}
+
+// AggregateRow - одна группа. Поля, не участвующие в группировке или не запрошенные как метрики, остаются nil
+type AggregateRow struct {
+	Service    *string
+	ServiceID  *string
+	UserID     *uuid.UUID
+	StartMonth *time.Time
+	EndMonth   *time.Time
+	// Category и RootCategory - ID категорий
+	Category     *string
+	RootCategory *string
+
+	Count     *int64
+	SumCost   *int64
+	TotalCost *int64
+	AvgPrice  *float64
+}
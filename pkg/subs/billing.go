@@ -0,0 +1,133 @@
+package subs
+
+import (
+	"time"
+)
+
+// Anchor - день месяца, в который списывается подписка, по умолчанию день начала
+func (s *Subscription) Anchor() int {
+	if s.BillingDay < 1 || s.BillingDay > 31 {
+		return s.StartDate.Day()
+	}
+
+	return s.BillingDay
+}
+
+// InPeriod сообщает, входит ли день в срок подписки
+func (s *Subscription) InPeriod(day time.Time) bool {
+	return !day.Before(s.StartDate) && (s.EndDate == nil || !day.After(*s.EndDate))
+}
+
+// BillingDates - дни списания по якорю в периоде [from, to] в пределах срока подписки, включая пробные и на паузе
+func (s *Subscription) BillingDates(from, to time.Time) []time.Time {
+	from, to = s.clampPeriod(from, to)
+
+	var dates []time.Time
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		date := BillingDate(month, s.Anchor())
+		if !date.Before(from) && !date.After(to) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// ChargeDates - дни фактических списаний в периоде [from, to]: дни списания вне пробного периода и пауз
+func (s *Subscription) ChargeDates(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for _, date := range s.BillingDates(from, to) {
+		if !s.freeOn(date) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// Charges - число списаний в периоде [from, to]
+func (s *Subscription) Charges(from, to time.Time) int {
+	return len(s.ChargeDates(from, to))
+}
+
+// MonthsActive - число начавшихся по день at расчётных периодов, пробные тоже считаются, периоды на паузе - нет
+func (s *Subscription) MonthsActive(at time.Time) int {
+	var months int
+	for _, date := range s.BillingDates(s.StartDate, at) {
+		if !s.PausedOn(date) {
+			months++
+		}
+	}
+
+	return months
+}
+
+// TotalPaid - сколько списано с начала подписки по день at включительно
+func (s *Subscription) TotalPaid(at time.Time) int64 {
+	return int64(s.Charges(s.StartDate, at)) * int64(s.Cost)
+}
+
+// ActiveOn сообщает, действует ли подписка в день day, в том числе на пробном периоде. На паузе подписка не активна
+func (s *Subscription) ActiveOn(day time.Time) bool {
+	return s.InPeriod(day) && !s.PausedOn(day)
+}
+
+// InTrial сообщает, приходится ли день day на пробный период
+func (s *Subscription) InTrial(day time.Time) bool {
+	for _, period := range s.trialPeriods() {
+		if period.covers(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TotalCost - сумма по подпискам за дни периода [start, end] включительно по политике proration,
+// пробные периоды и паузы бесплатны. prices - цены по ID подписки, без записи в prices берётся текущая цена
+func TotalCost(subscriptions []*Subscription, start, end time.Time, proration Proration, prices map[string]PriceFunc) int64 {
+	var sumCost int64
+	for _, sub := range subscriptions {
+		cost, _ := sub.CostIn(start, end, proration, prices[sub.ID])
+		sumCost += cost
+	}
+
+	return sumCost
+}
+
+// NextChargeDate - день следующего списания после дня at, nil если подписка к тому времени закончится
+// или приостановлена без срока
+func (s *Subscription) NextChargeDate(at time.Time) *time.Time {
+	from := at.AddDate(0, 0, 1)
+	if s.StartDate.After(from) {
+		from = s.StartDate
+	}
+
+	// Бесплатные периоды, кроме бессрочной паузы, конечны, поэтому перебор по месяцам завершится
+	for month := MonthStart(from); ; month = month.AddDate(0, 1, 0) {
+		date := BillingDate(month, s.Anchor())
+		if date.Before(from) {
+			continue
+		}
+		if s.EndDate != nil && date.After(*s.EndDate) {
+			return nil
+		}
+
+		if !s.freeOn(date) {
+			return &date
+		}
+		if s.openPauseFrom(date) {
+			return nil
+		}
+	}
+}
+
+// nextBillingDate - ближайший после day день списания по якорю без учёта срока и бесплатных периодов
+func (s *Subscription) nextBillingDate(day time.Time) time.Time {
+	date := BillingDate(day, s.Anchor())
+	if !date.After(day) {
+		date = BillingDate(MonthStart(day).AddDate(0, 1, 0), s.Anchor())
+	}
+
+	return date
+}
@@ -0,0 +1,180 @@
+package subs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	cursorDateFormat = "2006-01-02"
+	cursorInfinity   = "infinity"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Cursor указывает на строку, от которой продолжается выдача. Values - значения колонок сортировки этой строки,
+// последним всегда идёт id, Sort - отпечаток сортировки, под которую курсор был выдан
+type Cursor struct {
+	Values   []string `json:"v"`
+	Sort     string   `json:"s"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func EncodeCursor(cursor *Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(raw, &cursor); err != nil || len(cursor.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+func sortFingerprint(order []SortField) string {
+	parts := make([]string, 0, len(order))
+	for _, field := range order {
+		if field.Desc {
+			parts = append(parts, "-"+field.Column)
+		} else {
+			parts = append(parts, field.Column)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func cursorFromSubscription(subscription *Subscription, order []SortField, backward bool) *Cursor {
+	values := make([]string, 0, len(order))
+	for _, field := range order {
+		values = append(values, columnValue(subscription, field.Column))
+	}
+
+	return &Cursor{
+		Values:   values,
+		Sort:     sortFingerprint(order),
+		Backward: backward,
+	}
+}
+
+func columnValue(subscription *Subscription, column string) string {
+	switch column {
+	case "cost":
+		return strconv.FormatInt(int64(subscription.Cost), 10)
+	case "service":
+		return subscription.Service
+	case "start_date":
+		return subscription.StartDate.Format(cursorDateFormat)
+	case "end_date":
+		if subscription.EndDate == nil {
+			return cursorInfinity
+		}
+		return subscription.EndDate.Format(cursorDateFormat)
+	case "user_id":
+		return subscription.UserID.String()
+	default:
+		return subscription.ID
+	}
+}
+
+// typedCursorValue возвращает значение в Go-типе колонки, чтобы сравнение в SQL шло без неявных приведений
+func typedCursorValue(column, value string) (any, error) {
+	switch column {
+	case "cost":
+		cost, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return int32(cost), nil
+	case "start_date", "end_date":
+		if value == cursorInfinity && column == "end_date" {
+			return gorm.Expr("'infinity'::date"), nil
+		}
+		date, err := time.Parse(cursorDateFormat, value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return date, nil
+	case "user_id":
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return userID, nil
+	default:
+		return value, nil
+	}
+}
+
+// keysetCondition строит условие "строго после курсора" для произвольного набора направлений:
+// (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func keysetCondition(order []SortField, cursor *Cursor) (string, []any, error) {
+	if cursor.Sort != sortFingerprint(order) || len(cursor.Values) != len(order) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(order))
+	for i, field := range order {
+		value, err := typedCursorValue(field.Column, cursor.Values[i])
+		if err != nil {
+			return "", nil, err
+		}
+		values[i] = value
+	}
+
+	var (
+		disjuncts []string
+		args      []any
+	)
+	for i, field := range order {
+		conjuncts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, sortExpression(order[j].Column)+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if field.Desc != cursor.Backward {
+			op = "<"
+		}
+		conjuncts = append(conjuncts, sortExpression(field.Column)+" "+op+" ?")
+		args = append(args, values[i])
+
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args, nil
+}
+
+func orderClause(order []SortField, backward bool) string {
+	parts := make([]string, 0, len(order))
+	for _, field := range order {
+		direction := "ASC"
+		if field.Desc != backward {
+			direction = "DESC"
+		}
+		parts = append(parts, sortExpression(field.Column)+" "+direction)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,64 @@
+package subs
+
+import (
+	"errors"
+	"time"
+)
+
+// DateFormat - основной формат дат, TimeParseFormat (MM-YYYY) принимается для совместимости
+const DateFormat = "2006-01-02"
+
+var ErrInvalidDate = errors.New("invalid date, expected YYYY-MM-DD or MM-YYYY")
+
+// ParseDate разбирает дату начала чего-либо: MM-YYYY означает первое число месяца
+func ParseDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(DateFormat, value); err == nil {
+		return parsed, nil
+	}
+
+	if parsed, err := time.Parse(TimeParseFormat, value); err == nil {
+		return parsed, nil
+	}
+
+	return time.Time{}, ErrInvalidDate
+}
+
+// ParseEndDate разбирает дату окончания: MM-YYYY означает последний день месяца, месяц включается целиком
+func ParseEndDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(DateFormat, value); err == nil {
+		return parsed, nil
+	}
+
+	if parsed, err := time.Parse(TimeParseFormat, value); err == nil {
+		return MonthEnd(parsed), nil
+	}
+
+	return time.Time{}, ErrInvalidDate
+}
+
+// MonthStart - первое число месяца t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthEnd - последний день месяца t
+func MonthEnd(t time.Time) time.Time {
+	return MonthStart(t).AddDate(0, 1, -1)
+}
+
+// Today - текущая дата в UTC без времени
+func Today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// BillingDate - день списания в месяце month по якорю anchor. Якорь за концом месяца сдвигается на последний день:
+// 31 в феврале даёт 28 или 29 число
+func BillingDate(month time.Time, anchor int) time.Time {
+	last := MonthEnd(month).Day()
+	if anchor > last {
+		anchor = last
+	}
+
+	return time.Date(month.Year(), month.Month(), anchor, 0, 0, 0, 0, time.UTC)
+}
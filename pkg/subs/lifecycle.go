@@ -0,0 +1,134 @@
+package subs
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Status - состояние подписки в конкретный день. Хранится только факт отмены (CancelledAt),
+// остальное выводится из сроков подписки, пробного периода и пауз
+type Status string
+
+const (
+	StatusTrial                 Status = "trial"
+	StatusActive                Status = "active"
+	StatusPaused                Status = "paused"
+	StatusCancellationScheduled Status = "cancellation_scheduled"
+	StatusCancelled             Status = "cancelled"
+	StatusExpired               Status = "expired"
+)
+
+var Statuses = []Status{StatusTrial, StatusActive, StatusPaused, StatusCancellationScheduled, StatusCancelled, StatusExpired}
+
+// Event - действие пользователя, меняющее состояние подписки
+type Event string
+
+const (
+	EventPause      Event = "pause"
+	EventResume     Event = "resume"
+	EventCancel     Event = "cancel"
+	EventReactivate Event = "reactivate"
+)
+
+// transitions - из каких состояний допустимо событие
+var transitions = map[Event][]Status{
+	EventPause:      {StatusActive},
+	EventResume:     {StatusPaused},
+	EventCancel:     {StatusTrial, StatusActive, StatusPaused},
+	EventReactivate: {StatusCancellationScheduled, StatusCancelled, StatusExpired},
+}
+
+var (
+	ErrInvalidTransition = errors.New("transition is not allowed in the current subscription status")
+	ErrUnknownStatus     = errors.New("unknown subscription status")
+)
+
+// ParseStatus проверяет название состояния
+func ParseStatus(value string) (Status, error) {
+	status := Status(value)
+	if !slices.Contains(Statuses, status) {
+		return "", ErrUnknownStatus
+	}
+
+	return status, nil
+}
+
+// StatusAt - состояние подписки в день day. Ещё не начавшаяся подписка считается активной
+func (s *Subscription) StatusAt(day time.Time) Status {
+	switch {
+	case s.EndDate != nil && s.EndDate.Before(day):
+		if s.CancelledAt != nil {
+			return StatusCancelled
+		}
+		return StatusExpired
+	case s.PausedOn(day):
+		return StatusPaused
+	case s.CancelledAt != nil:
+		return StatusCancellationScheduled
+	case s.InTrial(day) && s.InPeriod(day):
+		return StatusTrial
+	default:
+		return StatusActive
+	}
+}
+
+// CanTransition проверяет, допустимо ли событие в состоянии подписки на день day
+func (s *Subscription) CanTransition(event Event, day time.Time) error {
+	if !slices.Contains(transitions[event], s.StatusAt(day)) {
+		return ErrInvalidTransition
+	}
+
+	return nil
+}
+
+// Cancel отменяет подписку в конце текущего периода: в конце пробного периода, если он идёт,
+// иначе накануне следующего дня списания. Более ранняя дата окончания сохраняется
+func (s *Subscription) Cancel(day, now time.Time) error {
+	if err := s.CanTransition(EventCancel, day); err != nil {
+		return err
+	}
+
+	if s.StartDate.After(day) {
+		day = s.StartDate
+	}
+
+	end := s.nextBillingDate(day).AddDate(0, 0, -1)
+	if s.InTrial(day) {
+		end = *s.TrialEndDate
+	}
+
+	s.EndDateBeforeCancel = s.EndDate
+	if s.EndDate == nil || end.Before(*s.EndDate) {
+		s.EndDate = &end
+	}
+	s.CancelledAt = &now
+
+	return nil
+}
+
+// Reactivate снимает отмену и возвращает дату окончания, заданную до отмены, если она ещё не прошла.
+// Если подписка уже закончилась, пропущенные дни до day закрываются паузой, она возвращается для сохранения
+func (s *Subscription) Reactivate(day time.Time) (*Pause, error) {
+	if err := s.CanTransition(EventReactivate, day); err != nil {
+		return nil, err
+	}
+
+	var gap *Pause
+	if s.EndDate != nil {
+		gapStart := s.EndDate.AddDate(0, 0, 1)
+		gapEnd := day.AddDate(0, 0, -1)
+		if !gapEnd.Before(gapStart) && !s.PausedOn(gapStart) {
+			gap = &Pause{StartDate: gapStart, EndDate: &gapEnd}
+		}
+	}
+
+	s.EndDate = nil
+	if s.EndDateBeforeCancel != nil && !s.EndDateBeforeCancel.Before(day) {
+		s.EndDate = s.EndDateBeforeCancel
+	}
+	s.EndDateBeforeCancel = nil
+	s.CancelledAt = nil
+
+	return gap, nil
+}
@@ -0,0 +1,43 @@
+package subs
+
+import (
+	"slices"
+	"time"
+)
+
+// MergeCheck проверяет заблокированные подписки перед слиянием, ошибка отменяет слияние
+type MergeCheck func(target *Subscription, merged []*Subscription) error
+
+// Overlaps сообщает, пересекаются ли сроки подписок. Бессрочная подписка не заканчивается
+func (s *Subscription) Overlaps(other *Subscription) bool {
+	return !endsBefore(s.EndDate, other.StartDate) && !endsBefore(other.EndDate, s.StartDate)
+}
+
+// Absorb растягивает срок подписки на срок other и добавляет её метки. Отмена берётся у той подписки,
+// чей срок окончания остаётся, категория other - только если своей нет
+func (s *Subscription) Absorb(other *Subscription) {
+	if other.StartDate.Before(s.StartDate) {
+		s.StartDate = other.StartDate
+	}
+
+	if s.EndDate != nil && (other.EndDate == nil || other.EndDate.After(*s.EndDate)) {
+		s.EndDate = other.EndDate
+		s.CancelledAt = other.CancelledAt
+		s.EndDateBeforeCancel = other.EndDateBeforeCancel
+	}
+
+	if s.CategoryID == nil {
+		s.CategoryID = other.CategoryID
+	}
+
+	for _, tag := range other.Tags {
+		if !slices.Contains(s.Tags, tag) {
+			s.Tags = append(s.Tags, tag)
+		}
+	}
+	slices.Sort(s.Tags)
+}
+
+func endsBefore(end *time.Time, day time.Time) bool {
+	return end != nil && end.Before(day)
+}
@@ -0,0 +1,98 @@
+package subs
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrPauseOutsidePeriod = errors.New("pause must lie within the subscription period")
+	ErrPauseOverlap       = errors.New("subscription is already paused in this period")
+	ErrNotPaused          = errors.New("subscription is not paused")
+)
+
+// Pause - приостановка подписки со дня StartDate по EndDate включительно, EndDate nil - до возобновления.
+// На паузе подписка не активна и не списывается
+type Pause struct {
+	ID             string     `gorm:"primaryKey;type:char(40)"`
+	SubscriptionID string     `gorm:"type:char(40);not null;index:ix_subscription_pauses_sub"`
+	StartDate      time.Time  `gorm:"type:date;not null"`
+	EndDate        *time.Time `gorm:"type:date"`
+	CreatedAt      time.Time  `gorm:"not null"`
+}
+
+func (Pause) TableName() string {
+	return "subscription_pauses"
+}
+
+// Covers сообщает, приходится ли день day на паузу
+func (p *Pause) Covers(day time.Time) bool {
+	return !day.Before(p.StartDate) && (p.EndDate == nil || !day.After(*p.EndDate))
+}
+
+// CanPause проверяет, что пауза лежит внутри подписки, не пересекается с уже назначенными
+// и что подписка в день начала паузы активна
+func (s *Subscription) CanPause(pause *Pause) error {
+	if pause.EndDate != nil && pause.EndDate.Before(pause.StartDate) {
+		return ErrPauseOutsidePeriod
+	}
+
+	if pause.StartDate.Before(s.StartDate) || (s.EndDate != nil && pause.StartDate.After(*s.EndDate)) {
+		return ErrPauseOutsidePeriod
+	}
+
+	for _, existing := range s.Pauses {
+		startsBeforeEnd := pause.EndDate == nil || !existing.StartDate.After(*pause.EndDate)
+		endsAfterStart := existing.EndDate == nil || !existing.EndDate.Before(pause.StartDate)
+		if startsBeforeEnd && endsAfterStart {
+			return ErrPauseOverlap
+		}
+	}
+
+	return s.CanTransition(EventPause, pause.StartDate)
+}
+
+// PausedOn сообщает, приостановлена ли подписка в день day
+func (s *Subscription) PausedOn(day time.Time) bool {
+	for _, pause := range s.Pauses {
+		if pause.Covers(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// openPauseFrom сообщает, начата ли к дню day бессрочная пауза
+func (s *Subscription) openPauseFrom(day time.Time) bool {
+	for _, pause := range s.Pauses {
+		if pause.EndDate == nil && !day.Before(pause.StartDate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// freePeriod - отрезок дней, за которые не списывается плата: пробный период или пауза
+type freePeriod struct {
+	start time.Time
+	end   *time.Time
+}
+
+func (p freePeriod) covers(day time.Time) bool {
+	return !day.Before(p.start) && (p.end == nil || !day.After(*p.end))
+}
+
+func (s *Subscription) trialPeriods() []freePeriod {
+	if s.TrialStartDate == nil || s.TrialEndDate == nil {
+		return nil
+	}
+
+	return []freePeriod{{start: *s.TrialStartDate, end: s.TrialEndDate}}
+}
+
+// freeOn сообщает, бесплатен ли день day: он в пробном периоде или на паузе
+func (s *Subscription) freeOn(day time.Time) bool {
+	return s.InTrial(day) || s.PausedOn(day)
+}
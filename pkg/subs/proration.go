@@ -0,0 +1,151 @@
+package subs
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Proration - политика расчёта сумм за период, в котором подписка начинается, заканчивается или меняет цену
+type Proration string
+
+const (
+	// ProrationNone - каждый календарный месяц, в котором есть хотя бы один оплачиваемый день, стоит полную цену
+	ProrationNone Proration = "none"
+	// ProrationDaily - цена расчётного периода делится на его дни, оплачиваются только дни в сроке подписки
+	ProrationDaily Proration = "daily"
+	// ProrationByBillingDay - полная цена в каждый день списания внутри периода, политика по умолчанию
+	ProrationByBillingDay Proration = "by_billing_day"
+)
+
+var Prorations = []Proration{ProrationNone, ProrationDaily, ProrationByBillingDay}
+
+var ErrUnknownProration = errors.New("unknown proration policy")
+
+// ParseProration разбирает название политики, пустая строка означает ProrationByBillingDay
+func ParseProration(value string) (Proration, error) {
+	if value == "" {
+		return ProrationByBillingDay, nil
+	}
+
+	for _, proration := range Prorations {
+		if string(proration) == value {
+			return proration, nil
+		}
+	}
+
+	return "", ErrUnknownProration
+}
+
+// PriceFunc - цена подписки в день day, позволяет учесть запланированные изменения цены
+type PriceFunc func(day time.Time) int32
+
+// PriceSource - цены подписок с учётом запланированных изменений, подписки без изменений можно не возвращать
+type PriceSource interface {
+	Prices(subscriptions []*Subscription) (map[string]PriceFunc, error)
+}
+
+// LoadPrices - цены подписок из source, при nil source суммы считаются по текущим ценам
+func LoadPrices(source PriceSource, subscriptions []*Subscription) (map[string]PriceFunc, error) {
+	if source == nil {
+		return nil, nil
+	}
+
+	return source.Prices(subscriptions)
+}
+
+// CostIn - сумма к оплате за дни периода [from, to] по политике proration. price nil означает текущую цену Cost.
+// billed сообщает, выставляется ли за период плата вообще: у бесплатной подписки сумма нулевая, но billed истинно
+func (s *Subscription) CostIn(from, to time.Time, proration Proration, price PriceFunc) (cost int64, billed bool) {
+	if price == nil {
+		price = func(time.Time) int32 { return s.Cost }
+	}
+
+	switch proration {
+	case ProrationNone:
+		return s.monthlyCost(from, to, price)
+	case ProrationDaily:
+		return s.dailyCost(from, to, price)
+	default:
+		for _, date := range s.ChargeDates(from, to) {
+			cost += int64(price(date))
+			billed = true
+		}
+		return cost, billed
+	}
+}
+
+// monthlyCost берёт полную цену за каждый месяц с оплачиваемыми днями, цена - на первый такой день месяца
+func (s *Subscription) monthlyCost(from, to time.Time, price PriceFunc) (cost int64, billed bool) {
+	from, to = s.clampPeriod(from, to)
+
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		start, end := maxTime(month, from), minTime(MonthEnd(month), to)
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if !s.freeOn(day) {
+				cost += int64(price(day))
+				billed = true
+				break
+			}
+		}
+	}
+
+	return cost, billed
+}
+
+// dailyCost складывает доли цены за оплачиваемые дни: день стоит цену, делённую на длину его расчётного периода.
+// Округление одно на всю сумму, поэтому полный период стоит ровно цену
+func (s *Subscription) dailyCost(from, to time.Time, price PriceFunc) (int64, bool) {
+	from, to = s.clampPeriod(from, to)
+
+	var (
+		cost   float64
+		billed bool
+	)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if s.freeOn(day) {
+			continue
+		}
+
+		start, next := s.billingCycle(day)
+		cycleDays := next.Sub(start).Hours() / 24
+		cost += float64(price(day)) / cycleDays
+		billed = true
+	}
+
+	return int64(math.Round(cost)), billed
+}
+
+// billingCycle - расчётный период, в который попадает день day: от дня списания до следующего не включая
+func (s *Subscription) billingCycle(day time.Time) (time.Time, time.Time) {
+	date := BillingDate(day, s.Anchor())
+	if day.Before(date) {
+		return BillingDate(MonthStart(day).AddDate(0, -1, 0), s.Anchor()), date
+	}
+
+	return date, BillingDate(MonthStart(day).AddDate(0, 1, 0), s.Anchor())
+}
+
+// clampPeriod сужает период [from, to] до срока подписки, пустой период получается с from после to
+func (s *Subscription) clampPeriod(from, to time.Time) (time.Time, time.Time) {
+	from = maxTime(from, s.StartDate)
+	if s.EndDate != nil {
+		to = minTime(to, *s.EndDate)
+	}
+
+	return from, to
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,141 @@
+package subs
+
+import (
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse(DateFormat, value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+
+	return parsed
+}
+
+func TestCostIn(t *testing.T) {
+	const price = 310
+
+	type want struct {
+		cost   int64
+		billed bool
+	}
+
+	tests := []struct {
+		name       string
+		start      string
+		end        string
+		billingDay int
+		from, to   string
+		none       want
+		daily      want
+		byDay      want
+	}{
+		{
+			// Март 2024: оплачены 1-3 числа периода 15.02-15.03 длиной 29 дней
+			name: "end on day 3", start: "2024-01-15", end: "2024-03-03",
+			from: "2024-03-01", to: "2024-03-31",
+			none: want{310, true}, daily: want{32, true}, byDay: want{0, false},
+		},
+		{
+			// 21 день из периода 10.04-10.05 длиной 30 дней
+			name: "mid-month start", start: "2024-04-10",
+			from: "2024-04-01", to: "2024-04-30",
+			none: want{310, true}, daily: want{217, true}, byDay: want{310, true},
+		},
+		{
+			// Списание 29.02, 28 дней из периода 31.01-29.02 и 1 день из 29.02-31.03
+			name: "anchor 31 in leap February", start: "2024-01-31", billingDay: 31,
+			from: "2024-02-01", to: "2024-02-29",
+			none: want{310, true}, daily: want{309, true}, byDay: want{310, true},
+		},
+		{
+			// Списание 28.02, 27 дней из периода 31.01-28.02 и 1 день из 28.02-31.03
+			name: "anchor 31 in February", start: "2023-01-31", billingDay: 31,
+			from: "2023-02-01", to: "2023-02-28",
+			none: want{310, true}, daily: want{309, true}, byDay: want{310, true},
+		},
+		{
+			// Полный период 20.12-20.01 и 12 дней из 20.01-20.02 длиной 31 день
+			name: "december to january", start: "2024-12-20",
+			from: "2024-12-01", to: "2025-01-31",
+			none: want{620, true}, daily: want{430, true}, byDay: want{620, true},
+		},
+		{
+			name: "ends on new year's eve", start: "2024-06-05", end: "2024-12-31",
+			from: "2024-12-01", to: "2025-01-31",
+			none: want{310, true}, daily: want{311, true}, byDay: want{310, true},
+		},
+		{
+			name: "full leap year", start: "2023-03-15",
+			from: "2024-01-01", to: "2024-12-31",
+			none: want{3720, true}, daily: want{3720, true}, byDay: want{3720, true},
+		},
+		{
+			name: "full year anchored on 31", start: "2022-12-31", billingDay: 31,
+			from: "2023-01-01", to: "2023-12-31",
+			none: want{3720, true}, daily: want{3720, true}, byDay: want{3720, true},
+		},
+		{
+			name: "period before start", start: "2024-05-10",
+			from: "2024-04-01", to: "2024-04-30",
+			none: want{0, false}, daily: want{0, false}, byDay: want{0, false},
+		},
+	}
+
+	for _, tt := range tests {
+		subscription := &Subscription{Cost: price, StartDate: date(t, tt.start), BillingDay: tt.billingDay}
+		if tt.end != "" {
+			end := date(t, tt.end)
+			subscription.EndDate = &end
+		}
+		from, to := date(t, tt.from), date(t, tt.to)
+
+		for proration, expected := range map[Proration]want{
+			ProrationNone:         tt.none,
+			ProrationDaily:        tt.daily,
+			ProrationByBillingDay: tt.byDay,
+		} {
+			t.Run(tt.name+"/"+string(proration), func(t *testing.T) {
+				cost, billed := subscription.CostIn(from, to, proration, nil)
+				if cost != expected.cost || billed != expected.billed {
+					t.Errorf("CostIn() = (%d, %v), want (%d, %v)", cost, billed, expected.cost, expected.billed)
+				}
+			})
+		}
+	}
+}
+
+func TestCostInPriceChange(t *testing.T) {
+	subscription := &Subscription{Cost: 300, StartDate: date(t, "2024-01-10")}
+	raise := date(t, "2024-03-10")
+	price := func(day time.Time) int32 {
+		if day.Before(raise) {
+			return 300
+		}
+		return 600
+	}
+
+	tests := []struct {
+		proration Proration
+		want      int64
+	}{
+		// Цена месяца берётся на его первый оплачиваемый день, 01.03 ещё старая
+		{ProrationNone, 300 + 300},
+		{ProrationByBillingDay, 300 + 600},
+		// 9 дней из 10.01-10.02 (31 день) и полный 10.02-10.03 по 300, 22 дня из 10.03-10.04 (31 день) по 600
+		{ProrationDaily, 813},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.proration), func(t *testing.T) {
+			cost, _ := subscription.CostIn(date(t, "2024-02-01"), date(t, "2024-03-31"), tt.proration, price)
+			if cost != tt.want {
+				t.Errorf("CostIn() = %d, want %d", cost, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package subs
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnknownSortField   = errors.New("unknown sort field")
+	ErrDuplicateSortField = errors.New("sort field is repeated")
+)
+
+// sortColumns - белый список полей сортировки: имя в запросе -> колонка. Понимаются и имена полей JSON
+var sortColumns = map[string]string{
+	"service":      "service",
+	"service_name": "service",
+	"cost":         "cost",
+	"price":        "cost",
+	"start_date":   "start_date",
+	"end_date":     "end_date",
+	"user_id":      "user_id",
+	"id":           "id",
+}
+
+// legacySorts - значения параметра sort до появления выражений, оставлены ради совместимости
+var legacySorts = map[string]string{
+	"cost_asc":     "cost",
+	"cost_desc":    "-cost",
+	"service_asc":  "service",
+	"service_desc": "-service",
+}
+
+var defaultSort = []SortField{{Column: "start_date", Desc: true}}
+
+// ParseSort разбирает выражение вида "service,-cost,start_date": минус перед полем - сортировка по убыванию.
+// Пустое выражение даёт сортировку по умолчанию, id в конец добавляет репозиторий
+func ParseSort(expression string) ([]SortField, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return defaultSort, nil
+	}
+
+	if legacy, ok := legacySorts[expression]; ok {
+		expression = legacy
+	}
+
+	var order []SortField
+	for _, part := range strings.Split(expression, ",") {
+		part = strings.TrimSpace(part)
+
+		var field SortField
+		if strings.HasPrefix(part, "-") {
+			field.Desc = true
+			part = part[1:]
+		} else {
+			part = strings.TrimPrefix(part, "+")
+		}
+
+		column, ok := sortColumns[strings.ToLower(part)]
+		if !ok {
+			return nil, &SortFieldError{Field: part, Err: ErrUnknownSortField}
+		}
+
+		for _, existing := range order {
+			if existing.Column == column {
+				return nil, &SortFieldError{Field: part, Err: ErrDuplicateSortField}
+			}
+		}
+
+		field.Column = column
+		order = append(order, field)
+	}
+
+	return order, nil
+}
+
+type SortFieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *SortFieldError) Error() string {
+	return e.Err.Error() + ": " + e.Field
+}
+
+func (e *SortFieldError) Unwrap() error {
+	return e.Err
+}
+
+// stableOrder добавляет id последним полем, иначе порядок строк с одинаковым ключом не определён
+// и keyset-пагинация теряет или дублирует строки
+func stableOrder(order []SortField) []SortField {
+	if len(order) == 0 {
+		order = defaultSort
+	}
+
+	for _, field := range order {
+		if field.Column == "id" {
+			return order
+		}
+	}
+
+	return append(order[:len(order):len(order)], SortField{Column: "id", Desc: order[0].Desc})
+}
+
+// sortExpression - SQL-выражение для колонки. Открытая подписка без end_date считается заканчивающейся позже любой другой
+func sortExpression(column string) string {
+	if column == "end_date" {
+		return "COALESCE(end_date, 'infinity'::date)"
+	}
+
+	return column
+}
@@ -5,21 +5,46 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 const (
-	SLATimeout = 5 * time.Second
+	SLATimeout       = 5 * time.Second
+	BatchSLATimeout  = 30 * time.Second
+	ExportSLATimeout = 10 * time.Minute
+
+	MaxBatchSize = 100
+	// MaxServiceLength - длина названия сервиса в символах, как у колонки service
+	MaxServiceLength = 255
 
 	TimeParseFormat = "01-2006"
 )
 
 type Subscription struct {
 	ID        string     `gorm:"primaryKey;type:char(40)"`
-	Service   string     `gorm:"type:varchar(255);uniqueIndex:index_subs"`
-	Cost      int32      `gorm:"type:int;not null"`
-	UserID    uuid.UUID  `gorm:"type:uuid;uniqueIndex:index_subs"`
-	StartDate time.Time  `gorm:"type:date;uniqueIndex:index_subs"`
-	EndDate   *time.Time `gorm:"type:date"`
+	Service   string     `gorm:"type:varchar(255)"`
+	Cost      int32      `gorm:"type:int;not null;index:ix_subs_cost"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index:ix_subs_user"`
+	StartDate time.Time  `gorm:"type:date"`
+	EndDate   *time.Time `gorm:"type:date;index:ix_subs_end_date"`
+
+	// ServiceID - сервис каталога, nil если название не сопоставлено с каталогом
+	ServiceID *string `gorm:"type:char(40);index:ix_subs_service_id"`
+	// CategoryID - категория подписки, при создании по умолчанию берётся из каталога
+	CategoryID *string `gorm:"type:char(40);index:ix_subs_category"`
+	// TrialStartDate и TrialEndDate - пробный период внутри подписки, оба заданы или оба nil. Дни списания в пробный период бесплатны
+	TrialStartDate *time.Time `gorm:"type:date"`
+	TrialEndDate   *time.Time `gorm:"type:date;index:ix_subs_trial_end"`
+	// BillingDay - якорный день списания 1..31, по умолчанию день начала. В коротких месяцах - последний день
+	BillingDay int `gorm:"type:smallint;not null;default:1"`
+	// CancelledAt - когда пользователь отменил подписку, см. Status
+	CancelledAt *time.Time `gorm:"type:timestamptz"`
+	// EndDateBeforeCancel - дата окончания до отмены, nil для бессрочной. Reactivate возвращает её вместо даты отмены
+	EndDateBeforeCancel *time.Time `gorm:"type:date"`
+	// Pauses хранятся в subscription_pauses и меняются только через Pause и Resume
+	Pauses []*Pause `gorm:"-"`
+	// Tags хранятся в subscription_tags. При обновлении nil оставляет метки как есть, пустой список их снимает
+	Tags []string `gorm:"-"`
 }
 
 type SubscriptionFilter struct {
@@ -29,28 +54,141 @@ type SubscriptionFilter struct {
 	StartDate *time.Time
 	EndDate   *time.Time
 
+	CostMin    *int32
+	CostMax    *int32
+	Services   []string
+	ServiceIDs []string
+	// CategoryID учитывает и все подкатегории, Tags - подписки хотя бы с одной из меток
+	CategoryID  *string
+	Tags        []string
+	UserIDs     []uuid.UUID
+	EndDateFrom *time.Time
+	EndDateTo   *time.Time
+	// Search - подстрока названия сервиса без учёта регистра
+	Search *string
+	// ActiveOnly и EndedOnly считаются относительно текущего дня
+	ActiveOnly bool
+	EndedOnly  bool
+	// Statuses оставляют подписки в этих состояниях на текущий день
+	Statuses []Status
+	// TrialEndFrom и TrialEndTo оставляют подписки, чей пробный период заканчивается в этом промежутке
+	TrialEndFrom *time.Time
+	TrialEndTo   *time.Time
+	// Proration - политика расчёта сумм в GetTotalCost и Aggregate, пустая - ProrationByBillingDay
+	Proration Proration
+
 	Limit  *int
 	Offset *int
-	Sort   *string
+	// Sort - поля сортировки из ParseSort, пустой список означает сортировку по умолчанию
+	Sort []SortField
+
+	// Cursor включает keyset-пагинацию, Offset при этом игнорируется
+	Cursor    *Cursor
+	SkipTotal bool
 }
 
 type SubscriptionsData struct {
 	Subscriptions []*Subscription
 	Total         int64
+	NextCursor    string
+	PrevCursor    string
+}
+
+type BatchMode string
+
+const (
+	// BatchModeAtomic - все операции в одной транзакции, первая ошибка откатывает всё
+	BatchModeAtomic BatchMode = "atomic"
+	// BatchModeBestEffort - каждая операция применяется независимо от остальных
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+type BatchUpdate struct {
+	ID           string
+	Subscription *Subscription
+}
+
+type BatchResult struct {
+	ID  string
+	Err error
+}
+
+// UserStart - пользователь и дата начала, вместе с сервисом они составляют уникальный ключ подписки
+type UserStart struct {
+	UserID    uuid.UUID
+	StartDate time.Time
 }
 
+// CreateCheck проверяет подписку перед вставкой по сохранённым подпискам того же пользователя existing.
+// Вызывается в транзакции создания под блокировкой пользователя, ошибка отменяет создание
+type CreateCheck func(subscription *Subscription, existing []*Subscription) error
+
+// CreateFollowUp выполняется в транзакции создания после вставки подписки, ошибка откатывает создание
+type CreateFollowUp func(tx *gorm.DB, subscription *Subscription) error
+
 type SubscriptionsRepo interface {
 	Create(subscription *Subscription) (string, error)
+	// CreateChecked создаёт подписку, проверяя её check под блокировкой пользователя, чтобы параллельные
+	// создания не обошли проверку. followUp может быть nil
+	CreateChecked(subscription *Subscription, check CreateCheck, followUp CreateFollowUp) (string, error)
 	ReadByParams(filter *SubscriptionFilter) (*Subscription, error)
+	// ListByUserStarts - подписки с любой из пар starts, без пауз и меток
+	ListByUserStarts(starts []UserStart) ([]*Subscription, error)
 	ReadByID(id string) (*Subscription, error)
 	Update(id string, subscriptionUpdated *Subscription) error
 	DeleteByID(id string) error
 	List(filter *SubscriptionFilter) (*SubscriptionsData, error)
 	GetTotalCost(filter *SubscriptionFilter) (int64, error)
+	Stream(filter *SubscriptionFilter, fn func(subscription *Subscription) error) error
+	Aggregate(query *AggregateQuery) ([]*AggregateRow, error)
+
+	// ListEndingTrials - подписки фильтра с пробным периодом, упорядоченные по его окончанию
+	ListEndingTrials(filter *SubscriptionFilter) ([]*Subscription, error)
+
+	// Pause назначает паузу подписке id. Пересекающиеся паузы и паузы вне срока подписки не допускаются
+	Pause(id string, pause *Pause) (string, error)
+	// Resume возобновляет подписку с дня day: ближайшая незавершённая пауза заканчивается днём раньше,
+	// а ещё не начавшаяся отменяется
+	Resume(id string, day time.Time) error
+
+	// Cancel и Reactivate проверяют переход по состоянию на день day и возвращают обновлённую подписку
+	Cancel(id string, day time.Time) (*Subscription, error)
+	Reactivate(id string, day time.Time) (*Subscription, error)
+
+	// Merge поглощает подписки ids подпиской id: её срок растягивается на их сроки, метки объединяются,
+	// а сами они удаляются вместе с паузами и списаниями. check вызывается под блокировкой всех подписок
+	Merge(id string, ids []string, check MergeCheck) (*Subscription, error)
+
+	ListTags() ([]*TagUsage, error)
+	// DeleteTag снимает метку со всех подписок и возвращает их число
+	DeleteTag(name string) (int64, error)
+
+	CreateBatch(subscriptions []*Subscription, mode BatchMode) ([]*BatchResult, error)
+	// CreateBatchChecked проверяет каждый элемент check как CreateChecked. В атомарном режиме проверка видит
+	// уже вставленные элементы пачки, в режиме best_effort - сохранённые
+	CreateBatchChecked(subscriptions []*Subscription, mode BatchMode, check CreateCheck) ([]*BatchResult, error)
+	UpdateBatch(updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error)
+	DeleteBatch(ids []string, mode BatchMode) ([]*BatchResult, error)
+}
+
+// ResolvedService - результат сопоставления названия сервиса с каталогом
+type ResolvedService struct {
+	// ID равен nil, если сервиса нет в каталоге, Name тогда содержит очищенное исходное название
+	ID         *string
+	Name       string
+	CategoryID *string
+}
+
+// ServiceResolver приводит название сервиса к каноническому виду
+type ServiceResolver interface {
+	Resolve(name string) (*ResolvedService, error)
 }
 
 var (
 	ErrAlreadyExists = errors.New("subscription already exists")
 	ErrWrongParams   = errors.New("wrong params")
 	ErrNotFound      = errors.New("subscription not found")
+	ErrSkipped       = errors.New("operation skipped because another operation in the batch failed")
+	// ErrUnknownCategory - подписка ссылается на несуществующую категорию
+	ErrUnknownCategory = errors.New("category does not exist")
 )
@@ -0,0 +1,132 @@
+package subs
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// billingDateSQL - день списания по якорю billing_day в месяце month, как BillingDate
+func billingDateSQL(month string) string {
+	return "(" + month + " + (LEAST(billing_day, EXTRACT(DAY FROM " + month + " + interval '1 month - 1 day')::int) - 1) * interval '1 day')::date"
+}
+
+// priceSQL - цена подписки в день day с учётом запланированных изменений из price_changes, как pricing.CostAt
+func priceSQL(day string) string {
+	return `COALESCE((SELECT pc.cost FROM price_changes pc WHERE pc.subscription_id = subscriptions.id
+		AND pc.effective_date <= ` + day + ` ORDER BY pc.effective_date DESC LIMIT 1), subscriptions.cost)`
+}
+
+// chargesCostSQL - сумма списаний подписки в периоде [@start, @end], та же логика, что в Subscription.Charges:
+// дни списания по якорю billing_day в пределах срока подписки без пробного периода и пауз, каждое по цене своего дня
+var chargesCostSQL = `(SELECT COALESCE(SUM(` + priceSQL("c.day") + `), 0) FROM generate_series(date_trunc('month', GREATEST(start_date, CAST(@start AS date))),
+		LEAST(end_date, CAST(@end AS date)), interval '1 month') AS m(month)
+	CROSS JOIN LATERAL (SELECT ` + billingDateSQL("m.month") + ` AS day) AS c
+	WHERE c.day BETWEEN GREATEST(start_date, CAST(@start AS date)) AND LEAST(COALESCE(end_date, CAST(@end AS date)), CAST(@end AS date))
+	AND ` + notFreeSQL("c.day") + `)`
+
+// periodDaysSQL - дни срока подписки в периоде [@start, @end]
+const periodDaysSQL = `(SELECT g.day::date AS day FROM generate_series(GREATEST(start_date, CAST(@start AS date)),
+		LEAST(COALESCE(end_date, CAST(@end AS date)), CAST(@end AS date)), interval '1 day') AS g(day)) AS d`
+
+// monthsCostSQL - полная цена за каждый месяц с оплачиваемыми днями, как в ProrationNone: цена на первый такой день месяца
+var monthsCostSQL = `(SELECT COALESCE(SUM(` + priceSQL("f.day") + `), 0) FROM (SELECT MIN(d.day) AS day FROM ` + periodDaysSQL + `
+	WHERE ` + notFreeSQL("d.day") + ` GROUP BY date_trunc('month', d.day)) AS f)`
+
+// cycleCostSQL - доли цены за оплачиваемые дни периода, как в ProrationDaily: день стоит цену дня, делённую на длину его расчётного периода
+var cycleCostSQL = `(SELECT COALESCE(SUM(` + priceSQL("d.day") + `::numeric / CASE WHEN d.day >= b.this_bill THEN b.next_bill - b.this_bill ELSE b.this_bill - b.prev_bill END), 0)
+	FROM ` + periodDaysSQL + `
+	CROSS JOIN LATERAL (SELECT date_trunc('month', d.day) AS month) AS m
+	CROSS JOIN LATERAL (SELECT ` + billingDateSQL("m.month") + ` AS this_bill,
+		` + billingDateSQL("(m.month - interval '1 month')") + ` AS prev_bill,
+		` + billingDateSQL("(m.month + interval '1 month')") + ` AS next_bill) AS b
+	WHERE ` + notFreeSQL("d.day") + `)`
+
+// notFreeSQL - условие, что день day не приходится на пробный период и паузы подписки
+func notFreeSQL(day string) string {
+	return `NOT (trial_start_date IS NOT NULL AND trial_end_date IS NOT NULL AND ` + day + ` BETWEEN trial_start_date AND trial_end_date)
+	AND NOT EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = subscriptions.id
+		AND ` + day + ` >= p.start_date AND (p.end_date IS NULL OR ` + day + ` <= p.end_date))`
+}
+
+// totalCostSQL - сумма подписки за период по политике proration с учётом изменений цены, округление по подписке как в Subscription.CostIn
+func totalCostSQL(proration Proration) string {
+	switch proration {
+	case ProrationNone:
+		return monthsCostSQL
+	case ProrationDaily:
+		return "ROUND(" + cycleCostSQL + ")"
+	default:
+		return chargesCostSQL
+	}
+}
+
+func (repo *SubscriptionsPgRepo) Aggregate(query *AggregateQuery) ([]*AggregateRow, error) {
+	repo.logger.Debugw("aggregate subscriptions", "query", query)
+
+	filter := query.Filter
+	if filter == nil {
+		filter = &SubscriptionFilter{}
+	}
+
+	if slices.Contains(query.Metrics, MetricTotalCost) && (filter.StartDate == nil || filter.EndDate == nil) {
+		repo.logger.Errorw("total cost requires a period", "filter", filter)
+		return nil, ErrWrongParams
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	db := repo.db.WithContext(ctx).Model(&Subscription{})
+	db = repo.filterQuery(db, filter)
+
+	var (
+		selects []string
+		groups  []string
+		args    []any
+	)
+	for _, groupBy := range query.GroupBy {
+		column := groupByColumns[groupBy]
+		group := column
+		switch groupBy {
+		case GroupByService:
+			// Варианты регистра одного сервиса - одна группа, как и в уникальном индексе
+			column, group = "MIN(service)", "lower(service)"
+		case GroupByRootCategory:
+			// root_category группируется по псевдониму: её выражение содержит коррелированный подзапрос
+			group = string(groupBy)
+		}
+		selects = append(selects, column+" AS "+string(groupBy))
+		groups = append(groups, group)
+	}
+
+	for _, metric := range query.Metrics {
+		switch metric {
+		case MetricCount:
+			selects = append(selects, "COUNT(*) AS count")
+		case MetricSumCost:
+			selects = append(selects, "COALESCE(SUM(cost), 0) AS sum_cost")
+		case MetricAvgPrice:
+			selects = append(selects, "AVG(cost)::float8 AS avg_price")
+		case MetricTotalCost:
+			selects = append(selects, "COALESCE(SUM("+totalCostSQL(filter.Proration)+"), 0)::bigint AS total_cost")
+			args = append(args, map[string]any{"start": *filter.StartDate, "end": *filter.EndDate})
+		}
+	}
+
+	db = db.Select(strings.Join(selects, ", "), args...)
+	if len(groups) > 0 {
+		db = db.Group(strings.Join(groups, ", ")).
+			Order(strings.Join(groups, ", ")).
+			Limit(MaxAggregateGroups)
+	}
+
+	var rows []*AggregateRow
+	if err := db.Scan(&rows).Error; err != nil {
+		repo.logger.Errorw("error aggregating subscriptions", "query", query, "error", err)
+		return nil, err
+	}
+
+	repo.logger.Infow("subscriptions aggregated", "query", query, "groups", len(rows))
+	return rows, nil
+}
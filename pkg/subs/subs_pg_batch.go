@@ -0,0 +1,119 @@
+package subs
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (repo *SubscriptionsPgRepo) CreateBatch(subscriptions []*Subscription, mode BatchMode) ([]*BatchResult, error) {
+	repo.logger.Debugw("create subscriptions batch", "count", len(subscriptions), "mode", mode)
+
+	return repo.createBatch(subscriptions, mode, nil)
+}
+
+func (repo *SubscriptionsPgRepo) CreateBatchChecked(subscriptions []*Subscription, mode BatchMode, check CreateCheck) ([]*BatchResult, error) {
+	repo.logger.Debugw("create checked subscriptions batch", "count", len(subscriptions), "mode", mode)
+
+	return repo.createBatch(subscriptions, mode, check)
+}
+
+func (repo *SubscriptionsPgRepo) createBatch(subscriptions []*Subscription, mode BatchMode, check CreateCheck) ([]*BatchResult, error) {
+	results, err := repo.runBatch(len(subscriptions), mode, func(db *gorm.DB, i int) (string, error) {
+		return repo.create(db, subscriptions[i], check, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ID откатившихся вставок никуда не записаны, отдавать их нельзя
+	for _, result := range results {
+		if errors.Is(result.Err, ErrSkipped) {
+			result.ID = ""
+		}
+	}
+
+	return results, nil
+}
+
+func (repo *SubscriptionsPgRepo) UpdateBatch(updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error) {
+	repo.logger.Debugw("update subscriptions batch", "count", len(updates), "mode", mode)
+
+	return repo.runBatch(len(updates), mode, func(db *gorm.DB, i int) (string, error) {
+		return updates[i].ID, repo.update(db, updates[i].ID, updates[i].Subscription)
+	})
+}
+
+func (repo *SubscriptionsPgRepo) DeleteBatch(ids []string, mode BatchMode) ([]*BatchResult, error) {
+	repo.logger.Debugw("delete subscriptions batch", "count", len(ids), "mode", mode)
+
+	return repo.runBatch(len(ids), mode, func(db *gorm.DB, i int) (string, error) {
+		return ids[i], repo.deleteByID(db, ids[i])
+	})
+}
+
+// errBatchAborted только прерывает транзакцию, наружу не отдаётся
+var errBatchAborted = errors.New("batch aborted")
+
+func (repo *SubscriptionsPgRepo) runBatch(size int, mode BatchMode, op func(db *gorm.DB, i int) (string, error)) ([]*BatchResult, error) {
+	if size == 0 || size > MaxBatchSize {
+		repo.logger.Errorw("invalid batch size", "size", size)
+		return nil, ErrWrongParams
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), BatchSLATimeout)
+	defer cancel()
+
+	results := make([]*BatchResult, size)
+
+	switch mode {
+	case BatchModeBestEffort:
+		db := repo.db.WithContext(ctx)
+		for i := 0; i < size; i++ {
+			id, err := op(db, i)
+			results[i] = &BatchResult{ID: id, Err: err}
+		}
+
+	case BatchModeAtomic:
+		txErr := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for i := 0; i < size; i++ {
+				id, err := op(tx, i)
+				results[i] = &BatchResult{ID: id, Err: err}
+				if err != nil {
+					return errBatchAborted
+				}
+			}
+			return nil
+		})
+
+		if txErr != nil && !errors.Is(txErr, errBatchAborted) {
+			repo.logger.Errorw("error committing batch", "error", txErr)
+			return nil, txErr
+		}
+
+		if txErr != nil {
+			markSkipped(results)
+		}
+
+	default:
+		repo.logger.Errorw("unknown batch mode", "mode", mode)
+		return nil, ErrWrongParams
+	}
+
+	repo.logger.Infow("batch processed", "size", size, "mode", mode)
+	return results, nil
+}
+
+// После отката транзакции успешные операции тоже не применились
+func markSkipped(results []*BatchResult) {
+	for i, result := range results {
+		if result == nil {
+			results[i] = &BatchResult{Err: ErrSkipped}
+			continue
+		}
+		if result.Err == nil {
+			result.Err = ErrSkipped
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package subs
+
+import (
+	"context"
+	"online-subs/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// statusSQL - Subscription.StatusAt для текущего дня на стороне БД
+const statusSQL = `CASE
+	WHEN end_date < current_date THEN CASE WHEN cancelled_at IS NOT NULL THEN 'cancelled' ELSE 'expired' END
+	WHEN EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = subscriptions.id
+		AND p.start_date <= current_date AND (p.end_date IS NULL OR p.end_date >= current_date)) THEN 'paused'
+	WHEN cancelled_at IS NOT NULL THEN 'cancellation_scheduled'
+	WHEN trial_start_date <= current_date AND trial_end_date >= current_date
+		AND start_date <= current_date THEN 'trial'
+	ELSE 'active'
+END`
+
+func (repo *SubscriptionsPgRepo) Cancel(id string, day time.Time) (*Subscription, error) {
+	repo.logger.Debugw("cancel subscription", "id", id, "day", day)
+
+	return repo.transition(id, func(tx *gorm.DB, subscription *Subscription) error {
+		return subscription.Cancel(day, time.Now().UTC())
+	})
+}
+
+func (repo *SubscriptionsPgRepo) Reactivate(id string, day time.Time) (*Subscription, error) {
+	repo.logger.Debugw("reactivate subscription", "id", id, "day", day)
+
+	return repo.transition(id, func(tx *gorm.DB, subscription *Subscription) error {
+		gap, err := subscription.Reactivate(day)
+		if err != nil || gap == nil {
+			return err
+		}
+
+		if gap.ID, err = utils.GenerateID(); err != nil {
+			return err
+		}
+		gap.SubscriptionID = id
+		subscription.Pauses = append(subscription.Pauses, gap)
+
+		return tx.Create(gap).Error
+	})
+}
+
+// transition применяет переход к заблокированной подписке и сохраняет дату окончания и отмену
+func (repo *SubscriptionsPgRepo) transition(id string, apply func(tx *gorm.DB, subscription *Subscription) error) (*Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	var subscription *Subscription
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if subscription, err = repo.lockSubscription(tx, id); err != nil {
+			return err
+		}
+
+		if err = apply(tx, subscription); err != nil {
+			return err
+		}
+
+		return tx.Model(&Subscription{}).Where("id = ?", id).Updates(map[string]any{
+			"end_date":               subscription.EndDate,
+			"cancelled_at":           subscription.CancelledAt,
+			"end_date_before_cancel": subscription.EndDateBeforeCancel,
+		}).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error changing subscription status", "id", id, "error", err)
+		return nil, err
+	}
+
+	if err = repo.loadTags(repo.db.WithContext(ctx), []*Subscription{subscription}); err != nil {
+		return nil, err
+	}
+
+	repo.logger.Infow("subscription status changed", "id", id, "endDate", subscription.EndDate, "cancelledAt", subscription.CancelledAt)
+	return subscription, nil
+}
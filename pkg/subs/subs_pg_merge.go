@@ -0,0 +1,82 @@
+package subs
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (repo *SubscriptionsPgRepo) Merge(id string, ids []string, check MergeCheck) (*Subscription, error) {
+	repo.logger.Debugw("merge subscriptions", "id", id, "ids", ids)
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	var target *Subscription
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Строки блокируются в порядке id, чтобы встречные слияния не взаимоблокировались
+		all := append([]string{id}, ids...)
+		var locked []*Subscription
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", all).Order("id").Find(&locked).Error
+		if err != nil {
+			return err
+		}
+		if len(locked) != len(all) {
+			return ErrNotFound
+		}
+
+		if err = repo.loadDetails(tx, locked); err != nil {
+			return err
+		}
+
+		merged := make([]*Subscription, 0, len(ids))
+		for _, subscription := range locked {
+			if subscription.ID == id {
+				target = subscription
+			} else {
+				merged = append(merged, subscription)
+			}
+		}
+		slices.SortFunc(merged, func(a, b *Subscription) int { return a.StartDate.Compare(b.StartDate) })
+
+		if err = check(target, merged); err != nil {
+			return err
+		}
+
+		for _, subscription := range merged {
+			target.Absorb(subscription)
+		}
+
+		// Удаление до обновления: у поглощаемой подписки может быть та же дата начала, что станет у целевой
+		if err = tx.Where("id IN ?", ids).Delete(&Subscription{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&Subscription{}).Where("id = ?", id).Updates(map[string]any{
+			"start_date":             target.StartDate,
+			"end_date":               target.EndDate,
+			"cancelled_at":           target.CancelledAt,
+			"end_date_before_cancel": target.EndDateBeforeCancel,
+			"category_id":            target.CategoryID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return repo.replaceTags(tx, id, target.Tags)
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error merging subscriptions", "id", id, "ids", ids, "error", err)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	repo.logger.Infow("subscriptions merged", "id", id, "merged", ids)
+	return target, nil
+}
@@ -0,0 +1,146 @@
+package subs
+
+import (
+	"context"
+	"errors"
+	"online-subs/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (repo *SubscriptionsPgRepo) Pause(id string, pause *Pause) (string, error) {
+	repo.logger.Debugw("pause subscription", "id", id, "pause", pause)
+
+	pauseID, err := utils.GenerateID()
+	if err != nil {
+		repo.logger.Errorw("error generating id", "err", err)
+		return "", err
+	}
+
+	pause.ID = pauseID
+	pause.SubscriptionID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	err = repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subscription, err := repo.lockSubscription(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = subscription.CanPause(pause); err != nil {
+			return err
+		}
+
+		return tx.Create(pause).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error pausing subscription", "id", id, "error", err)
+		return "", err
+	}
+
+	repo.logger.Infow("subscription paused", "id", id, "pause", pause)
+	return pause.ID, nil
+}
+
+func (repo *SubscriptionsPgRepo) Resume(id string, day time.Time) error {
+	repo.logger.Debugw("resume subscription", "id", id, "day", day)
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subscription, err := repo.lockSubscription(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var pause Pause
+		res := tx.Where("subscription_id = ? AND (end_date IS NULL OR end_date >= ?)", id, day).
+			Order("start_date").Limit(1).Find(&pause)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotPaused
+		}
+
+		if err = subscription.CanTransition(EventResume, pause.StartDate); err != nil {
+			return err
+		}
+
+		if !pause.StartDate.Before(day) {
+			return tx.Delete(&pause).Error
+		}
+
+		return tx.Model(&pause).Update("end_date", day.AddDate(0, 0, -1)).Error
+	})
+
+	if err != nil {
+		repo.logger.Errorw("error resuming subscription", "id", id, "error", err)
+		return err
+	}
+
+	repo.logger.Infow("subscription resumed", "id", id, "day", day)
+	return nil
+}
+
+// lockSubscription блокирует строку подписки до конца транзакции, чтобы параллельные переходы и паузы не пересеклись
+func (repo *SubscriptionsPgRepo) lockSubscription(tx *gorm.DB, id string) (*Subscription, error) {
+	var subscription Subscription
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&subscription)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, res.Error
+	}
+
+	if err := repo.loadPauses(tx, []*Subscription{&subscription}); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// loadDetails заполняет метки и паузы подписок
+func (repo *SubscriptionsPgRepo) loadDetails(db *gorm.DB, subscriptions []*Subscription) error {
+	if err := repo.loadTags(db, subscriptions); err != nil {
+		return err
+	}
+
+	return repo.loadPauses(db, subscriptions)
+}
+
+// loadPauses заполняет Pauses подписок одним запросом
+func (repo *SubscriptionsPgRepo) loadPauses(db *gorm.DB, subscriptions []*Subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subscriptions))
+	byID := make(map[string]*Subscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscription.Pauses = []*Pause{}
+		ids = append(ids, subscription.ID)
+		byID[subscription.ID] = subscription
+	}
+
+	var pauses []*Pause
+	if err := db.Where("subscription_id IN ?", ids).Order("start_date").Find(&pauses).Error; err != nil {
+		repo.logger.Errorw("error loading subscription pauses", "error", err)
+		return err
+	}
+
+	for _, pause := range pauses {
+		if subscription, ok := byID[pause.SubscriptionID]; ok {
+			subscription.Pauses = append(subscription.Pauses, pause)
+		}
+	}
+
+	return nil
+}
@@ -4,27 +4,86 @@ import (
 	"context"
 	"errors"
 	"online-subs/pkg/utils"
+	"slices"
+	"strings"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type SubscriptionsPgRepo struct {
-	logger *zap.SugaredLogger
-	db     *gorm.DB
+	logger   *zap.SugaredLogger
+	db       *gorm.DB
+	resolver ServiceResolver
+	prices   PriceSource
 }
 
-func NewSubscriptionsPgRepo(logger *zap.SugaredLogger, db *gorm.DB) *SubscriptionsPgRepo {
+// NewSubscriptionsPgRepo - resolver может быть nil, тогда названия сервисов сохраняются как есть.
+// prices может быть nil, тогда суммы за период считаются по текущим ценам
+func NewSubscriptionsPgRepo(logger *zap.SugaredLogger, db *gorm.DB, resolver ServiceResolver, prices PriceSource) *SubscriptionsPgRepo {
 	return &SubscriptionsPgRepo{
-		logger: logger,
-		db:     db,
+		logger:   logger,
+		db:       db,
+		resolver: resolver,
+		prices:   prices,
 	}
 }
 
+// resolveService подставляет каноническое название и ServiceID из каталога
+func (repo *SubscriptionsPgRepo) resolveService(subscription *Subscription) (*ResolvedService, error) {
+	if repo.resolver == nil || subscription.Service == "" {
+		return nil, nil
+	}
+
+	resolved, err := repo.resolver.Resolve(subscription.Service)
+	if err != nil {
+		repo.logger.Errorw("error resolving service", "service", subscription.Service, "error", err)
+		return nil, err
+	}
+
+	subscription.Service = resolved.Name
+	subscription.ServiceID = resolved.ID
+	return resolved, nil
+}
+
+// resolveServiceName используется фильтрами, ошибка каталога не должна ломать поиск
+func (repo *SubscriptionsPgRepo) resolveServiceName(name string) string {
+	if repo.resolver == nil {
+		return name
+	}
+
+	resolved, err := repo.resolver.Resolve(name)
+	if err != nil {
+		repo.logger.Warnw("error resolving service name for filter", "service", name, "error", err)
+		return name
+	}
+
+	return resolved.Name
+}
+
 func (repo *SubscriptionsPgRepo) Create(subscription *Subscription) (string, error) {
 	repo.logger.Debugw("create subscription", "subscription", subscription)
 
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	return repo.create(repo.db.WithContext(ctx), subscription, nil, nil)
+}
+
+func (repo *SubscriptionsPgRepo) CreateChecked(subscription *Subscription, check CreateCheck, followUp CreateFollowUp) (string, error) {
+	repo.logger.Debugw("create checked subscription", "subscription", subscription)
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	return repo.create(repo.db.WithContext(ctx), subscription, check, followUp)
+}
+
+// create вставляет подписку с метками. check и followUp могут быть nil, их ошибки возвращаются как есть
+func (repo *SubscriptionsPgRepo) create(db *gorm.DB, subscription *Subscription, check CreateCheck,
+	followUp CreateFollowUp) (string, error) {
 	id, err := utils.GenerateID()
 	if err != nil {
 		repo.logger.Errorw("error generating id", "err", err)
@@ -32,26 +91,85 @@ func (repo *SubscriptionsPgRepo) Create(subscription *Subscription) (string, err
 	}
 
 	subscription.ID = id
+	subscription.BillingDay = subscription.Anchor()
 
-	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
-	defer cancel()
+	resolved, err := repo.resolveService(subscription)
+	if err != nil {
+		return "", err
+	}
+	// Категория каталога - только значение по умолчанию, явно указанная важнее
+	if resolved != nil && subscription.CategoryID == nil {
+		subscription.CategoryID = resolved.CategoryID
+	}
 
-	upsertRes := repo.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Omit("end_date").Create(subscription)
+	var rejected error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if check != nil {
+			existing, err := repo.lockUserSubscriptions(tx, subscription.UserID)
+			if err != nil {
+				return err
+			}
+			if rejected = check(subscription, existing); rejected != nil {
+				return rejected
+			}
+		}
 
-	if upsertRes.Error != nil {
-		repo.logger.Errorw("error upserting subscription", "error", upsertRes.Error, "subscription", subscription)
-		return "", upsertRes.Error
-	}
+		upsertRes := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(subscription)
+		if upsertRes.Error != nil {
+			return upsertRes.Error
+		}
+
+		if upsertRes.RowsAffected != 1 {
+			return ErrAlreadyExists
+		}
+
+		if err := repo.replaceTags(tx, subscription.ID, subscription.Tags); err != nil {
+			return err
+		}
+
+		if followUp != nil {
+			rejected = followUp(tx, subscription)
+		}
+		return rejected
+	})
 
-	if upsertRes.RowsAffected != 1 {
-		repo.logger.Warnw("failed upserting subscription", "error", ErrAlreadyExists, "subscription", subscription)
-		return "", ErrAlreadyExists
+	if err != nil {
+		if rejected != nil {
+			repo.logger.Warnw("subscription creation rejected", "error", rejected, "subscription", subscription)
+			return "", rejected
+		}
+		if errors.Is(err, ErrAlreadyExists) {
+			repo.logger.Warnw("failed upserting subscription", "error", err, "subscription", subscription)
+			return "", ErrAlreadyExists
+		}
+		repo.logger.Errorw("error upserting subscription", "error", err, "subscription", subscription)
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return "", ErrUnknownCategory
+		}
+		return "", err
 	}
 
 	repo.logger.Infow("subscription created", "subscription", subscription)
 	return subscription.ID, nil
 }
 
+// lockUserSubscriptions блокирует создание подписок пользователя до конца транзакции tx и возвращает его подписки.
+// Блокировка рекомендательная: строк, которые можно было бы заблокировать, у нового пользователя ещё нет
+func (repo *SubscriptionsPgRepo) lockUserSubscriptions(tx *gorm.DB, userID uuid.UUID) ([]*Subscription, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", userID.String()).Error; err != nil {
+		repo.logger.Errorw("error locking user subscriptions", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	var existing []*Subscription
+	if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		repo.logger.Errorw("error reading user subscriptions", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	return existing, repo.loadPauses(tx, existing)
+}
+
 func (repo *SubscriptionsPgRepo) ReadByParams(filter *SubscriptionFilter) (*Subscription, error) {
 	repo.logger.Debugw("read subscription by params", "filter", filter)
 
@@ -65,8 +183,8 @@ func (repo *SubscriptionsPgRepo) ReadByParams(filter *SubscriptionFilter) (*Subs
 	defer cancel()
 
 	var subscription Subscription
-	res := repo.db.WithContext(ctx).Where("service = ? AND start_date = ? AND user_id = ?",
-		*filter.Service, *filter.StartDate, *filter.UserID).First(&subscription)
+	res := repo.db.WithContext(ctx).Where("lower(service) = lower(?) AND start_date = ? AND user_id = ?",
+		repo.resolveServiceName(*filter.Service), *filter.StartDate, *filter.UserID).First(&subscription)
 
 	if res.Error != nil {
 		repo.logger.Errorw("error finding subscription by params", "error", res.Error, "filter", filter)
@@ -76,10 +194,41 @@ func (repo *SubscriptionsPgRepo) ReadByParams(filter *SubscriptionFilter) (*Subs
 		return nil, res.Error
 	}
 
+	if err := repo.loadDetails(repo.db.WithContext(ctx), []*Subscription{&subscription}); err != nil {
+		return nil, err
+	}
+
 	repo.logger.Debugw("subscription found", "subscription", subscription)
 	return &subscription, nil
 }
 
+func (repo *SubscriptionsPgRepo) ListByUserStarts(starts []UserStart) ([]*Subscription, error) {
+	repo.logger.Debugw("list subscriptions by user and start date", "count", len(starts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), BatchSLATimeout)
+	defer cancel()
+
+	var subscriptions []*Subscription
+	for start := 0; start < len(starts); start += MaxBatchSize {
+		chunk := starts[start:min(start+MaxBatchSize, len(starts))]
+
+		pairs := make([][]any, 0, len(chunk))
+		for _, key := range chunk {
+			pairs = append(pairs, []any{key.UserID, key.StartDate.Format(DateFormat)})
+		}
+
+		var found []*Subscription
+		res := repo.db.WithContext(ctx).Where("(user_id, start_date) IN ?", pairs).Find(&found)
+		if res.Error != nil {
+			repo.logger.Errorw("error listing subscriptions by user and start date", "error", res.Error)
+			return nil, res.Error
+		}
+		subscriptions = append(subscriptions, found...)
+	}
+
+	return subscriptions, nil
+}
+
 func (repo *SubscriptionsPgRepo) ReadByID(id string) (*Subscription, error) {
 	repo.logger.Debugw("read subscription by id", "id", id)
 
@@ -97,6 +246,10 @@ func (repo *SubscriptionsPgRepo) ReadByID(id string) (*Subscription, error) {
 		return nil, res.Error
 	}
 
+	if err := repo.loadDetails(repo.db.WithContext(ctx), []*Subscription{&subscription}); err != nil {
+		return nil, err
+	}
+
 	repo.logger.Infow("subscription found", "subscription", subscription)
 	return &subscription, nil
 }
@@ -107,32 +260,100 @@ func (repo *SubscriptionsPgRepo) Update(id string, subscriptionUpdated *Subscrip
 	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
 	defer cancel()
 
-	res := repo.db.WithContext(ctx).Model(&Subscription{}).Where("id = ?", id).Omit("id").Updates(subscriptionUpdated)
+	return repo.update(repo.db.WithContext(ctx), id, subscriptionUpdated)
+}
 
-	if res.Error != nil {
-		repo.logger.Errorw("error updating subscription", "error", res.Error, "subscription", subscriptionUpdated)
-		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+func (repo *SubscriptionsPgRepo) update(db *gorm.DB, id string, subscriptionUpdated *Subscription) error {
+	if _, err := repo.resolveService(subscriptionUpdated); err != nil {
+		return err
+	}
+	// Дата окончания от пользователя переживает отмену: Reactivate вернёт её
+	subscriptionUpdated.EndDateBeforeCancel = subscriptionUpdated.EndDate
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&Subscription{}).Where("id = ?", id).Omit("id")
+		// При смене названия service_id обновляется явно, в том числе на NULL для сервиса вне каталога
+		if subscriptionUpdated.Service != "" {
+			query = query.Select(updatedColumns(subscriptionUpdated))
+		}
+
+		res := query.Updates(subscriptionUpdated)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
 			return ErrNotFound
 		}
-		return res.Error
-	}
 
-	if res.RowsAffected == 0 {
-		repo.logger.Warnw("failed subscription update", "subscription", subscriptionUpdated)
-		return ErrNotFound
+		if subscriptionUpdated.Tags == nil {
+			return nil
+		}
+
+		return repo.replaceTags(tx, id, subscriptionUpdated.Tags)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			repo.logger.Warnw("failed subscription update", "subscription", subscriptionUpdated)
+			return ErrNotFound
+		}
+		repo.logger.Errorw("error updating subscription", "error", err, "subscription", subscriptionUpdated)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyExists
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return ErrUnknownCategory
+		}
+		return err
 	}
 
 	repo.logger.Infow("subscription updated", "subscription", subscriptionUpdated)
 	return nil
 }
 
+// updatedColumns - ненулевые поля частичного обновления, как их выбирает Updates, плюс service_id
+func updatedColumns(subscription *Subscription) []string {
+	columns := []string{"service", "service_id"}
+	if subscription.Cost != 0 {
+		columns = append(columns, "cost")
+	}
+	if subscription.UserID != uuid.Nil {
+		columns = append(columns, "user_id")
+	}
+	if !subscription.StartDate.IsZero() {
+		columns = append(columns, "start_date")
+	}
+	if subscription.BillingDay != 0 {
+		columns = append(columns, "billing_day")
+	}
+	if subscription.EndDate != nil {
+		columns = append(columns, "end_date", "end_date_before_cancel")
+	}
+	if subscription.CategoryID != nil {
+		columns = append(columns, "category_id")
+	}
+	if subscription.TrialStartDate != nil {
+		columns = append(columns, "trial_start_date", "trial_end_date")
+	}
+
+	return columns
+}
+
 func (repo *SubscriptionsPgRepo) DeleteByID(id string) error {
 	repo.logger.Debugw("delete subscription", "id", id)
 
 	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
 	defer cancel()
 
-	res := repo.db.WithContext(ctx).Where("id = ?", id).Delete(&Subscription{})
+	return repo.deleteByID(repo.db.WithContext(ctx), id)
+}
+
+func (repo *SubscriptionsPgRepo) deleteByID(db *gorm.DB, id string) error {
+	res := db.Where("id = ?", id).Delete(&Subscription{})
 
 	if res.Error != nil {
 		repo.logger.Errorw("error deleting subscription", "id", id, "error", res.Error)
@@ -167,29 +388,42 @@ func (repo *SubscriptionsPgRepo) List(filter *SubscriptionFilter) (*Subscription
 	query = repo.filterQuery(query, filter)
 
 	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		repo.logger.Warnw("failed to count requests with filter", "err", err, "filter", filter)
-		return nil, err
-	}
-	if total == 0 {
-		repo.logger.Debugw("no subscriptions found with provided filter", "filter", filter)
-		return &SubscriptionsData{
-			Subscriptions: []*Subscription{},
-			Total:         0,
-		}, nil
+	if !filter.SkipTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			repo.logger.Warnw("failed to count requests with filter", "err", err, "filter", filter)
+			return nil, err
+		}
+		if total == 0 {
+			repo.logger.Debugw("no subscriptions found with provided filter", "filter", filter)
+			return &SubscriptionsData{
+				Subscriptions: []*Subscription{},
+				Total:         0,
+			}, nil
+		}
 	}
 
-	var sort string
-	if filter.Sort != nil {
-		sort = *filter.Sort
-	}
+	order := stableOrder(filter.Sort)
 
-	order := repo.getSubsListOrder(sort)
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if filter.Cursor != nil {
+		condition, args, err := keysetCondition(order, filter.Cursor)
+		if err != nil {
+			repo.logger.Warnw("invalid cursor", "cursor", filter.Cursor, "error", err)
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
 
-	query = query.Order(order)
+	query = query.Order(orderClause(order, backward))
 
-	if filter.Limit != nil && filter.Offset != nil {
-		query = repo.setLimitAndOffset(query, *filter.Limit, *filter.Offset)
+	// Лишняя строка нужна только чтобы понять, есть ли продолжение в направлении обхода
+	limit := 0
+	if filter.Limit != nil && *filter.Limit > 0 {
+		limit = *filter.Limit
+		query = query.Limit(limit + 1)
+	}
+	if filter.Cursor == nil && filter.Offset != nil && *filter.Offset > 0 {
+		query = query.Offset(*filter.Offset)
 	}
 
 	var subscriptions []*Subscription
@@ -198,18 +432,122 @@ func (repo *SubscriptionsPgRepo) List(filter *SubscriptionFilter) (*Subscription
 		return nil, err
 	}
 
-	repo.logger.Infow("subscriptions found with filter", "filter", filter)
-	return &SubscriptionsData{
+	hasMore := limit > 0 && len(subscriptions) > limit
+	if hasMore {
+		subscriptions = subscriptions[:limit]
+	}
+
+	if err := repo.loadDetails(repo.db.WithContext(ctx), subscriptions); err != nil {
+		return nil, err
+	}
+
+	if backward {
+		slices.Reverse(subscriptions)
+	}
+
+	data := &SubscriptionsData{
 		Subscriptions: subscriptions,
 		Total:         total,
-	}, nil
+	}
+
+	if len(subscriptions) > 0 {
+		hasNext := hasMore
+		hasPrev := filter.Cursor != nil || (filter.Offset != nil && *filter.Offset > 0)
+		if backward {
+			hasNext, hasPrev = true, hasMore
+		}
+
+		if hasNext {
+			data.NextCursor = EncodeCursor(cursorFromSubscription(subscriptions[len(subscriptions)-1], order, false))
+		}
+		if hasPrev {
+			data.PrevCursor = EncodeCursor(cursorFromSubscription(subscriptions[0], order, true))
+		}
+	}
+
+	repo.logger.Infow("subscriptions found with filter", "filter", filter)
+	return data, nil
+}
+
+// streamChunkSize - по сколько строк Stream подгружает паузы
+const streamChunkSize = 500
+
+// Stream построчно читает результат фильтра через курсор БД, не загружая его в память целиком
+func (repo *SubscriptionsPgRepo) Stream(filter *SubscriptionFilter, fn func(subscription *Subscription) error) error {
+	repo.logger.Debugw("stream subscriptions", "filter", filter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ExportSLATimeout)
+	defer cancel()
+
+	query := repo.db.WithContext(ctx).Model(&Subscription{})
+	query = repo.filterQuery(query, filter)
+
+	query = query.Order(orderClause(stableOrder(filter.Sort), false))
+
+	rows, err := query.Rows()
+	if err != nil {
+		repo.logger.Errorw("error streaming subscriptions", "filter", filter, "error", err)
+		return err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			repo.logger.Warnw("error closing subscriptions rows", "error", errClose)
+		}
+	}()
+
+	// Паузы нужны потребителям для расчёта трат, они подгружаются пачками по streamChunkSize строк
+	var count int
+	chunk := make([]*Subscription, 0, streamChunkSize)
+	flush := func() error {
+		if err := repo.loadPauses(repo.db.WithContext(ctx), chunk); err != nil {
+			return err
+		}
+
+		for _, subscription := range chunk {
+			if err := fn(subscription); err != nil {
+				repo.logger.Warnw("subscriptions stream stopped by consumer", "error", err, "streamed", count)
+				return err
+			}
+			count++
+		}
+
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		var subscription Subscription
+		if err = repo.db.ScanRows(rows, &subscription); err != nil {
+			repo.logger.Errorw("error scanning subscription", "filter", filter, "error", err)
+			return err
+		}
+
+		chunk = append(chunk, &subscription)
+		if len(chunk) == streamChunkSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.Errorw("error iterating subscriptions", "filter", filter, "error", err)
+		return err
+	}
+
+	if err = flush(); err != nil {
+		return err
+	}
+
+	repo.logger.Infow("subscriptions streamed", "filter", filter, "count", count)
+	return nil
 }
 
 func (repo *SubscriptionsPgRepo) filterQuery(query *gorm.DB, filter *SubscriptionFilter) *gorm.DB {
 	repo.logger.Debugw("filter subscriptions", "filter", filter)
 
 	if filter.Service != nil {
-		query = query.Where("service = ?", *filter.Service)
+		query = query.Where("lower(service) = lower(?)", repo.resolveServiceName(*filter.Service))
 	}
 
 	if filter.UserID != nil {
@@ -220,55 +558,136 @@ func (repo *SubscriptionsPgRepo) filterQuery(query *gorm.DB, filter *Subscriptio
 		query = query.Where("cost = ?", *filter.Cost)
 	}
 
+	// Подписка, приостановленная на весь период, в него не попадает
 	if filter.StartDate != nil && filter.EndDate != nil {
 		query = query.Where("start_date <= ?", *filter.EndDate).
-			Where("(end_date IS NULL OR end_date >= ?)", *filter.StartDate)
+			Where("(end_date IS NULL OR end_date >= ?)", *filter.StartDate).
+			Where(notPausedSQL, *filter.StartDate, *filter.EndDate)
 	} else if filter.StartDate != nil {
 		query = query.Where("start_date <= ?", *filter.StartDate).
-			Where("(end_date IS NULL OR end_date >= ?)", *filter.StartDate)
+			Where("(end_date IS NULL OR end_date >= ?)", *filter.StartDate).
+			Where(notPausedSQL, *filter.StartDate, *filter.StartDate)
 	} else if filter.EndDate != nil {
 		query = query.Where("start_date <= ?", *filter.EndDate).
-			Where("(end_date IS NULL OR end_date >= ?)", *filter.EndDate)
+			Where("(end_date IS NULL OR end_date >= ?)", *filter.EndDate).
+			Where(notPausedSQL, *filter.EndDate, *filter.EndDate)
+	}
+
+	if filter.CostMin != nil {
+		query = query.Where("cost >= ?", *filter.CostMin)
+	}
+
+	if filter.CostMax != nil {
+		query = query.Where("cost <= ?", *filter.CostMax)
+	}
+
+	if len(filter.Services) > 0 {
+		services := make([]string, 0, len(filter.Services))
+		for _, service := range filter.Services {
+			services = append(services, strings.ToLower(repo.resolveServiceName(service)))
+		}
+		query = query.Where("lower(service) IN ?", services)
+	}
+
+	if len(filter.ServiceIDs) > 0 {
+		query = query.Where("service_id IN ?", filter.ServiceIDs)
+	}
+
+	if filter.CategoryID != nil {
+		query = query.Where("category_id IN ("+categorySubtreeSQL+")", *filter.CategoryID)
+	}
+
+	if len(filter.Tags) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = subscriptions.id AND t.name IN ?)",
+			filter.Tags)
+	}
+
+	if len(filter.UserIDs) > 0 {
+		query = query.Where("user_id IN ?", filter.UserIDs)
+	}
+
+	if filter.EndDateFrom != nil {
+		query = query.Where("end_date >= ?", *filter.EndDateFrom)
+	}
+
+	if filter.EndDateTo != nil {
+		query = query.Where("end_date <= ?", *filter.EndDateTo)
+	}
+
+	// ILIKE с подстрокой обслуживается триграммным GIN-индексом ix_subs_service_trgm
+	if filter.Search != nil {
+		query = query.Where("service ILIKE ?", "%"+likeEscaper.Replace(*filter.Search)+"%")
+	}
+
+	// end_date - последний день подписки, в него она ещё активна
+	if filter.ActiveOnly {
+		query = query.Where("(end_date IS NULL OR end_date >= current_date)").
+			Where(notPausedSQL, gorm.Expr("current_date"), gorm.Expr("current_date"))
+	}
+
+	if filter.EndedOnly {
+		query = query.Where("end_date < current_date")
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		query = query.Where("("+statusSQL+") IN ?", statuses)
+	}
+
+	if filter.TrialEndFrom != nil {
+		query = query.Where("trial_end_date >= ?", *filter.TrialEndFrom)
+	}
+
+	if filter.TrialEndTo != nil {
+		query = query.Where("trial_end_date <= ?", *filter.TrialEndTo)
 	}
 
 	return query
 }
 
-func (repo *SubscriptionsPgRepo) getSubsListOrder(sort string) string {
-	repo.logger.Debugw("get subs list", "sort", sort)
-
-	var order string
-	switch sort {
-	case "cost_asc":
-		order = "cost ASC"
-	case "cost_desc":
-		order = "cost DESC"
-	case "service_asc":
-		order = "service ASC"
-	case "service_desc":
-		order = "service DESC"
-	case "start_date":
-		order = "start_date ASC"
-	default:
-		order = "start_date DESC"
-	}
-
-	return order
-}
+func (repo *SubscriptionsPgRepo) ListEndingTrials(filter *SubscriptionFilter) ([]*Subscription, error) {
+	repo.logger.Debugw("list ending trials", "filter", filter)
 
-func (repo *SubscriptionsPgRepo) setLimitAndOffset(query *gorm.DB, limit, offset int) *gorm.DB {
-	repo.logger.Debugw("set limit and offset", "limit", limit, "offset", offset)
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	query := repo.db.WithContext(ctx).Model(&Subscription{}).Where("trial_end_date IS NOT NULL")
+	query = repo.filterQuery(query, filter).Order("trial_end_date").Order("id")
+
+	if filter.Limit != nil && *filter.Limit > 0 {
+		query = query.Limit(*filter.Limit)
 	}
-	if offset > 0 {
-		query = query.Offset(offset)
+
+	var subscriptions []*Subscription
+	if err := query.Find(&subscriptions).Error; err != nil {
+		repo.logger.Errorw("error listing ending trials", "filter", filter, "error", err)
+		return nil, err
 	}
 
-	return query
+	if err := repo.loadDetails(repo.db.WithContext(ctx), subscriptions); err != nil {
+		return nil, err
+	}
+
+	repo.logger.Infow("ending trials found", "filter", filter, "count", len(subscriptions))
+	return subscriptions, nil
 }
 
+// notPausedSQL отсекает подписки с паузой на весь период [?, ?]
+const notPausedSQL = `NOT EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = subscriptions.id
+	AND p.start_date <= ? AND (p.end_date IS NULL OR p.end_date >= ?))`
+
+// categorySubtreeSQL - ID категории и всех её потомков
+const categorySubtreeSQL = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION ALL
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	) SELECT id FROM subtree`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (repo *SubscriptionsPgRepo) GetTotalCost(filter *SubscriptionFilter) (int64, error) {
 	repo.logger.Debugw("get total cost of subscriptions", "filter", filter)
 
@@ -291,14 +710,18 @@ func (repo *SubscriptionsPgRepo) GetTotalCost(filter *SubscriptionFilter) (int64
 		return 0, err
 	}
 
-	var sumCost int64
-	for _, sub := range subs {
-		months := utils.GetOverlappedMonths(*filter.StartDate, *filter.EndDate, sub.StartDate, sub.EndDate)
-		if months > 0 {
-			sumCost += int64(months) * int64(sub.Cost)
-		}
+	if err := repo.loadPauses(repo.db.WithContext(ctx), subs); err != nil {
+		return 0, err
+	}
+
+	prices, err := LoadPrices(repo.prices, subs)
+	if err != nil {
+		repo.logger.Errorw("error loading prices", "filter", filter, "error", err)
+		return 0, err
 	}
 
+	sumCost := TotalCost(subs, *filter.StartDate, *filter.EndDate, filter.Proration, prices)
+
 	repo.logger.Infow("total cost calculated", "sumCost", sumCost, "filter", filter)
 	return sumCost, nil
 }
@@ -0,0 +1,88 @@
+package subs
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// replaceTags заменяет метки подписки, tags уже нормализованы
+func (repo *SubscriptionsPgRepo) replaceTags(db *gorm.DB, subscriptionID string, tags []string) error {
+	if err := db.Where("subscription_id = ?", subscriptionID).Delete(&Tag{}).Error; err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]*Tag, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, &Tag{SubscriptionID: subscriptionID, Name: tag})
+	}
+
+	return db.Create(&rows).Error
+}
+
+// loadTags заполняет Tags подписок одним запросом
+func (repo *SubscriptionsPgRepo) loadTags(db *gorm.DB, subscriptions []*Subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subscriptions))
+	byID := make(map[string]*Subscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscription.Tags = []string{}
+		ids = append(ids, subscription.ID)
+		byID[subscription.ID] = subscription
+	}
+
+	var tags []*Tag
+	if err := db.Where("subscription_id IN ?", ids).Order("name").Find(&tags).Error; err != nil {
+		repo.logger.Errorw("error loading subscription tags", "error", err)
+		return err
+	}
+
+	for _, tag := range tags {
+		if subscription, ok := byID[tag.SubscriptionID]; ok {
+			subscription.Tags = append(subscription.Tags, tag.Name)
+		}
+	}
+
+	return nil
+}
+
+func (repo *SubscriptionsPgRepo) ListTags() ([]*TagUsage, error) {
+	repo.logger.Debugw("list tags")
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	usages := []*TagUsage{}
+	res := repo.db.WithContext(ctx).Model(&Tag{}).Select("name, COUNT(*) AS count").
+		Group("name").Order("name").Scan(&usages)
+
+	if res.Error != nil {
+		repo.logger.Errorw("error listing tags", "error", res.Error)
+		return nil, res.Error
+	}
+
+	return usages, nil
+}
+
+func (repo *SubscriptionsPgRepo) DeleteTag(name string) (int64, error) {
+	repo.logger.Debugw("delete tag", "tag", name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), SLATimeout)
+	defer cancel()
+
+	res := repo.db.WithContext(ctx).Where("name = ?", NormalizeTag(name)).Delete(&Tag{})
+	if res.Error != nil {
+		repo.logger.Errorw("error deleting tag", "tag", name, "error", res.Error)
+		return 0, res.Error
+	}
+
+	repo.logger.Infow("tag deleted", "tag", name, "subscriptions", res.RowsAffected)
+	return res.RowsAffected, nil
+}
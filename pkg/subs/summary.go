@@ -0,0 +1,61 @@
+package subs
+
+import (
+	"slices"
+	"time"
+)
+
+// UserSummary - сводка по подпискам пользователя на месяц Month
+type UserSummary struct {
+	Month time.Time
+
+	ActiveCount   int
+	MonthlySpend  int64
+	YearSpend     int64
+	PreviousSpend int64
+	// MostExpensive - самая дорогая из активных подписок, nil если активных нет
+	MostExpensive *Subscription
+	// EndingSoon - активные подписки, заканчивающиеся в ближайшие EndingWithin месяцев, по дате окончания
+	EndingSoon []*Subscription
+}
+
+// Summarize считает сводку по подпискам одного пользователя. Траты считаются так же, как в GetTotalCost, по политике proration
+// за месяц at целиком, год - с января по месяц at включительно, с ценами prices. Активность - на день at
+func Summarize(subscriptions []*Subscription, prices map[string]PriceFunc, at time.Time, endingWithin int,
+	proration Proration) *UserSummary {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	month := MonthStart(day)
+	previousMonth := month.AddDate(0, -1, 0)
+	yearStart := time.Date(month.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	endingBefore := month.AddDate(0, endingWithin, 0)
+
+	summary := &UserSummary{
+		Month:         month,
+		MonthlySpend:  TotalCost(subscriptions, month, MonthEnd(month), proration, prices),
+		YearSpend:     TotalCost(subscriptions, yearStart, MonthEnd(month), proration, prices),
+		PreviousSpend: TotalCost(subscriptions, previousMonth, MonthEnd(previousMonth), proration, prices),
+		EndingSoon:    []*Subscription{},
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.ActiveOn(day) {
+			continue
+		}
+
+		summary.ActiveCount++
+
+		if summary.MostExpensive == nil || subscription.Cost > summary.MostExpensive.Cost {
+			summary.MostExpensive = subscription
+		}
+
+		if subscription.EndDate != nil && subscription.EndDate.Before(endingBefore) {
+			summary.EndingSoon = append(summary.EndingSoon, subscription)
+		}
+	}
+
+	slices.SortFunc(summary.EndingSoon, func(a, b *Subscription) int {
+		return a.EndDate.Compare(*b.EndDate)
+	})
+
+	return summary
+}
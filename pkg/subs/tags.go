@@ -0,0 +1,56 @@
+package subs
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 64
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// Tag - метка подписки. Хранится нормализованной: в нижнем регистре, без лишних пробелов
+type Tag struct {
+	SubscriptionID string `gorm:"primaryKey;type:char(40)"`
+	Name           string `gorm:"primaryKey;type:varchar(64);index:ix_subscription_tags_name"`
+}
+
+func (Tag) TableName() string {
+	return "subscription_tags"
+}
+
+// TagUsage - метка и число подписок с ней
+type TagUsage struct {
+	Name  string
+	Count int64
+}
+
+// NormalizeTag приводит метку к виду, в котором она хранится и ищется
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags нормализует, сортирует и убирает повторы. Пустой список допустим и означает "без меток"
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name := NormalizeTag(tag)
+		if name == "" || len([]rune(name)) > MaxTagLength {
+			return nil, ErrInvalidTag
+		}
+		normalized = append(normalized, name)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > MaxTags {
+		return nil, ErrInvalidTag
+	}
+
+	return normalized, nil
+}